@@ -0,0 +1,110 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Uses of app secrets, as reported to SecretMetrics.
+const (
+	SecretUseAppSecretProof = "appsecret_proof"
+	SecretUseWebhook        = "webhook"
+)
+
+// SecretMetrics is implemented by Metrics which also export which app secret matched, so that it is known when a
+// previous secret is no longer used and can be removed.
+type SecretMetrics interface {
+	Metrics
+
+	// ObserveSecretMatch is called with the index of the app secret which matched, 0 for the current secret and
+	// 1 or more for previous secrets.
+	ObserveSecretMatch(use string, index int)
+}
+
+// WithAppSecretProof adds an appsecret_proof, signing the access token with the app secret, to each API call.
+// Previous app secrets can be set during secret rotation, if Facebook rejects the proof of one secret the API call
+// is sent again with the next, and the secret accepted is used first from then on.
+// See https://developers.facebook.com/docs/graph-api/securing-requests#appsecret_proof
+func WithAppSecretProof(secret string, previous ...string) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.appSecrets = &appSecrets{secrets: append([]string{secret}, previous...)}
+		return nil
+	}
+}
+
+type appSecrets struct {
+	secrets []string
+
+	// preferred is the index of the secret last accepted
+	preferred int32
+}
+
+// appSecretProof returns the appsecret_proof of accessToken signed with secret.
+func appSecretProof(secret string, accessToken string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(accessToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWithProof sends req with the appsecret_proof of each of s in turn, until one is accepted.
+func (c APIClient) sendWithProof(ctx context.Context, req *http.Request, s *appSecrets) (*http.Response, error) {
+	accessToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if accessToken == "" || accessToken == req.Header.Get("Authorization") {
+		return c.send(ctx, req)
+	}
+	preferred := int(atomic.LoadInt32(&s.preferred))
+	resendable := req.Body == nil || req.GetBody != nil
+	for n := 0; ; n++ {
+		i := (preferred + n) % len(s.secrets)
+		attempt := req.Clone(ctx)
+		if n > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		query := attempt.URL.Query()
+		query.Set("appsecret_proof", appSecretProof(s.secrets[i], accessToken))
+		attempt.URL.RawQuery = query.Encode()
+		res, err := c.send(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+		if rejectedProof(res) {
+			if n < len(s.secrets)-1 && resendable {
+				drainAndClose(res.Body)
+				continue
+			}
+			return res, nil
+		}
+		atomic.StoreInt32(&s.preferred, int32(i))
+		if m, ok := c.metrics.(SecretMetrics); ok {
+			m.ObserveSecretMatch(SecretUseAppSecretProof, i)
+		}
+		return res, nil
+	}
+}
+
+// rejectedProof returns true if res is an error response rejecting the appsecret_proof, the start of the body is
+// peeked at and replaced so that the response can still be read.
+func rejectedProof(res *http.Response) bool {
+	if res.StatusCode != http.StatusBadRequest && res.StatusCode != http.StatusForbidden {
+		return false
+	}
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(res.Body, head)
+	head = head[:n]
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), res.Body), res.Body}
+	return bytes.Contains(head, []byte("appsecret_proof"))
+}
@@ -0,0 +1,79 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testSecretMetrics struct {
+	testMetrics
+	matches []string
+}
+
+func (m *testSecretMetrics) ObserveSecretMatch(use string, index int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.matches = append(m.matches, fmt.Sprintf("%s %d", use, index))
+}
+
+func TestAppSecretProofRotation(t *testing.T) {
+	var mu sync.Mutex
+	var proofs []string
+	m := &testSecretMetrics{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proof := r.URL.Query().Get("appsecret_proof")
+		mu.Lock()
+		proofs = append(proofs, proof)
+		mu.Unlock()
+		// Facebook still has the previous secret
+		if proof != appSecretProof("old", "token") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid appsecret_proof provided in the API argument","type":"GraphMethodException","code":100}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1234"}`)
+	}), WithAppSecretProof("new", "old"), WithMetrics(m))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); err != nil {
+			t.Fatalf("failed to get fundraiser %v", err)
+		}
+	}
+	if len(proofs) != 3 || proofs[0] != appSecretProof("new", "token") {
+		t.Errorf("expected the new secret to be tried once, got %d requests", len(proofs))
+	}
+	if fmt.Sprint(m.matches) != "[appsecret_proof 1 appsecret_proof 1]" {
+		t.Errorf("unexpected secret matches %v", m.matches)
+	}
+
+	c = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"Invalid appsecret_proof provided in the API argument","type":"GraphMethodException","code":100}}`)
+	}), WithAppSecretProof("new", "old"))
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); err == nil || !strings.Contains(err.Error(), "appsecret_proof") {
+		t.Errorf("expected rejected proof error, got %v", err)
+	}
+}
+
+func TestWebhookPreviousAppSecret(t *testing.T) {
+	body := `{"object":"fundraiser","entry":[]}`
+	m := &testSecretMetrics{}
+	h := NewWebhookHandler(WebhookSettings{AppSecret: "new", PreviousAppSecrets: []string{"old"}, Metrics: m})
+	for _, secret := range []string{"old", "new", "other"} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("X-Hub-Signature-256", "sha256="+appSecretProof(secret, body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if expected := secret != "other"; expected != (w.Code == http.StatusOK) {
+			t.Errorf("unexpected status %d for secret %s", w.Code, secret)
+		}
+	}
+	if fmt.Sprint(m.matches) != "[webhook 1 webhook 0]" {
+		t.Errorf("unexpected secret matches %v", m.matches)
+	}
+}
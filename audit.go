@@ -0,0 +1,93 @@
+package flannel
+
+import (
+	"context"
+	"time"
+)
+
+// An AuditRecord describes a write API call, such as creating a fundraiser.
+type AuditRecord struct {
+	Timestamp time.Time
+
+	// Tenant is set if the API call was made with ContextWithTenant.
+	Tenant string
+
+	// RequestID is set if the API call was made with ContextWithRequestID.
+	RequestID string
+
+	// ExternalID identifies the fundraiser on the external site.
+	ExternalID string
+
+	Method   string
+	Endpoint string
+
+	// Params are the parameters sent, excluding credentials, files are recorded by file name.
+	Params map[string]string
+
+	// Status is the HTTP status code of the response, or zero if no response was received.
+	Status int
+
+	// ID is the id of the object created, if any.
+	ID string
+
+	// Error is the error returned by the API call, if any.
+	Error string
+}
+
+// AuditSink is implemented to receive an AuditRecord for every write API call, for example
+// to store a durable trail of fundraiser mutations. Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// The AuditSinkFunc type is an adapter to allow the use of ordinary functions as AuditSinks.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Record calls f(ctx, record).
+func (f AuditSinkFunc) Record(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// WithAuditSink records every write API call to sink. Errors recording are logged but do not fail the API call.
+func WithAuditSink(sink AuditSink) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.auditSink = sink
+		return nil
+	}
+}
+
+// audit records a write API call to the audit sink, if any.
+func (c APIClient) audit(ctx context.Context, method string, endpoint string, externalID string, form *fundraiserForm, status int, result map[string]interface{}, err error) {
+	if c.auditSink == nil {
+		return
+	}
+	record := AuditRecord{
+		Timestamp:  c.now(),
+		ExternalID: externalID,
+		Method:     method,
+		Endpoint:   endpoint,
+		Params:     make(map[string]string),
+		Status:     status,
+	}
+	record.Tenant, _ = TenantFromContext(ctx)
+	record.RequestID, _ = RequestIDFromContext(ctx)
+	for _, field := range form.fields {
+		if secretKeys[field.name] {
+			record.Params[field.name] = redacted
+			continue
+		}
+		record.Params[field.name] = field.value
+	}
+	for _, file := range form.files {
+		record.Params[file.fieldName] = file.fileName
+	}
+	if id, ok := result["id"].(string); ok {
+		record.ID = id
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditErr := c.auditSink.Record(ctx, record); auditErr != nil && c.logger != nil {
+		c.logf(ctx, "error recording audit of %s request to %s %v\n", method, endpoint, auditErr)
+	}
+}
@@ -0,0 +1,49 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAuditSink(t *testing.T) {
+	var records []AuditRecord
+	sink := AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	clock := newTestClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(fundraiserCreated), WithAuditSink(sink), WithClock(clock))
+
+	ctx := ContextWithTenant(context.Background(), "tenant")
+	params := CreateFundraiserParams{AccessToken: "token", Title: "Title", ExternalID: "external"}
+	_, _, err := c.CreateFundraiserWithContext(ctx, params,
+		WithFundraiserField("external_event_name", "Event"),
+		WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(testPhoto(0))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected only the write api call to be audited, got %d records", len(records))
+	}
+	r := records[0]
+	if r.Tenant != "tenant" || r.ExternalID != "external" || r.Method != "POST" || r.Status != http.StatusOK || r.ID != "1234" || r.Error != "" ||
+		!r.Timestamp.Equal(clock.Now()) {
+		t.Errorf("unexpected audit record %+v", r)
+	}
+	if r.Params["name"] != "Title" || r.Params["external_event_name"] != "Event" || r.Params["cover_photo"] != "photo.jpg" {
+		t.Errorf("unexpected audit params %v", r.Params)
+	}
+	for _, v := range r.Params {
+		if v == "token" {
+			t.Errorf("expected access token not to be audited, got %v", r.Params)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package flannel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// graphHost is the host of GraphAPIEndpoint.
+const graphHost = "graph.facebook.com"
+
+// WithBaseHosts sends requests to the Graph API, including cover photo URLs on graph.facebook.com, to hosts in order,
+// failing over to the next host if a connection cannot be made to one, for example graph.facebook.com followed by a
+// corporate egress gateway. Each host is a host name, with an optional port, or an https URL with an optional path
+// prefix, such as "https://gateway.example.com/facebook".
+func WithBaseHosts(hosts ...string) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if len(hosts) == 0 {
+			return errors.New("no base hosts")
+		}
+		bases := make([]*url.URL, len(hosts))
+		for i, host := range hosts {
+			if !strings.Contains(host, "://") {
+				host = "https://" + host
+			}
+			u, err := url.Parse(host)
+			if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+				return fmt.Errorf("invalid base host %q", hosts[i])
+			}
+			u.Path = strings.TrimSuffix(u.Path, "/")
+			bases[i] = u
+		}
+		c.baseHosts = bases
+		return nil
+	}
+}
+
+// WithBaseHost sends requests to the Graph API to host instead of graph.facebook.com, as WithBaseHosts.
+func WithBaseHost(host string) func(*APIClient) error {
+	return WithBaseHosts(host)
+}
+
+// sendHTTP sends req with the http.Client of the APIClient, to each base host in turn until a connection is made.
+func (c APIClient) sendHTTP(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if len(c.baseHosts) == 0 || req.URL.Host != graphHost {
+		return httpClient.Do(req)
+	}
+	for i := 0; ; i++ {
+		base := c.baseHosts[i]
+		attempt := req.Clone(req.Context())
+		attempt.URL.Scheme = base.Scheme
+		attempt.URL.Host = base.Host
+		attempt.URL.Path = base.Path + req.URL.Path
+		attempt.URL.RawPath = ""
+		attempt.Host = ""
+		if i > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		res, err := httpClient.Do(attempt)
+		if err == nil || i == len(c.baseHosts)-1 || !isConnectError(err) || (req.Body != nil && req.GetBody == nil) {
+			return res, err
+		}
+	}
+}
+
+// isConnectError returns true if err was returned because a connection could not be made, so the request was not sent.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
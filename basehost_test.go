@@ -0,0 +1,70 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBaseHosts(t *testing.T) {
+	var mu sync.Mutex
+	var dialed, paths []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Host+r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/picture") {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testPhoto(1024))
+			return
+		}
+		fmt.Fprint(w, `{"id":"1"}`)
+	}), func(c *APIClient) error {
+		tr := c.httpClient.Transport.(*http.Transport)
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dialed = append(dialed, addr)
+			mu.Unlock()
+			if strings.HasPrefix(addr, "down.example.com") {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+			}
+			return dial(ctx, network, addr)
+		}
+		return nil
+	}, WithBaseHosts("down.example.com", "https://gateway.example.com:8443/facebook"))
+
+	ctx := context.Background()
+	if _, _, err := c.GetFundraiser(ctx, "token", "1"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	photo := url.URL{Scheme: "https", Host: "graph.facebook.com", Path: "/1/picture"}
+	if _, _, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{Title: "Marathon"}, WithFundraiserCoverPhotoURL("photo.png", photo),
+		WithFundraiserField("external_event_name", "Marathon")); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if _, _, err := c.UpdateFundraiser(ctx, "token", "1", map[string]string{"name": "Half Marathon"}); err != nil {
+		t.Fatalf("failed to update fundraiser %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"gateway.example.com:8443/facebook/v2.8/1", "gateway.example.com:8443/facebook/1/picture",
+		"gateway.example.com:8443/facebook/v2.8/me/fundraisers", "gateway.example.com:8443/facebook/v2.8/1"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("expected requests %v, got %v", want, paths)
+	}
+	if len(dialed) == 0 || dialed[0] != "down.example.com:443" {
+		t.Errorf("expected first host to be dialed first, got %v", dialed)
+	}
+
+	if _, err := CreateAPIClient(WithBaseHosts("ftp://example.com")); err == nil {
+		t.Errorf("expected invalid base host to fail")
+	}
+}
@@ -0,0 +1,197 @@
+package flannel
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerSettings configures a CircuitBreaker.
+type CircuitBreakerSettings struct {
+
+	// WindowSize is the number of most recent API calls used to calculate the error rate, defaults to 20.
+	WindowSize int
+
+	// MinimumCalls is the number of API calls required in the window before the circuit can open, defaults to WindowSize.
+	MinimumCalls int
+
+	// ErrorRateThreshold is the fraction (0 to 1) of failed API calls in the window that opens the circuit, defaults to 0.5.
+	ErrorRateThreshold float64
+
+	// SlowCallThreshold is the latency above which an API call is counted as failed, zero disables latency tracking.
+	SlowCallThreshold time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probe calls are permitted, defaults to 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of successful probe calls required to close the circuit again, defaults to 1.
+	HalfOpenProbes int
+
+	// Clock times OpenTimeout, defaults to SystemClock.
+	Clock Clock
+}
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// A CircuitBreaker fails API calls fast whilst the Facebook APIs are failing or responding slowly.
+//
+// The circuit opens when the error rate over the most recent API calls exceeds the threshold.
+// Once OpenTimeout has elapsed a limited number of probe calls are let through (half-open),
+// closing the circuit if they all succeed or re-opening it if any fail.
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu       sync.Mutex
+	state    CircuitState
+	window   []bool // true represents a failed call
+	next     int
+	count    int
+	openedAt time.Time
+	probes   int
+	passed   int
+}
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// NewCircuitBreaker creates a new CircuitBreaker with the provided settings.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.WindowSize <= 0 {
+		settings.WindowSize = 20
+	}
+	if settings.MinimumCalls <= 0 || settings.MinimumCalls > settings.WindowSize {
+		settings.MinimumCalls = settings.WindowSize
+	}
+	if settings.ErrorRateThreshold <= 0 {
+		settings.ErrorRateThreshold = 0.5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{
+		settings: settings,
+		window:   make([]bool, settings.WindowSize),
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.since(b.openedAt) >= b.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether an API call may proceed.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.since(b.openedAt) < b.settings.OpenTimeout {
+			return errCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probes = 0
+		b.passed = 0
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes >= b.settings.HalfOpenProbes {
+			return errCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// record updates the circuit with the outcome of an API call.
+func (b *CircuitBreaker) record(failed bool, latency time.Duration) {
+	if b.settings.SlowCallThreshold > 0 && latency > b.settings.SlowCallThreshold {
+		failed = true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitHalfOpen:
+		if failed {
+			b.open()
+			return
+		}
+		b.passed++
+		if b.passed >= b.settings.HalfOpenProbes {
+			b.reset()
+		}
+	case CircuitClosed:
+		b.window[b.next] = failed
+		b.next = (b.next + 1) % len(b.window)
+		if b.count < len(b.window) {
+			b.count++
+		}
+		if b.count < b.settings.MinimumCalls {
+			return
+		}
+		failures := 0
+		for i := 0; i < b.count; i++ {
+			if b.window[i] {
+				failures++
+			}
+		}
+		if float64(failures)/float64(b.count) >= b.settings.ErrorRateThreshold {
+			b.open()
+		}
+	}
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = CircuitOpen
+	b.openedAt = clockOrSystem(b.settings.Clock).Now()
+}
+
+// since returns the time elapsed since t on the clock of the circuit.
+func (b *CircuitBreaker) since(t time.Time) time.Duration {
+	return clockOrSystem(b.settings.Clock).Now().Sub(t)
+}
+
+func (b *CircuitBreaker) reset() {
+	b.state = CircuitClosed
+	b.next = 0
+	b.count = 0
+}
+
+// WithCircuitBreaker fails API calls fast using the provided CircuitBreaker whilst the Facebook APIs are unavailable.
+// Transport errors, 5xx responses and calls slower than the configured SlowCallThreshold are counted as failures.
+func WithCircuitBreaker(breaker *CircuitBreaker) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.circuitBreaker = breaker
+		return nil
+	}
+}
+
+// IsCircuitOpen returns true if err was returned because the circuit breaker is open.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, errCircuitOpen)
+}
@@ -0,0 +1,46 @@
+package flannel
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failing := true
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fundraiserCreated(w, r)
+	}), WithCircuitBreaker(NewCircuitBreaker(CircuitBreakerSettings{
+		WindowSize:  4,
+		OpenTimeout: 20 * time.Millisecond,
+	})))
+	breaker := c.circuitBreaker
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); err == nil || IsCircuitOpen(err) {
+			t.Fatalf("expected failed call %d to reach the server, got %v", i, err)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected circuit to be open, got %v", breaker.State())
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); !IsCircuitOpen(err) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("expected circuit to be half-open, got %v", breaker.State())
+	}
+	failing = false
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); err != nil {
+		t.Fatalf("expected probe call to succeed, got %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("expected circuit to be closed, got %v", breaker.State())
+	}
+}
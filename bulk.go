@@ -0,0 +1,52 @@
+package flannel
+
+import (
+	"context"
+	"sync"
+)
+
+// CreateFundraiserResult is the outcome of creating one of the fundraisers passed to CreateFundraisers.
+type CreateFundraiserResult struct {
+
+	// Params are the parameters the fundraiser was created with.
+	Params CreateFundraiserParams
+
+	// Status, Result and Err are as returned by CreateFundraiser.
+	Status int
+	Result map[string]interface{}
+	Err    error
+}
+
+// CreateFundraisers creates many Facebook Fundraisers, making up to concurrency API calls at the same time.
+// A result is returned for each of params, in the same order, so that individual failures can be retried.
+// API calls are subject to the rate limiting and retry policy of the APIClient, and once ctx is done
+// any fundraisers not yet created fail with the context error.
+func (c APIClient) CreateFundraisers(ctx context.Context, params []CreateFundraiserParams, concurrency int) []CreateFundraiserResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]CreateFundraiserResult, len(params))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(params); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r := CreateFundraiserResult{Params: params[i]}
+				if err := ctx.Err(); err != nil {
+					r.Err = err
+				} else {
+					r.Status, r.Result, r.Err = c.CreateFundraiserWithContext(ctx, params[i])
+				}
+				results[i] = r
+			}
+		}()
+	}
+	for i := range params {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
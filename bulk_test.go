@@ -0,0 +1,50 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateFundraisers(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("external_id") == "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid parameter","code":100}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"fb-%s"}`, r.FormValue("external_id"))
+	}))
+
+	var params []CreateFundraiserParams
+	for i := 0; i < 10; i++ {
+		params = append(params, CreateFundraiserParams{ExternalID: fmt.Sprint(i)})
+	}
+	results := c.CreateFundraisers(context.Background(), params, 3)
+	if len(results) != len(params) {
+		t.Fatalf("expected %d results, got %d", len(params), len(results))
+	}
+	for i, r := range results {
+		if r.Params.ExternalID != params[i].ExternalID {
+			t.Errorf("expected result %d for %s, got %s", i, params[i].ExternalID, r.Params.ExternalID)
+		}
+		if i == 2 {
+			if code, _ := ErrorCodes(r.Err); code != 100 {
+				t.Errorf("expected facebook error for %d, got %v", i, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.Result["id"] != fmt.Sprintf("fb-%d", i) {
+			t.Errorf("unexpected result %d %v %v", i, r.Result, r.Err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range c.CreateFundraisers(ctx, params, 3) {
+		if r.Err != context.Canceled {
+			t.Errorf("expected context canceled, got %v", r.Err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package flannel
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache is the interface implemented by caches used by the APIClient.
+// Implementations must be safe for concurrent use, see MemoryCache for an in-memory implementation.
+type Cache interface {
+
+	// Get returns the value stored for key, if it exists and has not expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores value for key, a zero ttl means the value does not expire.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// A MemoryCache is an in-memory Cache which evicts the least recently used values once full.
+type MemoryCache struct {
+	maxEntries int
+	clock      Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a new MemoryCache holding up to maxEntries values.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// SetClock sets the Clock used to expire values, which defaults to SystemClock. It must be set before the cache is used.
+func (m *MemoryCache) SetClock(clock Clock) {
+	m.clock = clock
+}
+
+// Get returns the value stored for key, if it exists and has not expired.
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, exists := m.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && clockOrSystem(m.clock).Now().After(entry.expires) {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+		return nil, false
+	}
+	m.lru.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set stores value for key, a zero ttl means the value does not expire.
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = clockOrSystem(m.clock).Now().Add(ttl)
+	}
+	if el, exists := m.entries[key]; exists {
+		el.Value = entry
+		m.lru.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+	if m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// WithResponseCache caches responses to API calls reading Facebook objects, such as GetFundraiser,
+// which include an ETag. Subsequent API calls are sent with If-None-Match and the cached response
+// is returned when Facebook responds 304 Not Modified.
+func WithResponseCache(cache Cache) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.responseCache = cache
+		return nil
+	}
+}
+
+type cachedResponse struct {
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+// responseCacheKey identifies a request by its URL, including any fields, and access token.
+func responseCacheKey(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return "flannel:etag:" + req.URL.String() + ":" + hex.EncodeToString(h[:8])
+}
+
+// doCached sends req, revalidating any cached response with If-None-Match.
+func (c APIClient) doCached(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.responseCache == nil || req.Method != http.MethodGet {
+		return c.do(ctx, req)
+	}
+	key := responseCacheKey(req)
+	var cached cachedResponse
+	v, hit := c.responseCache.Get(ctx, key)
+	if hit {
+		hit = json.Unmarshal(v, &cached) == nil && cached.ETag != ""
+	}
+	if hit {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotModified && hit:
+		drainAndClose(res.Body)
+		res.StatusCode = http.StatusOK
+		res.Status = "200 OK"
+		setResponseBody(res, cached.Body)
+	case res.StatusCode == http.StatusOK && res.Header.Get("ETag") != "":
+		body, release, err := c.readBody(res.Body)
+		drainAndClose(res.Body)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		cached = cachedResponse{ETag: res.Header.Get("ETag"), Body: append(json.RawMessage(nil), body...)}
+		release()
+		if v, err := json.Marshal(cached); err == nil {
+			c.responseCache.Set(ctx, key, v, 0)
+		}
+		setResponseBody(res, cached.Body)
+	}
+	return res, nil
+}
+
+func setResponseBody(res *http.Response, body []byte) {
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+}
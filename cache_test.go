@@ -0,0 +1,92 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryCache(2)
+	m.Set(ctx, "a", []byte("1"), 0)
+	m.Set(ctx, "b", []byte("2"), 0)
+	m.Get(ctx, "a")
+	m.Set(ctx, "c", []byte("3"), 0)
+	if _, ok := m.Get(ctx, "b"); ok {
+		t.Errorf("expected least recently used value to be evicted")
+	}
+	if v, ok := m.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("expected recently used value to be kept, got %s %v", v, ok)
+	}
+	m.Set(ctx, "d", []byte("4"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := m.Get(ctx, "d"); ok {
+		t.Errorf("expected value to expire")
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	var requests, notModified int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"id":"1234","amount_raised":500}`)
+	}), WithResponseCache(NewMemoryCache(100)))
+
+	for i := 0; i < 3; i++ {
+		status, result, err := c.GetFundraiser(context.Background(), "token", "1234", "amount_raised")
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("failed to get fundraiser %d %v", status, err)
+		}
+		if result["amount_raised"] != float64(500) {
+			t.Errorf("unexpected result %v", result)
+		}
+	}
+	if requests != 3 || notModified != 2 {
+		t.Errorf("expected 2 of 3 requests to be revalidated, got %d of %d", notModified, requests)
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/v2.8/debug_token":
+			fmt.Fprint(w, `{"data":{"is_valid":true,"scopes":["manage_fundraisers"]}}`)
+		default:
+			fmt.Fprint(w, `{"id":"5678","name":"Example Charity"}`)
+		}
+	}), WithMetadataCache(NewMemoryCache(100), time.Minute))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, result, err := c.GetCharity(ctx, "token", "5678", "name")
+		if err != nil || result["name"] != "Example Charity" {
+			t.Fatalf("failed to get charity %v %v", result, err)
+		}
+		_, result, err = c.DebugToken(ctx, "app-token", "user-token")
+		if err != nil || result["data"] == nil {
+			t.Fatalf("failed to debug token %v %v", result, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected repeated calls to be served from the cache, got %d requests", requests)
+	}
+
+	// results are not shared between access tokens
+	if _, _, err := c.GetCharity(ctx, "other-token", "5678", "name"); err != nil {
+		t.Fatalf("failed to get charity %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected charity to be requested with another access token, got %d requests", requests)
+	}
+}
@@ -0,0 +1,72 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangedSince(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"id":"1","name":"Old","updated_time":"2026-01-01T00:00:00+0000"},
+			{"id":"2","name":"New","updated_time":"2026-01-03T00:00:00+0000"}
+		]}`)
+	}))
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	changed, err := c.ChangedSince(context.Background(), "token", since, "description")
+	if err != nil || len(changed) != 1 || changed[0]["id"] != "2" {
+		t.Errorf("expected fundraisers updated after since, got %v %v", changed, err)
+	}
+}
+
+func TestChangedSinceDiff(t *testing.T) {
+	name := "Run"
+	var requested []string
+	clock := newTestClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryMirrorStore()
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := r.URL.Query().Get("fields")
+		requested = append(requested, fields)
+		if strings.Contains(fields, "updated_time") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"(#100) Tried accessing nonexisting field (updated_time)","code":100}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":[{"id":"1","name":%q},{"id":"2","name":"Walk"}]}`, name)
+	}), WithMirrorStore(store), WithClock(clock))
+	ctx := context.Background()
+
+	changed, err := c.ChangedSince(ctx, "token", time.Time{})
+	if err != nil || len(changed) != 2 {
+		t.Fatalf("expected fundraisers not yet mirrored to be changed, got %v %v", changed, err)
+	}
+	if len(requested) != 2 || strings.Contains(requested[1], "updated_time") {
+		t.Errorf("expected fallback without updated_time, got %v", requested)
+	}
+	if mirrored, _ := store.Fundraisers(ctx); len(mirrored) != 0 {
+		t.Errorf("expected changed fundraisers not to be mirrored, got %v", mirrored)
+	}
+	if err := c.MirrorFundraisers(ctx, changed); err != nil {
+		t.Fatalf("failed to mirror fundraisers %v", err)
+	}
+	mirrored := clock.Now()
+	clock.advance(time.Hour)
+
+	if changed, err = c.ChangedSince(ctx, "token", mirrored); err != nil || len(changed) != 0 {
+		t.Errorf("expected no changes, got %v %v", changed, err)
+	}
+	if changed, err = c.ChangedSince(ctx, "token", mirrored.Add(-time.Minute)); err != nil || len(changed) != 2 {
+		t.Errorf("expected fundraisers mirrored after since to be changed, got %v %v", changed, err)
+	}
+	name = "Marathon"
+	if changed, err = c.ChangedSince(ctx, "token", mirrored); err != nil || len(changed) != 1 || changed[0]["name"] != "Marathon" {
+		t.Errorf("expected renamed fundraiser to be changed, got %v %v", changed, err)
+	}
+	if changed, err = c.ChangedSince(ctx, "token", mirrored); err != nil || len(changed) != 1 {
+		t.Errorf("expected renamed fundraiser to be changed until it is mirrored, got %v %v", changed, err)
+	}
+}
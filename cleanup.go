@@ -0,0 +1,148 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CleanupSettings configures a CampaignCleanup.
+type CleanupSettings struct {
+
+	// AccessToken is used to list and end the fundraisers of the user.
+	AccessToken string
+
+	// CampaignEnd returns when the campaign of a fundraiser ended, typically looked up by its ExternalID.
+	// Fundraisers are left alone if it returns false. If not set, all fundraisers end at EndDate.
+	CampaignEnd func(fundraiser FundraiserDetails) (time.Time, bool)
+	EndDate     time.Time
+
+	// FinalUpdate, if set, returns fields updated as the fundraiser is ended, for example a thank you added to the
+	// description.
+	FinalUpdate func(fundraiser FundraiserDetails) map[string]string
+
+	// OnEnded is called with the final totals of each fundraiser ended.
+	OnEnded func(ctx context.Context, fundraiser FundraiserDetails) error
+
+	// Interval is the time between runs, defaults to 1 hour.
+	Interval time.Duration
+
+	// OnError is called with errors cleaning up, which are otherwise ignored by Run.
+	OnError func(err error)
+}
+
+// CleanupSummary counts the fundraisers of a cleanup run.
+type CleanupSummary struct {
+	Checked int
+	Ended   int
+	Failed  int
+}
+
+// A CampaignCleanup ends fundraisers which are still open once their campaign has ended, so that fundraisers are
+// not left linked to closed campaigns.
+type CampaignCleanup struct {
+	c        APIClient
+	settings CleanupSettings
+	loop     loop
+}
+
+// NewCampaignCleanup creates a new CampaignCleanup which ends fundraisers using c.
+func (c APIClient) NewCampaignCleanup(settings CleanupSettings) *CampaignCleanup {
+	if settings.Interval <= 0 {
+		settings.Interval = time.Hour
+	}
+	return &CampaignCleanup{c: c, settings: settings}
+}
+
+// Run cleans up until ctx is done, which is returned.
+func (w *CampaignCleanup) Run(ctx context.Context) error {
+	return w.run(ctx, ctx)
+}
+
+// Start cleans up in the background until Shutdown or Close.
+func (w *CampaignCleanup) Start(ctx context.Context) error {
+	return w.loop.start(ctx, func(ctx context.Context, stop context.Context) { w.run(ctx, stop) })
+}
+
+// Shutdown stops cleaning up once any run in progress has completed, cancelling it if ctx is done first.
+func (w *CampaignCleanup) Shutdown(ctx context.Context) error {
+	return w.loop.shutdown(ctx)
+}
+
+// Close stops cleaning up once any run in progress has completed.
+func (w *CampaignCleanup) Close() error {
+	return w.Shutdown(context.Background())
+}
+
+// run cleans up with ctx until stop is done.
+func (w *CampaignCleanup) run(ctx context.Context, stop context.Context) error {
+	for {
+		if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil && w.settings.OnError != nil {
+			w.settings.OnError(err)
+		}
+		if err := sleep(stop, w.c.clock, w.settings.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// RunOnce lists the fundraisers of the user, ending those which are still open after their campaign ended, with
+// any FinalUpdate, and reporting their final totals to OnEnded. The first error is returned once all fundraisers
+// have been checked.
+func (w *CampaignCleanup) RunOnce(ctx context.Context) (CleanupSummary, error) {
+	ctx = backgroundContext(ctx)
+	var summary CleanupSummary
+	var first error
+	fail := func(err error) {
+		summary.Failed++
+		if first == nil {
+			first = err
+		}
+	}
+	now := w.c.now()
+	it := w.c.ListFundraisers(ctx, w.settings.AccessToken,
+		"id", "name", "description", "goal_amount", "amount_raised", "currency", "end_time", "external_id", "uri")
+	defer it.Close()
+	for it.Next() {
+		summary.Checked++
+		var f FundraiserDetails
+		if err := decodeResult(it.Item(), &f); err != nil {
+			fail(err)
+			continue
+		}
+		if end, ok := parseGraphTime(f.EndTime); ok && !end.After(now) {
+			// already ended
+			continue
+		}
+		campaignEnd, ok := w.settings.EndDate, !w.settings.EndDate.IsZero()
+		if w.settings.CampaignEnd != nil {
+			campaignEnd, ok = w.settings.CampaignEnd(f)
+		}
+		if !ok || campaignEnd.After(now) {
+			continue
+		}
+		fields := map[string]string{}
+		if w.settings.FinalUpdate != nil {
+			for name, value := range w.settings.FinalUpdate(f) {
+				fields[name] = value
+			}
+		}
+		fields["end_time"] = strconv.FormatInt(now.Unix(), 10)
+		if _, _, err := w.c.UpdateFundraiser(ctx, w.settings.AccessToken, f.ID, fields); err != nil {
+			fail(fmt.Errorf("error ending fundraiser %s %w", f.ID, err))
+			continue
+		}
+		summary.Ended++
+		f.EndTime = now.UTC().Format("2006-01-02T15:04:05-0700")
+		if w.settings.OnEnded != nil {
+			if err := w.settings.OnEnded(ctx, f); err != nil {
+				fail(err)
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return summary, err
+	}
+	return summary, first
+}
@@ -0,0 +1,77 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCampaignCleanup(t *testing.T) {
+	future := time.Now().AddDate(0, 1, 0).UTC().Format("2006-01-02T15:04:05-0700")
+	past := time.Now().AddDate(0, -1, 0).UTC().Format("2006-01-02T15:04:05-0700")
+	var updates []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			r.ParseForm()
+			updates = append(updates, fmt.Sprintf("%s %s %t", r.URL.Path, r.PostForm.Get("description"), r.PostForm.Get("end_time") != ""))
+			fmt.Fprint(w, `{"success":true}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":[
+			{"id":"1","external_id":"closed","description":"d","amount_raised":500,"end_time":%[1]q},
+			{"id":"2","external_id":"open","amount_raised":100,"end_time":%[1]q},
+			{"id":"3","external_id":"closed","amount_raised":700,"end_time":%[2]q}]}`, future, past)
+	}))
+
+	var ended []string
+	cleanup := c.NewCampaignCleanup(CleanupSettings{
+		AccessToken: "token",
+		CampaignEnd: func(f FundraiserDetails) (time.Time, bool) {
+			return time.Now().Add(-time.Hour), f.ExternalID == "closed"
+		},
+		FinalUpdate: func(f FundraiserDetails) map[string]string {
+			return map[string]string{"description": f.Description + " Thank you!"}
+		},
+		OnEnded: func(ctx context.Context, f FundraiserDetails) error {
+			ended = append(ended, fmt.Sprintf("%s:%d", f.ID, f.AmountRaised))
+			return nil
+		},
+	})
+	summary, err := cleanup.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("failed to clean up %v", err)
+	}
+	if summary != (CleanupSummary{Checked: 3, Ended: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if fmt.Sprintf("%q", updates) != `["/v2.8/1 d Thank you! true"]` {
+		t.Errorf("unexpected updates %q", updates)
+	}
+	if fmt.Sprint(ended) != "[1:500]" {
+		t.Errorf("unexpected ended fundraisers %v", ended)
+	}
+}
+
+func TestCampaignCleanupConcurrencyLimit(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fmt.Fprint(w, `{"success":true}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"1"},{"id":"2"}]}`)
+	}), WithMaxConcurrentRequests(1))
+	cleanup := c.NewCampaignCleanup(CleanupSettings{AccessToken: "token", EndDate: time.Now().Add(-time.Hour)})
+
+	// fundraisers are updated while iterating, which must not wait on the slot of the list request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	summary, err := cleanup.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("failed to clean up %v", err)
+	}
+	if summary != (CleanupSummary{Checked: 2, Ended: 2}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
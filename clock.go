@@ -0,0 +1,65 @@
+package flannel
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the interface implemented by the clock used for time dependent behaviour, such as validating end times,
+// retry backoff, cache expiry and scheduling jobs, so that it can be tested deterministically and clock skew simulated.
+// Implementations must be safe for concurrent use.
+type Clock interface {
+
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc waits for d to elapse and then calls f in its own goroutine. The returned stop func cancels the
+	// call, returning false if f has already been called or stopped.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// SystemClock is the Clock of the system, it is used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// WithClock sets the Clock used by the APIClient, and by the queues, pollers and sessions created from it.
+func WithClock(clock Clock) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.clock = clock
+		return nil
+	}
+}
+
+// now returns the current time of the clock of the APIClient.
+func (c APIClient) now() time.Time {
+	return clockOrSystem(c.clock).Now()
+}
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// sleep waits for d to elapse on clock, returning early with the error of ctx if it is done first.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	elapsed := make(chan struct{})
+	stop := clockOrSystem(clock).AfterFunc(d, func() { close(elapsed) })
+	select {
+	case <-ctx.Done():
+		stop()
+		return ctx.Err()
+	case <-elapsed:
+		return nil
+	}
+}
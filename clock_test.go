@@ -0,0 +1,166 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a Clock which only moves when advanced.
+type testClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*time.Time]func()
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{now: now, timers: make(map[*time.Time]func())}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	due := c.now.Add(d)
+	c.timers[&due] = f
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, pending := c.timers[&due]
+		delete(c.timers, &due)
+		return pending
+	}
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for due, f := range c.timers {
+		if !due.After(c.now) {
+			delete(c.timers, due)
+			go f()
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	clock := newTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	m := NewMemoryCache(10)
+	m.SetClock(clock)
+	m.Set(ctx, "a", []byte("1"), time.Hour)
+	clock.advance(59 * time.Minute)
+	if _, ok := m.Get(ctx, "a"); !ok {
+		t.Errorf("expected value not to expire before the clock passes its expiry")
+	}
+	clock.advance(2 * time.Minute)
+	if _, ok := m.Get(ctx, "a"); ok {
+		t.Errorf("expected value to expire once the clock passes its expiry")
+	}
+
+	created := make(chan time.Time, 1)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created <- clock.Now()
+		fmt.Fprint(w, `{"id":"1234"}`)
+	}), WithClock(clock))
+	q := NewFundraiserQueue(c, NewMemoryJobStore(), FundraiserQueueSettings{})
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	defer q.Close()
+	launch := clock.Now().Add(24 * time.Hour)
+	if _, err := q.Schedule(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "launch"}}, launch); err != nil {
+		t.Fatalf("failed to schedule job %v", err)
+	}
+	select {
+	case <-created:
+		t.Fatalf("expected scheduled job to wait for the clock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.advance(24 * time.Hour)
+	select {
+	case at := <-created:
+		if at.Before(launch) {
+			t.Errorf("fundraiser created %v before launch", launch.Sub(at))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for scheduled job")
+	}
+}
+
+func TestClockRateLimiting(t *testing.T) {
+	ctx := context.Background()
+	clock := newTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	// waitFor waits until wait is blocked on a timer of the clock, then advances the clock by d
+	waitFor := func(wait func(ctx context.Context) error, d time.Duration) {
+		t.Helper()
+		done := make(chan error, 1)
+		go func() { done <- wait(ctx) }()
+		for {
+			clock.mu.Lock()
+			timers := len(clock.timers)
+			clock.mu.Unlock()
+			if timers > 0 {
+				break
+			}
+			select {
+			case err := <-done:
+				t.Fatalf("expected wait to block on the clock, got %v", err)
+			case <-time.After(time.Millisecond):
+			}
+		}
+		clock.advance(d)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("failed to wait %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the clock")
+		}
+	}
+
+	bucket := NewTokenBucket(1, 1)
+	bucket.SetClock(clock)
+	if err := bucket.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait %v", err)
+	}
+	waitFor(bucket.Wait, time.Second)
+
+	scheduler := NewFairScheduler(FairSchedulerSettings{Rate: 1, Burst: 1, Clock: clock})
+	if err := scheduler.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait %v", err)
+	}
+	waitFor(scheduler.Wait, time.Second)
+
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{WindowSize: 1, OpenTimeout: time.Minute, Clock: clock})
+	breaker.record(true, 0)
+	if breaker.allow() == nil {
+		t.Errorf("expected circuit to be open")
+	}
+	clock.advance(time.Minute)
+	if state := breaker.State(); state != CircuitHalfOpen {
+		t.Errorf("expected circuit to be half-open once the clock passes the open timeout, got %v", state)
+	}
+
+	summary := NewErrorSummary(time.Minute)
+	summary.SetClock(clock)
+	summary.observe("GET", "/{id}", 100, 0)
+	if report := summary.Report(); report.Total != 1 {
+		t.Errorf("expected error to be reported, got %+v", report)
+	}
+	clock.advance(2 * time.Minute)
+	if report := summary.Report(); report.Total != 0 {
+		t.Errorf("expected error outside the window of the clock not to be reported, got %+v", report)
+	}
+}
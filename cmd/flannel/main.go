@@ -0,0 +1,380 @@
+// Command flannel calls the Facebook Fundraiser API using the flannel library, so that API issues can be reproduced
+// without writing Go programs.
+//
+//	flannel [flags] create -charity 1234 -title "Marathon" -goal 10000 -currency GBP -end 720h
+//	flannel [flags] get <fundraiser-id>
+//	flannel [flags] update <fundraiser-id> -field name=Marathon
+//	flannel [flags] list
+//	flannel [flags] donations [-follow] <fundraiser-id>
+//
+// The access token is read from the -token flag, or the FLANNEL_ACCESS_TOKEN environment variable.
+// Results are written as JSON, one object per line for lists, or as a table with -output table.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+const usage = `usage: flannel [flags] <command> [command flags] [args]
+
+commands:
+  create     create a fundraiser
+  get        read a fundraiser
+  update     update the fields of a fundraiser
+  list       list the fundraisers of the user
+  donations  list, or with -follow tail, the donations made to a fundraiser
+
+flags:
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// cli holds the settings shared by all commands.
+type cli struct {
+	c      flannel.APIClient
+	token  string
+	output string
+	stdout io.Writer
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("flannel", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	token := fs.String("token", os.Getenv("FLANNEL_ACCESS_TOKEN"), "Facebook access token, defaults to $FLANNEL_ACCESS_TOKEN")
+	output := fs.String("output", "json", "output format, json or table")
+	debug := fs.Bool("debug", false, "log all API calls")
+	dump := fs.Bool("dump", false, "dump HTTP requests and responses, with secrets redacted")
+	timeout := fs.Duration("timeout", 20*time.Second, "timeout of each API call")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *output != "json" && *output != "table" {
+		return fmt.Errorf("invalid output %q, expected json or table", *output)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	logger := log.New(stderr, "", log.LstdFlags)
+	options := []func(*flannel.APIClient) error{
+		flannel.WithLogger(flannel.LoggerFunc(logger.Printf), *debug),
+		flannel.WithTimeouts(flannel.Timeouts{Overall: *timeout}),
+	}
+	if *dump {
+		options = append(options, flannel.WithHTTPDump(stderr))
+	}
+	c, err := flannel.CreateAPIClient(options...)
+	if err != nil {
+		return err
+	}
+	cmd := &cli{c: c, token: *token, output: *output, stdout: stdout}
+
+	commands := map[string]func(context.Context, []string) error{
+		"create":    cmd.create,
+		"get":       cmd.get,
+		"update":    cmd.update,
+		"list":      cmd.list,
+		"donations": cmd.donations,
+	}
+	name, args := fs.Arg(0), fs.Args()[1:]
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		fs.Usage()
+		return errUsage
+	}
+	if cmd.token == "" {
+		return errors.New("missing access token, set -token or $FLANNEL_ACCESS_TOKEN")
+	}
+	return command(ctx, args)
+}
+
+func (cmd *cli) create(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	var params flannel.CreateFundraiserParams
+	params.AccessToken = cmd.token
+	fs.StringVar(&params.CharityID, "charity", "", "Facebook charity ID")
+	fs.StringVar(&params.Title, "title", "", "title of the fundraiser")
+	fs.StringVar(&params.Description, "description", "", "description of the fundraiser")
+	fs.IntVar(&params.Goal, "goal", 0, "goal in the currency's smallest unit")
+	fs.StringVar(&params.Currency, "currency", "", "ISO 4217 currency code of the goal")
+	fs.StringVar(&params.ExternalID, "external-id", "", "ID of the fundraiser in your system")
+	end := fs.String("end", "720h", "end time of the fundraiser, as RFC 3339 or a duration from now")
+	coverPhoto := fs.String("cover-photo", "", "path or URL of a cover photo")
+	fields := fieldsFlag{}
+	fs.Var(fields, "field", "optional field as name=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	endTime, err := parseTime(*end)
+	if err != nil {
+		return err
+	}
+	params.EndTime = endTime
+
+	var options []func(*multipart.Writer) error
+	for _, name := range fields.names() {
+		options = append(options, flannel.WithFundraiserField(name, fields[name]))
+	}
+	if *coverPhoto != "" {
+		if u, err := url.Parse(*coverPhoto); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			options = append(options, flannel.WithFundraiserCoverPhotoURL(path.Base(u.Path), *u))
+		} else {
+			options = append(options, flannel.WithFundraiserCoverPhotoFile(*coverPhoto))
+		}
+	}
+	_, result, err := cmd.c.CreateFundraiserWithContext(ctx, params, options...)
+	if err != nil {
+		return err
+	}
+	return cmd.writeObject(result)
+}
+
+func (cmd *cli) get(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fields := fs.String("fields", "", "comma separated fields to return")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: flannel get [-fields a,b] <fundraiser-id>")
+	}
+	_, result, err := cmd.c.GetFundraiser(ctx, cmd.token, fs.Arg(0), splitFields(*fields)...)
+	if err != nil {
+		return err
+	}
+	return cmd.writeObject(result)
+}
+
+func (cmd *cli) update(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	fields := fieldsFlag{}
+	fs.Var(fields, "field", "field to update as name=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 || len(fields) == 0 {
+		return errors.New("usage: flannel update -field name=value <fundraiser-id>")
+	}
+	_, result, err := cmd.c.UpdateFundraiser(ctx, cmd.token, fs.Arg(0), fields)
+	if err != nil {
+		return err
+	}
+	return cmd.writeObject(result)
+}
+
+func (cmd *cli) list(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fields := fs.String("fields", "", "comma separated fields to return")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return cmd.writeList(cmd.c.ListFundraisers(ctx, cmd.token, splitFields(*fields)...), splitFields(*fields), nil)
+}
+
+func (cmd *cli) donations(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("donations", flag.ContinueOnError)
+	fields := fs.String("fields", "", "comma separated fields to return")
+	follow := fs.Bool("follow", false, "poll for new donations until interrupted")
+	interval := fs.Duration("interval", 30*time.Second, "interval between polls with -follow")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: flannel donations [-follow] <fundraiser-id>")
+	}
+	columns := splitFields(*fields)
+	if *follow && len(columns) > 0 && !contains(columns, "id") {
+		// the id is required to recognise new donations
+		columns = append([]string{"id"}, columns...)
+	}
+	seen := make(map[string]bool)
+	for {
+		it := cmd.c.ListDonations(ctx, cmd.token, fs.Arg(0), columns...)
+		if err := cmd.writeList(it, columns, seen); err != nil {
+			return err
+		}
+		if !*follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// writeObject writes a single object, as indented JSON or a table of fields and values.
+func (cmd *cli) writeObject(object map[string]interface{}) error {
+	if cmd.output == "json" {
+		enc := json.NewEncoder(cmd.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(object)
+	}
+	w := tabwriter.NewWriter(cmd.stdout, 0, 4, 2, ' ', 0)
+	for _, k := range sortedKeys(object) {
+		fmt.Fprintf(w, "%s\t%s\n", k, formatValue(object[k]))
+	}
+	return w.Flush()
+}
+
+// writeList writes the items of it, as JSON lines or a table with a column for each field.
+// Items with IDs in seen are skipped, and the IDs of written items are added to seen if it is not nil.
+func (cmd *cli) writeList(it *flannel.ListIterator, columns []string, seen map[string]bool) error {
+	defer it.Close()
+	enc := json.NewEncoder(cmd.stdout)
+	var w *tabwriter.Writer
+	for it.Next() {
+		item := it.Item()
+		if seen != nil {
+			id, _ := item["id"].(string)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		if cmd.output == "json" {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+			continue
+		}
+		if w == nil {
+			w = tabwriter.NewWriter(cmd.stdout, 0, 4, 2, ' ', 0)
+			if len(columns) == 0 {
+				columns = sortedKeys(item)
+			}
+			fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+		}
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = formatValue(item[column])
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	if w != nil {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// fieldsFlag collects repeated name=value flags.
+type fieldsFlag map[string]string
+
+func (f fieldsFlag) String() string {
+	var s []string
+	for _, name := range f.names() {
+		s = append(s, name+"="+f[name])
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldsFlag) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i < 1 {
+		return fmt.Errorf("invalid field %q, expected name=value", v)
+	}
+	f[v[:i]] = v[i+1:]
+	return nil
+}
+
+func (f fieldsFlag) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTime parses s as an RFC 3339 timestamp, a Unix timestamp or a duration from now.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(i, 0), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, a Unix timestamp or a duration", s)
+	}
+	return time.Now().Add(d), nil
+}
+
+func splitFields(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue formats a table cell, with nested objects written as JSON.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
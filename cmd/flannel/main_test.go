@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunUsage(t *testing.T) {
+	t.Setenv("FLANNEL_ACCESS_TOKEN", "")
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), []string{"unknown"}, &stdout, &stderr); err != errUsage {
+		t.Errorf("expected usage error for unknown command, got %v", err)
+	}
+	if err := run(context.Background(), []string{"get", "1234"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "access token") {
+		t.Errorf("expected missing access token error, got %v", err)
+	}
+	if err := run(context.Background(), []string{"-output", "xml", "get", "1234"}, &stdout, &stderr); err == nil {
+		t.Error("expected invalid output error")
+	}
+}
+
+func TestWriteObject(t *testing.T) {
+	var stdout bytes.Buffer
+	cmd := &cli{output: "table", stdout: &stdout}
+	cmd.writeObject(map[string]interface{}{"id": "1234", "goal_amount": float64(10000), "owner": map[string]interface{}{"id": "1"}})
+	want := "goal_amount  10000\nid           1234\nowner        {\"id\":\"1\"}\n"
+	if stdout.String() != want {
+		t.Errorf("unexpected table\n%s", stdout.String())
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	if tm, err := parseTime("2026-01-02T15:04:05Z"); err != nil || tm.Unix() != 1767366245 {
+		t.Errorf("unexpected RFC 3339 time %v %v", tm, err)
+	}
+	if tm, err := parseTime("1767366245"); err != nil || tm.Unix() != 1767366245 {
+		t.Errorf("unexpected Unix time %v %v", tm, err)
+	}
+	if tm, err := parseTime("24h"); err != nil || time.Until(tm) < 23*time.Hour {
+		t.Errorf("unexpected duration time %v %v", tm, err)
+	}
+	if _, err := parseTime("tomorrow"); err == nil {
+		t.Error("expected invalid time error")
+	}
+}
+
+func TestFieldsFlag(t *testing.T) {
+	f := fieldsFlag{}
+	if err := f.Set("name=Marathon=2026"); err != nil || f["name"] != "Marathon=2026" {
+		t.Errorf("unexpected field %v %v", f, err)
+	}
+	if err := f.Set("=value"); err == nil {
+		t.Error("expected invalid field error")
+	}
+}
@@ -0,0 +1,175 @@
+package flannel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// capability is an endpoint, or a field of an endpoint, and the Graph API versions in which it is available.
+type capability struct {
+	endpoint string
+	field    string
+	added    string
+	removed  string
+}
+
+// capabilities are the endpoints and fields used by flannel, the field is empty for the endpoint itself.
+// Endpoints are as reported by Stats, with IDs replaced by {id}.
+var capabilities = []capability{
+	{endpoint: "/me/fundraisers", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "charity_id", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "name", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "description", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "goal_amount", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "currency", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "end_time", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "external_id", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "fundraiser_type", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "cover_photo", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "external_fundraiser_uri", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "external_event_name", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "external_event_uri", added: "v2.8"},
+	{endpoint: "/me/fundraisers", field: "external_event_start_time", added: "v2.8"},
+	{endpoint: "/{id}", field: "amount_raised", added: "v2.8"},
+	{endpoint: "/{id}", field: "external_id", added: "v2.8"},
+	{endpoint: "/{id}/donations", added: "v2.8"},
+	{endpoint: "/me/permissions", added: "v2.0"},
+	{endpoint: "/debug_token", added: "v2.0"},
+}
+
+// CompatibilityIssue is an endpoint, or a field of an endpoint, which is not available in a Graph API version.
+type CompatibilityIssue struct {
+	Version  string
+	Endpoint string
+	Field    string
+
+	// Reason is why it is not available, such as "added in v2.8".
+	Reason string
+}
+
+func (i CompatibilityIssue) String() string {
+	if i.Field == "" {
+		return fmt.Sprintf("%s is not available in %s, %s", i.Endpoint, i.Version, i.Reason)
+	}
+	return fmt.Sprintf("field %s of %s is not available in %s, %s", i.Field, i.Endpoint, i.Version, i.Reason)
+}
+
+// CheckCompatibility returns the endpoints and fields used by flannel which are not available in the Graph API
+// version, such as "v3.0", so that a migration to another version can be checked before it is made.
+func CheckCompatibility(version string) ([]CompatibilityIssue, error) {
+	v, ok := parseGraphVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("invalid graph api version %q", version)
+	}
+	var issues []CompatibilityIssue
+	for _, c := range capabilities {
+		if reason, available := c.availableIn(v); !available {
+			issues = append(issues, CompatibilityIssue{Version: version, Endpoint: c.endpoint, Field: c.field, Reason: reason})
+		}
+	}
+	return issues, nil
+}
+
+// WithCompatibilityCheck checks the endpoint and fields of each API call against the Graph API version it requests,
+// failing API calls using endpoints or fields which are not available in that version before they are sent.
+func WithCompatibilityCheck() func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.compatibilityCheck = true
+		return nil
+	}
+}
+
+type compatibilityError struct {
+	issues []CompatibilityIssue
+}
+
+func (e compatibilityError) Error() string {
+	messages := make([]string, len(e.issues))
+	for i, issue := range e.issues {
+		messages[i] = issue.String()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// IsIncompatible returns true if err was returned because an API call used endpoints or fields which are not
+// available in the Graph API version requested, when configured with WithCompatibilityCheck.
+func IsIncompatible(err error) bool {
+	var e compatibilityError
+	return errors.As(err, &e)
+}
+
+// checkCompatibility checks the endpoint of req and fields against the Graph API version requested.
+func (c APIClient) checkCompatibility(req *http.Request, fields []string) error {
+	if !c.compatibilityCheck {
+		return nil
+	}
+	version := c.graphVersion(req)
+	v, ok := parseGraphVersion(version)
+	if !ok {
+		return nil
+	}
+	endpoint := statsEndpoint(req)
+	used := map[string]bool{"": true}
+	for _, f := range fields {
+		used[f] = true
+	}
+	var issues []CompatibilityIssue
+	for _, c := range capabilities {
+		if c.endpoint != endpoint || !used[c.field] {
+			continue
+		}
+		if reason, available := c.availableIn(v); !available {
+			issues = append(issues, CompatibilityIssue{Version: version, Endpoint: c.endpoint, Field: c.field, Reason: reason})
+		}
+	}
+	if len(issues) > 0 {
+		sort.Slice(issues, func(i, j int) bool { return issues[i].Field < issues[j].Field })
+		return compatibilityError{issues: issues}
+	}
+	return nil
+}
+
+// queryFields returns the fields requested by query.
+func queryFields(query url.Values) []string {
+	if f := query.Get("fields"); f != "" {
+		return strings.Split(f, ",")
+	}
+	return nil
+}
+
+// availableIn returns whether the capability is available in version v, and if not why.
+func (c capability) availableIn(v [2]int) (string, bool) {
+	if added, _ := parseGraphVersion(c.added); c.added != "" && lessGraphVersion(v, added) {
+		return "added in " + c.added, false
+	}
+	if removed, _ := parseGraphVersion(c.removed); c.removed != "" && !lessGraphVersion(v, removed) {
+		return "removed in " + c.removed, false
+	}
+	return "", true
+}
+
+// parseGraphVersion parses a Graph API version such as "v2.8" into its major and minor numbers.
+func parseGraphVersion(version string) ([2]int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)
+	if len(parts) != 2 || !strings.HasPrefix(version, "v") {
+		return [2]int{}, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return [2]int{}, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return [2]int{}, false
+	}
+	return [2]int{major, minor}, true
+}
+
+func lessGraphVersion(a [2]int, b [2]int) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
@@ -0,0 +1,33 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	issues, err := CheckCompatibility("v2.7")
+	if err != nil {
+		t.Fatalf("failed to check compatibility %v", err)
+	}
+	if len(issues) == 0 || issues[0].String() != "/me/fundraisers is not available in v2.7, added in v2.8" {
+		t.Errorf("unexpected issues %v", issues)
+	}
+	if issues, _ := CheckCompatibility("v2.10"); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+	if _, err := CheckCompatibility("2.8"); err == nil {
+		t.Error("expected invalid version to fail")
+	}
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}), WithCompatibilityCheck())
+	capabilities = append(capabilities, capability{endpoint: "/{id}", field: "legacy_field", removed: "v2.8"})
+	defer func() { capabilities = capabilities[:len(capabilities)-1] }()
+	_, _, err = c.GetFundraiser(context.Background(), "token", "1", "id", "legacy_field")
+	if !IsIncompatible(err) || err.Error() != "field legacy_field of /{id} is not available in v2.8, removed in v2.8" {
+		t.Errorf("expected incompatible field to fail, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package flannel
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// TransferStats reports the response body bytes received by the APIClient.
+type TransferStats struct {
+
+	// Responses is the number of response bodies received.
+	Responses int64
+
+	// CompressedResponses is the number of response bodies received gzip encoded.
+	CompressedResponses int64
+
+	// WireBytes is the number of response body bytes received, before decompression.
+	WireBytes int64
+
+	// DecodedBytes is the number of response body bytes read, after decompression.
+	DecodedBytes int64
+}
+
+type transferCounters struct {
+	responses, compressedResponses, wireBytes, decodedBytes int64
+}
+
+// TransferStats returns a snapshot of the response body bytes received by the APIClient,
+// which can be used to verify the saving from gzip compression.
+func (c APIClient) TransferStats() TransferStats {
+	if c.transfer == nil {
+		return TransferStats{}
+	}
+	return TransferStats{
+		Responses:           atomic.LoadInt64(&c.transfer.responses),
+		CompressedResponses: atomic.LoadInt64(&c.transfer.compressedResponses),
+		WireBytes:           atomic.LoadInt64(&c.transfer.wireBytes),
+		DecodedBytes:        atomic.LoadInt64(&c.transfer.decodedBytes),
+	}
+}
+
+// acceptGzip advertises gzip support on req, unless an encoding has already been set.
+// Setting the header explicitly stops the http.Transport decompressing the response
+// itself so that the compressed bytes can be counted by decodeResponse.
+func acceptGzip(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// decodeResponse transparently decompresses a gzip encoded response body, counting the bytes received.
+func (c APIClient) decodeResponse(res *http.Response) {
+	if c.transfer == nil {
+		return
+	}
+	atomic.AddInt64(&c.transfer.responses, 1)
+	wire := &countingReadCloser{ReadCloser: res.Body, n: &c.transfer.wireBytes}
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		res.Body = &countingReadCloser{ReadCloser: wire, n: &c.transfer.decodedBytes}
+		return
+	}
+	atomic.AddInt64(&c.transfer.compressedResponses, 1)
+	res.Body = &countingReadCloser{ReadCloser: &gzipBody{body: wire}, n: &c.transfer.decodedBytes}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+// gzipBody lazily creates the gzip.Reader, as creating it reads the gzip header.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = getGzipReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	if b.zr != nil {
+		putGzipReader(b.zr)
+		b.zr = nil
+		b.err = io.ErrClosedPipe
+	}
+	return b.body.Close()
+}
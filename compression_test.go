@@ -0,0 +1,39 @@
+package flannel
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGzipResponses(t *testing.T) {
+	description := strings.Repeat("a very repetitive description ", 100)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write([]byte(`{"description":"` + description + `"}`))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`{"description":"` + description + `"}`))
+		zw.Close()
+	}))
+
+	_, result, err := c.GetFundraiser(context.Background(), "token", "1234")
+	if err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if result["description"] != description {
+		t.Errorf("unexpected description %v", result["description"])
+	}
+	stats := c.TransferStats()
+	if stats.Responses != 1 || stats.CompressedResponses != 1 {
+		t.Errorf("expected 1 compressed response, got %+v", stats)
+	}
+	if stats.WireBytes == 0 || stats.WireBytes >= stats.DecodedBytes {
+		t.Errorf("expected fewer bytes on the wire than decoded, got %+v", stats)
+	}
+}
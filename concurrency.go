@@ -0,0 +1,112 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WithMaxConcurrentRequests limits the number of API calls the APIClient makes at the same time to n.
+// The limit also covers any downloads made by options, such as WithFundraiserCoverPhotoURL.
+// API calls block until a slot is available or their context is done, interactive API calls are given
+// slots before background ones, as set with ContextWithPriority.
+func WithMaxConcurrentRequests(n int) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if n < 1 {
+			return errors.New("max concurrent requests must be at least 1")
+		}
+		c.concurrency = &concurrencyLimiter{limit: n}
+		return nil
+	}
+}
+
+// WithInteractiveReserve reserves n of the slots set with WithMaxConcurrentRequests for interactive API calls,
+// so that background API calls, such as bulk syncs, cannot take every slot during traffic spikes.
+func WithInteractiveReserve(n int) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if c.concurrency == nil {
+			return errors.New("interactive reserve requires max concurrent requests")
+		}
+		if n < 0 || n >= c.concurrency.limit {
+			return fmt.Errorf("interactive reserve must be less than max concurrent requests %d", c.concurrency.limit)
+		}
+		c.concurrency = &concurrencyLimiter{limit: c.concurrency.limit, reserve: n}
+		return nil
+	}
+}
+
+// concurrencyLimiter limits the number of concurrent API calls, giving slots to waiting interactive API calls
+// before background ones.
+type concurrencyLimiter struct {
+	limit   int
+	reserve int
+
+	mu      sync.Mutex
+	active  int
+	waiting [2][]chan struct{}
+}
+
+// available returns whether an API call with priority can start.
+func (l *concurrencyLimiter) available(priority Priority) bool {
+	if priority == PriorityInteractive {
+		return l.active < l.limit
+	}
+	return l.active < l.limit-l.reserve && len(l.waiting[PriorityInteractive]) == 0
+}
+
+// acquire blocks until a slot is available, returning a func to release it.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (release func(), err error) {
+	priority := PriorityFromContext(ctx)
+	if priority != PriorityInteractive {
+		priority = PriorityBackground
+	}
+	l.mu.Lock()
+	if len(l.waiting[priority]) == 0 && l.available(priority) {
+		l.active++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	ready := make(chan struct{})
+	l.waiting[priority] = append(l.waiting[priority], ready)
+	l.mu.Unlock()
+	select {
+	case <-ready:
+		return l.release, nil
+	case <-ctx.Done():
+	}
+	l.mu.Lock()
+	for i, w := range l.waiting[priority] {
+		if w == ready {
+			l.waiting[priority] = append(l.waiting[priority][:i:i], l.waiting[priority][i+1:]...)
+			l.mu.Unlock()
+			return nil, fmt.Errorf("error waiting for concurrent request slot %w", ctx.Err())
+		}
+	}
+	l.mu.Unlock()
+	// the slot was given as ctx was done
+	l.release()
+	return nil, fmt.Errorf("error waiting for concurrent request slot %w", ctx.Err())
+}
+
+// release frees a slot, giving it to the next waiting API call.
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	for _, priority := range []Priority{PriorityInteractive, PriorityBackground} {
+		for len(l.waiting[priority]) > 0 && l.available(priority) {
+			close(l.waiting[priority][0])
+			l.waiting[priority] = l.waiting[priority][1:]
+			l.active++
+		}
+	}
+}
+
+// acquire blocks until a concurrent request slot is available, returning a func to release it.
+func (c APIClient) acquire(ctx context.Context) (release func(), err error) {
+	if c.concurrency == nil {
+		return func() {}, nil
+	}
+	return c.concurrency.acquire(ctx)
+}
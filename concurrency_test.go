@@ -0,0 +1,111 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inflight, peak int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fundraiserCreated(w, r)
+	}), WithMaxConcurrentRequests(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); err != nil {
+				t.Errorf("failed to create fundraiser %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", peak)
+	}
+
+	if _, err := CreateAPIClient(WithMaxConcurrentRequests(0)); err == nil {
+		t.Errorf("expected error creating client with max concurrent requests of 0")
+	}
+}
+
+func TestRequestPriority(t *testing.T) {
+	background := ContextWithPriority(context.Background(), PriorityBackground)
+	interactive := context.Background()
+	l := &concurrencyLimiter{limit: 1}
+	release, err := l.acquire(background)
+	if err != nil {
+		t.Fatalf("failed to acquire slot %v", err)
+	}
+	waitFor := func(priority Priority, n int) {
+		for {
+			l.mu.Lock()
+			waiting := len(l.waiting[priority])
+			l.mu.Unlock()
+			if waiting == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	order := make(chan Priority, 2)
+	for _, ctx := range []context.Context{background, interactive} {
+		priority := PriorityFromContext(ctx)
+		go func(ctx context.Context) {
+			release, err := l.acquire(ctx)
+			if err != nil {
+				t.Errorf("failed to acquire slot %v", err)
+				return
+			}
+			order <- priority
+			release()
+		}(ctx)
+		waitFor(priority, 1)
+	}
+	release()
+	if first, second := <-order, <-order; first != PriorityInteractive || second != PriorityBackground {
+		t.Errorf("expected interactive call to be given the slot first, got %v %v", first, second)
+	}
+
+	// background calls cannot take the reserved slot
+	l = &concurrencyLimiter{limit: 2, reserve: 1}
+	if _, err := l.acquire(background); err != nil {
+		t.Fatalf("failed to acquire slot %v", err)
+	}
+	ctx, cancel := context.WithTimeout(background, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); err == nil {
+		t.Errorf("expected background call not to be given the reserved slot")
+	}
+	if _, err := l.acquire(interactive); err != nil {
+		t.Errorf("expected interactive call to be given the reserved slot, got %v", err)
+	}
+
+	b := NewTokenBucket(1000, 1)
+	b.interactive = 1
+	if b.take(true) <= 0 {
+		t.Errorf("expected background call to wait for interactive calls")
+	}
+	if b.take(false) != 0 {
+		t.Errorf("expected interactive call to take a token")
+	}
+
+	if _, err := CreateAPIClient(WithMaxConcurrentRequests(2), WithInteractiveReserve(2)); err == nil {
+		t.Errorf("expected error reserving all slots")
+	}
+}
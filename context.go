@@ -0,0 +1,68 @@
+package flannel
+
+import "context"
+
+type contextKey int
+
+const (
+	tenantContextKey contextKey = iota
+	requestIDContextKey
+	priorityContextKey
+)
+
+// ContextWithTenant returns a copy of ctx carrying the tenant on whose behalf API calls are made.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant set on ctx with ContextWithTenant, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey).(string)
+	return tenant, ok
+}
+
+// ContextWithRequestID returns a copy of ctx carrying a caller correlation ID, which is included in the log lines
+// of API calls made with ctx and, if enabled with WithRequestIDHeader, sent to Facebook in a request header.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID set on ctx with ContextWithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey).(string)
+	return requestID, ok
+}
+
+// Priority is the priority of API calls, which is set on their context with ContextWithPriority.
+type Priority int
+
+// Priorities of API calls.
+const (
+	// PriorityInteractive API calls are made whilst a user waits, such as creating a fundraiser as they sign up.
+	// It is the default.
+	PriorityInteractive Priority = iota
+
+	// PriorityBackground API calls are made by bulk operations, such as syncs and exports, and yield to interactive
+	// API calls waiting for a concurrent request slot, configured with WithMaxConcurrentRequests, or a TokenBucket.
+	// PlanSync, ApplySync, ImportFundraisers, the exports, DonationPoller and CampaignCleanup default to it.
+	PriorityBackground
+)
+
+// ContextWithPriority returns a copy of ctx carrying the priority of API calls made with it.
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey, priority)
+}
+
+// PriorityFromContext returns the priority set on ctx with ContextWithPriority, or PriorityInteractive.
+func PriorityFromContext(ctx context.Context) Priority {
+	priority, _ := ctx.Value(priorityContextKey).(Priority)
+	return priority
+}
+
+// backgroundContext returns ctx with PriorityBackground, unless a priority is already set.
+func backgroundContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(priorityContextKey).(Priority); ok {
+		return ctx
+	}
+	return ContextWithPriority(ctx, PriorityBackground)
+}
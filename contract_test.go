@@ -0,0 +1,172 @@
+//go:build flannel_live
+
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// The live contract test runs the full fundraiser lifecycle against the Graph API using a test user and test charity,
+// reporting schema drift so that breaking Graph API changes are caught before release. Run it with:
+//
+//	go test -tags flannel_live -run TestLiveContract -v
+//
+// ACCESS_TOKEN and CHARITY_ID must be set as for TestCreateFundraiser. Facebook does not support creating donations
+// through the API, so DONATED_FUNDRAISER_ID can optionally be set to a fundraiser with test donations made by hand.
+
+// fundraiserSchema is the JSON type of each field of a fundraiser.
+var fundraiserSchema = map[string]string{
+	"id":            "string",
+	"name":          "string",
+	"description":   "string",
+	"goal_amount":   "number",
+	"amount_raised": "number",
+	"currency":      "string",
+	"end_time":      "string",
+	"external_id":   "string",
+	"charity":       "object",
+}
+
+// donationSchema is the JSON type of each field of a donation.
+var donationSchema = map[string]string{
+	"id":           "string",
+	"amount":       "number",
+	"currency":     "string",
+	"created_time": "string",
+}
+
+func TestLiveContract(t *testing.T) {
+	accessToken := os.Getenv("ACCESS_TOKEN")
+	charityID := os.Getenv("CHARITY_ID")
+	if accessToken == "" || charityID == "" {
+		t.Skip("ACCESS_TOKEN and CHARITY_ID are required")
+	}
+	ctx := context.Background()
+	c, err := CreateAPIClient(WithLogger(t, false), WithDeprecationHandler(func(ctx context.Context, d Deprecation) {
+		t.Errorf("deprecated %s %s: %s", d.Method, d.Endpoint, d.Message)
+	}, false))
+	if err != nil {
+		t.Fatalf("failed to create api client %v", err)
+	}
+	fields := schemaFields(fundraiserSchema)
+
+	externalID := fmt.Sprintf("contract_%s", time.Now().Format("20060102150405"))
+	_, result, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{
+		AccessToken: accessToken,
+		CharityID:   charityID,
+		Title:       "Contract Test " + externalID,
+		Description: "The description for Contract Test " + externalID,
+		Goal:        100000,
+		Currency:    "GBP",
+		EndTime:     time.Now().AddDate(0, 1, 0),
+		ExternalID:  externalID,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	checkSchema(t, "create", result, map[string]string{"id": "string"})
+	id, _ := result["id"].(string)
+
+	_, result, err = c.GetFundraiser(ctx, accessToken, id, fields...)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	checkSchema(t, "get", result, fundraiserSchema)
+	if result["external_id"] != externalID {
+		t.Errorf("get: expected external_id %s, got %v", externalID, result["external_id"])
+	}
+
+	_, result, err = c.UpdateFundraiser(ctx, accessToken, id, map[string]string{"name": "Updated Contract Test " + externalID})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	checkSchema(t, "update", result, map[string]string{"success": "bool"})
+	_, result, err = c.GetFundraiser(ctx, accessToken, id, "name")
+	if err != nil || result["name"] != "Updated Contract Test "+externalID {
+		t.Errorf("update: expected updated name, got %v %v", result["name"], err)
+	}
+
+	donated := os.Getenv("DONATED_FUNDRAISER_ID")
+	if donated == "" {
+		donated = id
+	}
+	it := c.ListDonations(ctx, accessToken, donated, schemaFields(donationSchema)...)
+	for it.Next() {
+		checkSchema(t, "donation", it.Item(), donationSchema)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("donations: %v", err)
+	}
+	it.Close()
+
+	var found bool
+	it = c.ListFundraisers(ctx, accessToken, fields...)
+	for it.Next() {
+		checkSchema(t, "list", it.Item(), fundraiserSchema)
+		if it.Item()["id"] == id {
+			found = true
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("list: %v", err)
+	}
+	it.Close()
+	if !found {
+		t.Errorf("list: created fundraiser %s not found", id)
+	}
+}
+
+// checkSchema reports fields of object which are missing or have changed type as errors,
+// and fields which are not in schema as drift to review.
+func checkSchema(t *testing.T, name string, object map[string]interface{}, schema map[string]string) {
+	t.Helper()
+	for _, field := range schemaFields(schema) {
+		v, exists := object[field]
+		if !exists {
+			t.Errorf("%s: schema drift, field %s is missing", name, field)
+			continue
+		}
+		if kind := jsonKind(v); kind != schema[field] {
+			t.Errorf("%s: schema drift, field %s is %s not %s", name, field, kind, schema[field])
+		}
+	}
+	for field := range object {
+		if _, exists := schema[field]; !exists {
+			t.Logf("%s: schema drift, new field %s of %s", name, field, jsonKind(object[field]))
+		}
+	}
+}
+
+func schemaFields(schema map[string]string) []string {
+	fields := make([]string, 0, len(schema))
+	for field := range schema {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
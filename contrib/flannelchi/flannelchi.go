@@ -0,0 +1,34 @@
+// Package flannelchi routes Facebook webhooks to a flannel.WebhookHandler with chi.
+//
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{
+//		Tenant:           flannelchi.Param("tenant"),
+//		TenantAppSecrets: appSecrets,
+//		OnEvent:          q.Enqueue,
+//	})
+//	flannelchi.Route(r, "/webhooks/{tenant}", h)
+//
+// The tenant path parameter is not covered by the signature of webhook requests, so TenantAppSecrets looks up the
+// app secrets of each tenant's Facebook app, rejecting requests unless signed for their tenant.
+package flannelchi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/homemade/flannel"
+)
+
+// Route routes subscription verification GET requests and event notification POST requests to pattern to h,
+// chi responds to other methods with 405 Method Not Allowed.
+func Route(r chi.Router, pattern string, h *flannel.WebhookHandler) {
+	r.Get(pattern, h.Verify)
+	r.Post(pattern, h.Receive)
+}
+
+// Param returns a func returning the URL parameter name of a request, such as "tenant", for use as
+// WebhookSettings Tenant.
+func Param(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return chi.URLParam(r, name)
+	}
+}
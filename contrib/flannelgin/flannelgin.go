@@ -0,0 +1,45 @@
+// Package flannelgin routes Facebook webhooks to a flannel.WebhookHandler with gin.
+//
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{
+//		Tenant:           flannelgin.Param("tenant"),
+//		TenantAppSecrets: appSecrets,
+//		OnEvent:          q.Enqueue,
+//	})
+//	flannelgin.Route(r, "/webhooks/:tenant", h)
+//
+// The tenant path parameter is not covered by the signature of webhook requests, so TenantAppSecrets looks up the
+// app secrets of each tenant's Facebook app, rejecting requests unless signed for their tenant.
+package flannelgin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homemade/flannel"
+)
+
+// Route routes subscription verification GET requests and event notification POST requests to path to h.
+func Route(r gin.IRoutes, path string, h *flannel.WebhookHandler) {
+	r.GET(path, handle(h.Verify))
+	r.POST(path, handle(h.Receive))
+}
+
+type paramsKey struct{}
+
+// handle adapts f to gin, adding the path parameters of the route to the context of the request for Param.
+func handle(f http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), paramsKey{}, c.Params)
+		f(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+// Param returns a func returning the path parameter name of a request routed by Route, such as "tenant",
+// for use as WebhookSettings Tenant.
+func Param(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		params, _ := r.Context().Value(paramsKey{}).(gin.Params)
+		return params.ByName(name)
+	}
+}
@@ -0,0 +1,58 @@
+package flannelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homemade/flannel"
+	"github.com/homemade/flannel/flanneltest"
+)
+
+func TestRoute(t *testing.T) {
+	var tenants []string
+	h := flannel.NewWebhookHandler(flannel.WebhookSettings{
+		VerifyToken: "token",
+		Tenant:      Param("tenant"),
+		TenantAppSecrets: func(tenant string) []string {
+			if tenant == "acme" {
+				return []string{"secret"}
+			}
+			return nil
+		},
+		OnEvent: func(ctx context.Context, event flannel.WebhookEvent) error {
+			tenant, _ := flannel.TenantFromContext(ctx)
+			tenants = append(tenants, tenant)
+			return nil
+		},
+	})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Route(r, "/webhooks/:tenant", h)
+	serve := func(req *http.Request, path string) *httptest.ResponseRecorder {
+		req.URL.Path = path
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(flanneltest.NewWebhookVerificationRequest("token", "challenge"), "/webhooks/acme"); w.Code != http.StatusOK || w.Body.String() != "challenge" {
+		t.Errorf("unexpected verification response %d %q", w.Code, w.Body.String())
+	}
+	donation := flannel.WebhookDonation{ID: "1", FundraiserID: "1234"}
+	if w := serve(flanneltest.NewDonationCreatedRequest("secret", donation), "/webhooks/acme"); w.Code != http.StatusOK {
+		t.Errorf("unexpected event response %d %q", w.Code, w.Body.String())
+	}
+	// the request is signed for acme, not other
+	if w := serve(flanneltest.NewDonationCreatedRequest("secret", donation), "/webhooks/other"); w.Code != http.StatusForbidden {
+		t.Errorf("expected request for another tenant to be rejected, got %d", w.Code)
+	}
+	if len(tenants) != 1 || tenants[0] != "acme" {
+		t.Errorf("unexpected tenants of events %v", tenants)
+	}
+	if w := serve(httptest.NewRequest(http.MethodPut, "/", nil), "/webhooks/acme"); w.Code != http.StatusNotFound {
+		t.Errorf("expected other methods not to be routed, got %d", w.Code)
+	}
+}
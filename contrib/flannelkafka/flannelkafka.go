@@ -0,0 +1,54 @@
+// Package flannelkafka publishes the webhook events received by flannel, such as donations, to Kafka.
+//
+//	w := &kafka.Writer{Addr: kafka.TCP("localhost:9092"), Balancer: &kafka.Hash{}}
+//	sink := flannelkafka.New(w, flannelkafka.Settings{Topic: "giving-activity"})
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{AppSecret: secret, OnEvent: flannel.PublishTo(sink)})
+package flannelkafka
+
+import (
+	"context"
+
+	"github.com/homemade/flannel"
+	"github.com/segmentio/kafka-go"
+)
+
+// MessageWriter is the method of the Kafka writer used by a Sink, it is implemented by *kafka.Writer.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Settings configures a Sink.
+type Settings struct {
+
+	// Topic is the topic events are written to, it must be empty if the Topic of the writer is set.
+	Topic string
+}
+
+// Sink implements flannel.EventSink by writing events encoded with flannel.MarshalEvent to a Kafka topic.
+// Messages are keyed by the fundraiser id, so that with a hash balancer the events of each fundraiser are written
+// to the same partition in order, and have the event type as the event_type header.
+type Sink struct {
+	w        MessageWriter
+	settings Settings
+}
+
+var _ flannel.EventSink = (*Sink)(nil)
+
+// New creates a new Sink writing events with w.
+func New(w MessageWriter, settings Settings) *Sink {
+	return &Sink{w: w, settings: settings}
+}
+
+// Publish implements flannel.EventSink, waiting until the event is written as configured by the writer.
+func (s *Sink) Publish(ctx context.Context, event flannel.WebhookEvent) error {
+	b, err := flannel.MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+	return s.w.WriteMessages(ctx, kafka.Message{
+		Topic:   s.settings.Topic,
+		Key:     []byte(event.FundraiserID()),
+		Value:   b,
+		Headers: []kafka.Header{{Key: "event_type", Value: []byte(event.Type)}},
+	})
+}
@@ -0,0 +1,41 @@
+package flannelkafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/homemade/flannel"
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeWriter struct {
+	written []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	w := &fakeWriter{}
+	sink := New(w, Settings{Topic: "giving-activity"})
+	event := flannel.WebhookEvent{Type: flannel.DonationCreated, EntryID: "1234", Donation: &flannel.WebhookDonation{ID: "1", FundraiserID: "1234"}}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("failed to publish event %v", err)
+	}
+	if len(w.written) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(w.written))
+	}
+	msg := w.written[0]
+	if msg.Topic != "giving-activity" || string(msg.Key) != "1234" {
+		t.Errorf("unexpected message topic %q and key %q", msg.Topic, msg.Key)
+	}
+	if len(msg.Headers) != 1 || msg.Headers[0].Key != "event_type" || string(msg.Headers[0].Value) != string(flannel.DonationCreated) {
+		t.Errorf("unexpected headers %v", msg.Headers)
+	}
+	decoded, err := flannel.UnmarshalEvent(msg.Value)
+	if err != nil || decoded.Donation == nil || decoded.Donation.ID != "1" {
+		t.Errorf("unexpected message value %s %v", msg.Value, err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package flannelprom exports the metrics of a flannel.APIClient to Prometheus.
+//
+//	m, err := flannelprom.New(prometheus.DefaultRegisterer, "flannel")
+//	c, err := flannel.CreateAPIClient(flannel.WithMetrics(m))
+package flannelprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/homemade/flannel"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements flannel.Metrics using Prometheus collectors.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	errors        *prometheus.CounterVec
+	retries       *prometheus.CounterVec
+	rateLimitWait *prometheus.HistogramVec
+	appUsage      *prometheus.GaugeVec
+	connection    *prometheus.HistogramVec
+	secrets       *prometheus.CounterVec
+}
+
+var (
+	_ flannel.ConnectionMetrics = (*Metrics)(nil)
+	_ flannel.SecretMetrics     = (*Metrics)(nil)
+)
+
+// New creates Metrics with collectors registered against reg, with metric names prefixed by namespace.
+func New(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Facebook API requests by method, endpoint and status code, 0 if no response was received.",
+		}, []string{"method", "endpoint", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to receive the response headers of Facebook API requests.",
+			Buckets:   []float64{.025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20},
+		}, []string{"method", "endpoint"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Facebook API errors by method, endpoint, error code and subcode.",
+		}, []string{"method", "endpoint", "code", "subcode"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Retried Facebook API requests by method and endpoint.",
+		}, []string{"method", "endpoint"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time spent waiting on rate limiters before Facebook API requests, by tenant.",
+			Buckets:   []float64{.001, .01, .1, .5, 1, 5, 10, 30},
+		}, []string{"tenant"}),
+		appUsage: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "app_usage_percent",
+			Help:      "Percentage of the app's rate limit used, as reported by Facebook in the X-App-Usage header.",
+		}, []string{"type"}),
+		connection: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "connection_phase_seconds",
+			Help:      "Duration of the dns, connect, tls and first_byte phases of Facebook API requests, when connection tracing is enabled.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20},
+		}, []string{"phase"}),
+		secrets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "secret_matches_total",
+			Help:      "App secrets matched by use, appsecret_proof or webhook, and index, 0 for the current secret.",
+		}, []string{"use", "index"}),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.latency, m.errors, m.retries, m.rateLimitWait, m.appUsage, m.connection, m.secrets} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveRequest implements flannel.Metrics.
+func (m *Metrics) ObserveRequest(method string, endpoint string, status int, latency time.Duration) {
+	m.requests.WithLabelValues(method, endpoint, strconv.Itoa(status)).Inc()
+	m.latency.WithLabelValues(method, endpoint).Observe(latency.Seconds())
+}
+
+// ObserveError implements flannel.Metrics.
+func (m *Metrics) ObserveError(method string, endpoint string, code int, subcode int) {
+	m.errors.WithLabelValues(method, endpoint, strconv.Itoa(code), strconv.Itoa(subcode)).Inc()
+}
+
+// ObserveRetry implements flannel.Metrics.
+func (m *Metrics) ObserveRetry(method string, endpoint string) {
+	m.retries.WithLabelValues(method, endpoint).Inc()
+}
+
+// ObserveRateLimitWait implements flannel.Metrics.
+func (m *Metrics) ObserveRateLimitWait(tenant string, wait time.Duration) {
+	m.rateLimitWait.WithLabelValues(tenant).Observe(wait.Seconds())
+}
+
+// ObserveAppUsage implements flannel.Metrics.
+func (m *Metrics) ObserveAppUsage(callCount float64, totalCPUTime float64, totalTime float64) {
+	m.appUsage.WithLabelValues("call_count").Set(callCount)
+	m.appUsage.WithLabelValues("total_cputime").Set(totalCPUTime)
+	m.appUsage.WithLabelValues("total_time").Set(totalTime)
+}
+
+// ObserveConnection implements flannel.ConnectionMetrics.
+func (m *Metrics) ObserveConnection(trace flannel.ConnectionTrace) {
+	for phase, d := range map[string]time.Duration{
+		"dns":        trace.DNS,
+		"connect":    trace.Connect,
+		"tls":        trace.TLSHandshake,
+		"first_byte": trace.TimeToFirstByte,
+	} {
+		if d > 0 {
+			m.connection.WithLabelValues(phase).Observe(d.Seconds())
+		}
+	}
+}
+
+// ObserveSecretMatch implements flannel.SecretMetrics.
+func (m *Metrics) ObserveSecretMatch(use string, index int) {
+	m.secrets.WithLabelValues(use, strconv.Itoa(index)).Inc()
+}
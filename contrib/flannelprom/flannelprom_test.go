@@ -0,0 +1,59 @@
+package flannelprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homemade/flannel"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := New(reg, "flannel")
+	if err != nil {
+		t.Fatalf("failed to create metrics %v", err)
+	}
+	m.ObserveRequest("POST", "/fundraisers", 200, 300*time.Millisecond)
+	m.ObserveRequest("POST", "/fundraisers", 400, 100*time.Millisecond)
+	m.ObserveError("POST", "/fundraisers", 100, 2078014)
+	m.ObserveRetry("GET", "/fundraiser")
+	m.ObserveAppUsage(10, 20, 30)
+	m.ObserveConnection(flannel.ConnectionTrace{Connect: 5 * time.Millisecond})
+	m.ObserveSecretMatch(flannel.SecretUseWebhook, 1)
+
+	expected := `
+# HELP flannel_requests_total Facebook API requests by method, endpoint and status code, 0 if no response was received.
+# TYPE flannel_requests_total counter
+flannel_requests_total{endpoint="/fundraisers",method="POST",status="200"} 1
+flannel_requests_total{endpoint="/fundraisers",method="POST",status="400"} 1
+# HELP flannel_errors_total Facebook API errors by method, endpoint, error code and subcode.
+# TYPE flannel_errors_total counter
+flannel_errors_total{code="100",endpoint="/fundraisers",method="POST",subcode="2078014"} 1
+# HELP flannel_app_usage_percent Percentage of the app's rate limit used, as reported by Facebook in the X-App-Usage header.
+# TYPE flannel_app_usage_percent gauge
+flannel_app_usage_percent{type="call_count"} 10
+flannel_app_usage_percent{type="total_cputime"} 20
+flannel_app_usage_percent{type="total_time"} 30
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "flannel_requests_total", "flannel_errors_total", "flannel_app_usage_percent"); err != nil {
+		t.Error(err)
+	}
+	for name, want := range map[string]int{
+		"flannel_request_duration_seconds": 1,
+		"flannel_retries_total":            1,
+		"flannel_connection_phase_seconds": 1,
+		"flannel_secret_matches_total":     1,
+		"flannel_rate_limit_wait_seconds":  0,
+	} {
+		if got, err := testutil.GatherAndCount(reg, name); err != nil || got != want {
+			t.Errorf("expected %d %s series, got %d %v", want, name, got, err)
+		}
+	}
+
+	if _, err := New(reg, "flannel"); err == nil {
+		t.Error("expected error registering collectors twice")
+	}
+}
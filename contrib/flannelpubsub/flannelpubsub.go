@@ -0,0 +1,89 @@
+// Package flannelpubsub publishes the webhook events received by flannel to Google Cloud Pub/Sub.
+//
+//	sink := flannelpubsub.New(client.Topic("fundraising-events"), flannelpubsub.Settings{})
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{AppSecret: secret, OnEvent: flannel.PublishTo(sink)})
+package flannelpubsub
+
+import (
+	"context"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/homemade/flannel"
+)
+
+// Publisher publishes messages to a topic, it is implemented for a *pubsub.Topic by TopicPublisher.
+type Publisher interface {
+
+	// Publish publishes msg, waiting until it is accepted by Pub/Sub, and returns its server id.
+	Publish(ctx context.Context, msg *pubsub.Message) (serverID string, err error)
+
+	// ResumePublish resumes publishing messages with orderingKey after an error.
+	ResumePublish(orderingKey string)
+}
+
+// TopicPublisher adapts a *pubsub.Topic to a Publisher.
+type TopicPublisher struct {
+	Topic *pubsub.Topic
+}
+
+// Publish implements Publisher.
+func (p TopicPublisher) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
+	return p.Topic.Publish(ctx, msg).Get(ctx)
+}
+
+// ResumePublish implements Publisher.
+func (p TopicPublisher) ResumePublish(orderingKey string) {
+	p.Topic.ResumePublish(orderingKey)
+}
+
+// Settings configures a Sink.
+type Settings struct {
+
+	// Ordered publishes events with the fundraiser id as the ordering key, so that the events of each fundraiser
+	// are received in order. The topic must have message ordering enabled.
+	Ordered bool
+}
+
+// Sink implements flannel.EventSink by publishing events encoded with flannel.MarshalEvent to a Pub/Sub topic,
+// with the event type and fundraiser id as attributes.
+type Sink struct {
+	publisher Publisher
+	settings  Settings
+}
+
+var _ flannel.EventSink = (*Sink)(nil)
+
+// New creates a new Sink publishing events to topic.
+func New(topic *pubsub.Topic, settings Settings) *Sink {
+	topic.EnableMessageOrdering = settings.Ordered
+	return NewWithPublisher(TopicPublisher{Topic: topic}, settings)
+}
+
+// NewWithPublisher creates a new Sink publishing events with p, for example a fake Publisher in tests.
+func NewWithPublisher(p Publisher, settings Settings) *Sink {
+	return &Sink{publisher: p, settings: settings}
+}
+
+// Publish implements flannel.EventSink, waiting until the event is accepted by Pub/Sub.
+func (s *Sink) Publish(ctx context.Context, event flannel.WebhookEvent) error {
+	b, err := flannel.MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+	msg := &pubsub.Message{
+		Data: b,
+		Attributes: map[string]string{
+			"event_type":    string(event.Type),
+			"fundraiser_id": event.FundraiserID(),
+		},
+	}
+	if s.settings.Ordered {
+		msg.OrderingKey = event.FundraiserID()
+	}
+	_, err = s.publisher.Publish(ctx, msg)
+	if err != nil && s.settings.Ordered {
+		// publishing is paused for the ordering key after an error, resume it so that the event can be retried
+		s.publisher.ResumePublish(msg.OrderingKey)
+	}
+	return err
+}
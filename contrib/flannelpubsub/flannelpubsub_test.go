@@ -0,0 +1,53 @@
+package flannelpubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/homemade/flannel"
+)
+
+type fakePublisher struct {
+	published []*pubsub.Message
+	resumed   []string
+	err       error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
+	p.published = append(p.published, msg)
+	return "1", p.err
+}
+
+func (p *fakePublisher) ResumePublish(orderingKey string) {
+	p.resumed = append(p.resumed, orderingKey)
+}
+
+func TestSink(t *testing.T) {
+	p := &fakePublisher{}
+	sink := NewWithPublisher(p, Settings{Ordered: true})
+	event := flannel.WebhookEvent{Type: flannel.DonationCreated, EntryID: "1234", Donation: &flannel.WebhookDonation{ID: "1", FundraiserID: "1234"}}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("failed to publish event %v", err)
+	}
+	if len(p.published) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(p.published))
+	}
+	msg := p.published[0]
+	if msg.OrderingKey != "1234" || msg.Attributes["event_type"] != string(flannel.DonationCreated) || msg.Attributes["fundraiser_id"] != "1234" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	decoded, err := flannel.UnmarshalEvent(msg.Data)
+	if err != nil || decoded.Donation == nil || decoded.Donation.ID != "1" {
+		t.Errorf("unexpected message data %s %v", msg.Data, err)
+	}
+
+	p.err = errors.New("unavailable")
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Error("expected publish error to be returned")
+	}
+	if len(p.resumed) != 1 || p.resumed[0] != "1234" {
+		t.Errorf("expected publishing to be resumed for the ordering key, got %v", p.resumed)
+	}
+}
@@ -0,0 +1,100 @@
+// Package flannelqr generates QR codes of fundraiser links, for printed event materials.
+//
+//	png, err := flannelqr.PNG(fundraiserID, flannelqr.Settings{Size: 512})
+package flannelqr
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/homemade/flannel"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ErrorCorrection is the error correction level of a QR code, higher levels remain readable when more of the
+// code is damaged or obscured, at the cost of a denser code.
+type ErrorCorrection int
+
+// Error correction levels, Low, Medium, High and Highest recover 7%, 15%, 25% and 30% of the code respectively.
+const (
+	Medium ErrorCorrection = iota
+	Low
+	High
+	Highest
+)
+
+// Settings configures the QR codes generated.
+type Settings struct {
+
+	// Size is the width and height of the QR code in pixels, defaults to 256.
+	Size int
+
+	// ErrorCorrection defaults to Medium.
+	ErrorCorrection ErrorCorrection
+
+	// UTM parameters are added to the fundraiser link encoded.
+	UTM flannel.UTMParams
+}
+
+// PNG returns a PNG image of a QR code of the share link of the fundraiser identified by its ID or permalink.
+func PNG(idOrPermalink string, settings Settings) ([]byte, error) {
+	q, size, err := newQRCode(idOrPermalink, settings)
+	if err != nil {
+		return nil, err
+	}
+	return q.PNG(size)
+}
+
+// SVG returns an SVG image of a QR code of the share link of the fundraiser identified by its ID or permalink.
+// The image scales without loss so is better suited to print.
+func SVG(idOrPermalink string, settings Settings) ([]byte, error) {
+	q, size, err := newQRCode(idOrPermalink, settings)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		// each run of dark modules is drawn as a single rectangle
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
+
+func newQRCode(idOrPermalink string, settings Settings) (*qrcode.QRCode, int, error) {
+	links, err := flannel.NewFundraiserLinks(idOrPermalink, flannel.LinkSettings{UTM: settings.UTM})
+	if err != nil {
+		return nil, 0, err
+	}
+	level := qrcode.Medium
+	switch settings.ErrorCorrection {
+	case Low:
+		level = qrcode.Low
+	case High:
+		level = qrcode.High
+	case Highest:
+		level = qrcode.Highest
+	}
+	size := settings.Size
+	if size <= 0 {
+		size = 256
+	}
+	q, err := qrcode.New(links.Share, level)
+	if err != nil {
+		return nil, 0, err
+	}
+	return q, size, nil
+}
@@ -0,0 +1,76 @@
+package flannelqr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/homemade/flannel"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+func TestPNG(t *testing.T) {
+	b, err := PNG("1234", Settings{Size: 300})
+	if err != nil {
+		t.Fatalf("failed to generate png %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to decode png %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 300 || bounds.Dy() != 300 {
+		t.Errorf("unexpected size %v", bounds)
+	}
+	// the quiet zone around the code is white
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("expected white quiet zone, got %v", img.At(0, 0))
+	}
+}
+
+func TestSVG(t *testing.T) {
+	b, err := SVG("1234", Settings{Size: 300, ErrorCorrection: High})
+	if err != nil {
+		t.Fatalf("failed to generate svg %v", err)
+	}
+	var svg struct {
+		Width   string `xml:"width,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+		Path    struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(b, &svg); err != nil {
+		t.Fatalf("failed to parse svg %v", err)
+	}
+
+	// the modules drawn must be those of a code of the share link
+	links, _ := flannel.NewFundraiserLinks("1234", flannel.LinkSettings{})
+	q, err := qrcode.New(links.Share, qrcode.High)
+	if err != nil {
+		t.Fatalf("failed to generate expected code %v", err)
+	}
+	want := q.Bitmap()
+	if svg.Width != "300" || svg.ViewBox != fmt.Sprintf("0 0 %d %d", len(want), len(want)) {
+		t.Errorf("unexpected width %s and view box %s", svg.Width, svg.ViewBox)
+	}
+	got := make([][]bool, len(want))
+	for i := range got {
+		got[i] = make([]bool, len(want))
+	}
+	for _, rect := range strings.Split(strings.TrimSuffix(svg.Path.D, "z"), "z") {
+		var x, y, w int
+		if _, err := fmt.Sscanf(rect, "M%d %dh%d", &x, &y, &w); err != nil {
+			t.Fatalf("failed to parse path %q %v", rect, err)
+		}
+		for i := x; i < x+w; i++ {
+			got[y][i] = true
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("expected svg to draw the modules of the share link code")
+	}
+}
@@ -0,0 +1,166 @@
+// Package flannelsql implements flannel.MirrorStore with SQLite or Postgres, using any database/sql driver.
+//
+//	db, err := sql.Open("pgx", dsn)
+//	store := flannelsql.New(db, flannelsql.Postgres)
+//	err = store.Migrate(ctx)
+//	c, err := flannel.CreateAPIClient(flannel.WithMirrorStore(store))
+package flannelsql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/homemade/flannel"
+)
+
+// Dialect is the SQL dialect of a database.
+type Dialect int
+
+// Supported dialects.
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// Store implements flannel.MirrorStore with the flannel_fundraisers and flannel_donations tables.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+var _ flannel.MirrorStore = (*Store)(nil)
+
+// New creates a new Store using db.
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// Migrate creates the tables of the Store if they do not exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	timestamp := "TIMESTAMP"
+	if s.dialect == Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS flannel_fundraisers (
+			id TEXT PRIMARY KEY,
+			external_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL,
+			goal_amount BIGINT NOT NULL,
+			amount_raised BIGINT NOT NULL,
+			currency TEXT NOT NULL,
+			end_time ` + timestamp + ` NOT NULL,
+			mirrored_at ` + timestamp + ` NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS flannel_donations (
+			id TEXT PRIMARY KEY,
+			fundraiser_id TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			currency TEXT NOT NULL,
+			created_time ` + timestamp + ` NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS flannel_donations_fundraiser_id ON flannel_donations (fundraiser_id, created_time)`,
+	} {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query rewrites the ? placeholders of query for the dialect.
+func (s *Store) query(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SaveFundraiser implements flannel.MirrorStore.
+func (s *Store) SaveFundraiser(ctx context.Context, f flannel.MirroredFundraiser) error {
+	_, err := s.db.ExecContext(ctx, s.query(`INSERT INTO flannel_fundraisers
+		(id, external_id, name, description, goal_amount, amount_raised, currency, end_time, mirrored_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET external_id = excluded.external_id, name = excluded.name,
+		description = excluded.description, goal_amount = excluded.goal_amount, amount_raised = excluded.amount_raised,
+		currency = excluded.currency, end_time = excluded.end_time, mirrored_at = excluded.mirrored_at`),
+		f.ID, f.ExternalID, f.Name, f.Description, f.GoalAmount, f.AmountRaised, f.Currency, f.EndTime.UTC(), f.MirroredAt.UTC())
+	return err
+}
+
+// SaveDonation implements flannel.MirrorStore.
+func (s *Store) SaveDonation(ctx context.Context, d flannel.MirroredDonation) error {
+	_, err := s.db.ExecContext(ctx, s.query(`INSERT INTO flannel_donations (id, fundraiser_id, amount, currency, created_time)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET fundraiser_id = excluded.fundraiser_id, amount = excluded.amount,
+		currency = excluded.currency, created_time = excluded.created_time`),
+		d.ID, d.FundraiserID, d.Amount, d.Currency, d.CreatedTime.UTC())
+	return err
+}
+
+const fundraiserColumns = `id, external_id, name, description, goal_amount, amount_raised, currency, end_time, mirrored_at`
+
+func scanFundraiser(row interface{ Scan(...interface{}) error }) (flannel.MirroredFundraiser, error) {
+	var f flannel.MirroredFundraiser
+	err := row.Scan(&f.ID, &f.ExternalID, &f.Name, &f.Description, &f.GoalAmount, &f.AmountRaised, &f.Currency, &f.EndTime, &f.MirroredAt)
+	return f, err
+}
+
+// Fundraiser implements flannel.MirrorStore.
+func (s *Store) Fundraiser(ctx context.Context, id string) (flannel.MirroredFundraiser, bool, error) {
+	f, err := scanFundraiser(s.db.QueryRowContext(ctx, s.query(`SELECT `+fundraiserColumns+` FROM flannel_fundraisers WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return f, false, nil
+	}
+	return f, err == nil, err
+}
+
+// Fundraisers implements flannel.MirrorStore.
+func (s *Store) Fundraisers(ctx context.Context) ([]flannel.MirroredFundraiser, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+fundraiserColumns+` FROM flannel_fundraisers ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fundraisers []flannel.MirroredFundraiser
+	for rows.Next() {
+		f, err := scanFundraiser(rows)
+		if err != nil {
+			return nil, err
+		}
+		fundraisers = append(fundraisers, f)
+	}
+	return fundraisers, rows.Err()
+}
+
+// Donations implements flannel.MirrorStore.
+func (s *Store) Donations(ctx context.Context, fundraiserID string) ([]flannel.MirroredDonation, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT id, fundraiser_id, amount, currency, created_time
+		FROM flannel_donations WHERE fundraiser_id = ? ORDER BY created_time, id`), fundraiserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var donations []flannel.MirroredDonation
+	for rows.Next() {
+		var d flannel.MirroredDonation
+		if err := rows.Scan(&d.ID, &d.FundraiserID, &d.Amount, &d.Currency, &d.CreatedTime); err != nil {
+			return nil, err
+		}
+		donations = append(donations, d)
+	}
+	return donations, rows.Err()
+}
@@ -0,0 +1,166 @@
+package flannelsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+// fakeDB is a database/sql driver serving the statements of the Store from in-memory tables keyed by id.
+type fakeDB struct {
+	mu      sync.Mutex
+	tables  map[string]map[string][]driver.Value
+	queries []string
+}
+
+func (db *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	for _, table := range []string{"flannel_fundraisers", "flannel_donations"} {
+		if strings.HasPrefix(query, "INSERT INTO "+table) {
+			row := make([]driver.Value, len(args))
+			for i, arg := range args {
+				row[i] = arg.Value
+			}
+			c.db.tables[table][row[0].(string)] = row
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	rows := &fakeRows{}
+	switch {
+	case strings.Contains(query, "FROM flannel_fundraisers WHERE id"):
+		if row, exists := c.db.tables["flannel_fundraisers"][args[0].Value.(string)]; exists {
+			rows.rows = append(rows.rows, row)
+		}
+	case strings.Contains(query, "FROM flannel_fundraisers"):
+		for _, row := range c.db.tables["flannel_fundraisers"] {
+			rows.rows = append(rows.rows, row)
+		}
+		sort.Slice(rows.rows, func(i, j int) bool { return rows.rows[i][0].(string) < rows.rows[j][0].(string) })
+	case strings.Contains(query, "FROM flannel_donations WHERE fundraiser_id"):
+		for _, row := range c.db.tables["flannel_donations"] {
+			if row[1] == args[0].Value {
+				rows.rows = append(rows.rows, row)
+			}
+		}
+		sort.Slice(rows.rows, func(i, j int) bool {
+			ti, tj := rows.rows[i][4].(time.Time), rows.rows[j][4].(time.Time)
+			return ti.Before(tj) || ti.Equal(tj) && rows.rows[i][0].(string) < rows.rows[j][0].(string)
+		})
+	default:
+		return nil, errors.New("unexpected query " + query)
+	}
+	if len(rows.rows) > 0 {
+		rows.columns = make([]string, len(rows.rows[0]))
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{tables: map[string]map[string][]driver.Value{"flannel_fundraisers": {}, "flannel_donations": {}}}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := New(sql.OpenDB(newFakeDB()), SQLite)
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("failed to migrate %v", err)
+	}
+
+	end := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := flannel.MirroredFundraiser{ID: "2", ExternalID: "b", Name: "Marathon", GoalAmount: 10000, Currency: "GBP", EndTime: end, MirroredAt: end.AddDate(-1, 0, 0)}
+	second := flannel.MirroredFundraiser{ID: "1", ExternalID: "a", Name: "Swim", GoalAmount: 5000, Currency: "GBP", EndTime: end, MirroredAt: end.AddDate(-1, 0, 0)}
+	for _, f := range []flannel.MirroredFundraiser{first, second} {
+		if err := store.SaveFundraiser(ctx, f); err != nil {
+			t.Fatalf("failed to save fundraiser %v", err)
+		}
+	}
+	// saves replace the fundraiser with the same id
+	first.AmountRaised = 2500
+	if err := store.SaveFundraiser(ctx, first); err != nil {
+		t.Fatalf("failed to save fundraiser %v", err)
+	}
+	if f, found, err := store.Fundraiser(ctx, "2"); err != nil || !found || !reflect.DeepEqual(f, first) {
+		t.Errorf("unexpected fundraiser %+v %t %v", f, found, err)
+	}
+	if _, found, err := store.Fundraiser(ctx, "3"); err != nil || found {
+		t.Errorf("expected fundraiser not to be found, got %t %v", found, err)
+	}
+	if fundraisers, err := store.Fundraisers(ctx); err != nil || !reflect.DeepEqual(fundraisers, []flannel.MirroredFundraiser{second, first}) {
+		t.Errorf("unexpected fundraisers %+v %v", fundraisers, err)
+	}
+
+	later := flannel.MirroredDonation{ID: "d1", FundraiserID: "2", Amount: 1000, Currency: "GBP", CreatedTime: end.AddDate(0, -1, 0)}
+	earlier := flannel.MirroredDonation{ID: "d2", FundraiserID: "2", Amount: 1500, Currency: "GBP", CreatedTime: end.AddDate(0, -2, 0)}
+	other := flannel.MirroredDonation{ID: "d3", FundraiserID: "1", Amount: 500, Currency: "GBP", CreatedTime: end.AddDate(0, -2, 0)}
+	for _, d := range []flannel.MirroredDonation{later, earlier, other} {
+		if err := store.SaveDonation(ctx, d); err != nil {
+			t.Fatalf("failed to save donation %v", err)
+		}
+	}
+	if donations, err := store.Donations(ctx, "2"); err != nil || !reflect.DeepEqual(donations, []flannel.MirroredDonation{earlier, later}) {
+		t.Errorf("unexpected donations %+v %v", donations, err)
+	}
+}
+
+func TestStorePostgres(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	store := New(sql.OpenDB(db), Postgres)
+	if err := store.SaveFundraiser(ctx, flannel.MirroredFundraiser{ID: "1"}); err != nil {
+		t.Fatalf("failed to save fundraiser %v", err)
+	}
+	if _, _, err := store.Fundraiser(ctx, "1"); err != nil {
+		t.Fatalf("failed to read fundraiser %v", err)
+	}
+	for _, query := range db.queries {
+		if strings.Contains(query, "?") || !strings.Contains(query, "$1") {
+			t.Errorf("expected numbered placeholders, got %s", query)
+		}
+	}
+}
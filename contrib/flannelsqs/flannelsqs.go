@@ -0,0 +1,75 @@
+// Package flannelsqs publishes the webhook events received by flannel to Amazon SQS.
+//
+//	sink := flannelsqs.New(sqs.NewFromConfig(cfg), flannelsqs.Settings{QueueURL: url})
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{AppSecret: secret, OnEvent: flannel.PublishTo(sink)})
+package flannelsqs
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/homemade/flannel"
+)
+
+// SendMessageAPI is the method of the SQS client used by a Sink, it is implemented by *sqs.Client.
+type SendMessageAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// Settings configures a Sink.
+type Settings struct {
+
+	// QueueURL is the URL of the queue events are sent to.
+	QueueURL string
+
+	// FIFO is set for FIFO queues, events are sent with the fundraiser id as the message group id so that the
+	// events of each fundraiser are received in order, and the entry id, field and time as the deduplication id.
+	FIFO bool
+}
+
+// Sink implements flannel.EventSink by sending events encoded with flannel.MarshalEvent to an SQS queue, with
+// the event type and fundraiser id as message attributes.
+type Sink struct {
+	client   SendMessageAPI
+	settings Settings
+}
+
+var _ flannel.EventSink = (*Sink)(nil)
+
+// New creates a new Sink sending events using client.
+func New(client SendMessageAPI, settings Settings) *Sink {
+	return &Sink{client: client, settings: settings}
+}
+
+// Publish implements flannel.EventSink.
+func (s *Sink) Publish(ctx context.Context, event flannel.WebhookEvent) error {
+	b, err := flannel.MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.settings.QueueURL),
+		MessageBody: aws.String(string(b)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"event_type":    {DataType: aws.String("String"), StringValue: aws.String(string(event.Type))},
+			"fundraiser_id": {DataType: aws.String("String"), StringValue: aws.String(event.FundraiserID())},
+		},
+	}
+	if s.settings.FIFO {
+		input.MessageGroupId = aws.String(event.FundraiserID())
+		input.MessageDeduplicationId = aws.String(deduplicationID(event))
+	}
+	_, err = s.client.SendMessage(ctx, input)
+	return err
+}
+
+// deduplicationID identifies an event, so that events redelivered by Facebook are only received once.
+func deduplicationID(event flannel.WebhookEvent) string {
+	id := event.EntryID + ":" + event.Field + ":" + event.Time.UTC().Format("20060102T150405")
+	if event.Donation != nil {
+		id += ":" + event.Donation.ID
+	}
+	return id
+}
@@ -0,0 +1,52 @@
+package flannelsqs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/homemade/flannel"
+)
+
+type fakeSQS struct {
+	sent []*sqs.SendMessageInput
+}
+
+func (f *fakeSQS) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, params)
+	return &sqs.SendMessageOutput{MessageId: aws.String("1")}, nil
+}
+
+func TestSink(t *testing.T) {
+	client := &fakeSQS{}
+	sink := New(client, Settings{QueueURL: "https://sqs.eu-west-2.amazonaws.com/1/events.fifo", FIFO: true})
+	event := flannel.WebhookEvent{
+		Type:     flannel.DonationCreated,
+		EntryID:  "1234",
+		Field:    "donations",
+		Time:     time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		Donation: &flannel.WebhookDonation{ID: "1", FundraiserID: "1234"},
+	}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("failed to publish event %v", err)
+	}
+	if len(client.sent) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(client.sent))
+	}
+	input := client.sent[0]
+	if aws.ToString(input.QueueUrl) != "https://sqs.eu-west-2.amazonaws.com/1/events.fifo" || aws.ToString(input.MessageGroupId) != "1234" {
+		t.Errorf("unexpected message %+v", input)
+	}
+	if id := aws.ToString(input.MessageDeduplicationId); id != "1234:donations:20260102T150405:1" {
+		t.Errorf("unexpected deduplication id %q", id)
+	}
+	if attr := input.MessageAttributes["event_type"]; aws.ToString(attr.StringValue) != string(flannel.DonationCreated) {
+		t.Errorf("unexpected event type attribute %+v", attr)
+	}
+	decoded, err := flannel.UnmarshalEvent([]byte(aws.ToString(input.MessageBody)))
+	if err != nil || decoded.Donation == nil || decoded.Donation.ID != "1" {
+		t.Errorf("unexpected message body %s %v", aws.ToString(input.MessageBody), err)
+	}
+}
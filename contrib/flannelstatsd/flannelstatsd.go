@@ -0,0 +1,145 @@
+// Package flannelstatsd exports the metrics of a flannel.APIClient to StatsD, with tags in the DogStatsD format
+// accepted by the Datadog agent, Telegraf and the Prometheus statsd_exporter.
+//
+//	m, err := flannelstatsd.New("127.0.0.1:8125", flannelstatsd.Settings{Prefix: "flannel.", Tags: []string{"env:prod"}})
+//	c, err := flannel.CreateAPIClient(flannel.WithMetrics(m))
+package flannelstatsd
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+// Settings configures the metrics sent to StatsD.
+type Settings struct {
+
+	// Prefix is prepended to metric names, such as "flannel." for "flannel.requests".
+	Prefix string
+
+	// Tags are added to every metric, such as "env:prod".
+	Tags []string
+}
+
+// Metrics implements flannel.Metrics by sending metrics to StatsD. Metrics are sent as they are observed,
+// errors sending are ignored so that API calls are never slowed or failed by an unavailable StatsD.
+type Metrics struct {
+	settings Settings
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var (
+	_ flannel.ConnectionMetrics = (*Metrics)(nil)
+	_ flannel.SecretMetrics     = (*Metrics)(nil)
+)
+
+// New creates Metrics sending to the StatsD server at addr, such as "127.0.0.1:8125", over UDP.
+func New(addr string, settings Settings) (*Metrics, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithWriter(conn, settings), nil
+}
+
+// NewWithWriter creates Metrics writing StatsD lines to w, for example a Unix socket or, in tests, a buffer.
+func NewWithWriter(w io.Writer, settings Settings) *Metrics {
+	return &Metrics{settings: settings, w: w}
+}
+
+// Close closes the connection to StatsD, if it can be closed.
+func (m *Metrics) Close() error {
+	if c, ok := m.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// send writes a metric of type kind, "c", "g" or "ms", with value and tags.
+func (m *Metrics) send(name string, value string, kind string, tags ...string) {
+	var b strings.Builder
+	b.WriteString(m.settings.Prefix)
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+	tags = append(tags, m.settings.Tags...)
+	for i, tag := range tags {
+		if i == 0 {
+			b.WriteString("|#")
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(tag)
+	}
+	b.WriteByte('\n')
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.w.Write([]byte(b.String()))
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
+
+// ObserveRequest implements flannel.Metrics.
+func (m *Metrics) ObserveRequest(method string, endpoint string, status int, latency time.Duration) {
+	m.send("requests", "1", "c", "method:"+method, "endpoint:"+endpoint, "status:"+strconv.Itoa(status))
+	m.send("request_duration", milliseconds(latency), "ms", "method:"+method, "endpoint:"+endpoint)
+}
+
+// ObserveError implements flannel.Metrics.
+func (m *Metrics) ObserveError(method string, endpoint string, code int, subcode int) {
+	m.send("errors", "1", "c", "method:"+method, "endpoint:"+endpoint, "code:"+strconv.Itoa(code), "subcode:"+strconv.Itoa(subcode))
+}
+
+// ObserveRetry implements flannel.Metrics.
+func (m *Metrics) ObserveRetry(method string, endpoint string) {
+	m.send("retries", "1", "c", "method:"+method, "endpoint:"+endpoint)
+}
+
+// ObserveRateLimitWait implements flannel.Metrics.
+func (m *Metrics) ObserveRateLimitWait(tenant string, wait time.Duration) {
+	if tenant == "" {
+		m.send("rate_limit_wait", milliseconds(wait), "ms")
+		return
+	}
+	m.send("rate_limit_wait", milliseconds(wait), "ms", "tenant:"+tenant)
+}
+
+// ObserveAppUsage implements flannel.Metrics.
+func (m *Metrics) ObserveAppUsage(callCount float64, totalCPUTime float64, totalTime float64) {
+	m.send("app_usage_percent", strconv.FormatFloat(callCount, 'f', -1, 64), "g", "type:call_count")
+	m.send("app_usage_percent", strconv.FormatFloat(totalCPUTime, 'f', -1, 64), "g", "type:total_cputime")
+	m.send("app_usage_percent", strconv.FormatFloat(totalTime, 'f', -1, 64), "g", "type:total_time")
+}
+
+// ObserveConnection implements flannel.ConnectionMetrics.
+func (m *Metrics) ObserveConnection(trace flannel.ConnectionTrace) {
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"dns", trace.DNS},
+		{"connect", trace.Connect},
+		{"tls", trace.TLSHandshake},
+		{"first_byte", trace.TimeToFirstByte},
+	} {
+		if phase.d > 0 {
+			m.send("connection_phase", milliseconds(phase.d), "ms", "phase:"+phase.name)
+		}
+	}
+}
+
+// ObserveSecretMatch implements flannel.SecretMetrics.
+func (m *Metrics) ObserveSecretMatch(use string, index int) {
+	m.send("secret_matches", "1", "c", "use:"+use, "index:"+strconv.Itoa(index))
+}
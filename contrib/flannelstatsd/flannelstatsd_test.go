@@ -0,0 +1,37 @@
+package flannelstatsd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+func TestMetrics(t *testing.T) {
+	var b bytes.Buffer
+	m := NewWithWriter(&b, Settings{Prefix: "flannel.", Tags: []string{"env:prod"}})
+	var _ flannel.Metrics = m
+	m.ObserveRequest("GET", "/{id}", 200, 1500*time.Microsecond)
+	m.ObserveRateLimitWait("", 2*time.Millisecond)
+	m.ObserveAppUsage(12.5, 0, 0)
+
+	expected := []string{
+		"flannel.requests:1|c|#method:GET,endpoint:/{id},status:200,env:prod",
+		"flannel.request_duration:1.5|ms|#method:GET,endpoint:/{id},env:prod",
+		"flannel.rate_limit_wait:2|ms|#env:prod",
+		"flannel.app_usage_percent:12.5|g|#type:call_count,env:prod",
+		"flannel.app_usage_percent:0|g|#type:total_cputime,env:prod",
+		"flannel.app_usage_percent:0|g|#type:total_time,env:prod",
+	}
+	if lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n"); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected lines\n%s", b.String())
+	}
+
+	b.Reset()
+	NewWithWriter(&b, Settings{}).ObserveRetry("POST", "/me/fundraisers")
+	if b.String() != "retries:1|c|#method:POST,endpoint:/me/fundraisers\n" {
+		t.Errorf("unexpected line without prefix or tags %q", b.String())
+	}
+}
@@ -0,0 +1,57 @@
+package flannel
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+// An ImageDecoder decodes images of a format, it is used to convert cover photos to JPEG.
+type ImageDecoder interface {
+	Decode(r io.Reader) (image.Image, error)
+}
+
+// The ImageDecoderFunc type is an adapter to allow the use of ordinary functions, such as webp.Decode, as ImageDecoders.
+type ImageDecoderFunc func(r io.Reader) (image.Image, error)
+
+// Decode calls f(r).
+func (f ImageDecoderFunc) Decode(r io.Reader) (image.Image, error) {
+	return f(r)
+}
+
+// defaultImageDecoders are the decoders included by build tags, keyed by format.
+var defaultImageDecoders = map[string]ImageDecoder{}
+
+// ConvertCoverPhotoToJPEG returns a CoverPhotoTransform which converts cover photos to JPEG, with the quality (1 to 100),
+// if there is a decoder for their format in decoders, which is keyed by format ("heif", "webp" or "tiff").
+// If decoders is nil the default decoders are used, which are included by building with the flannel_heif
+// (requires cgo and libheif) and flannel_webp build tags. Photos in other formats are uploaded unchanged.
+func ConvertCoverPhotoToJPEG(decoders map[string]ImageDecoder, quality int) CoverPhotoTransform {
+	if decoders == nil {
+		decoders = defaultImageDecoders
+	}
+	return func(photo io.Reader) (io.Reader, error) {
+		b, err := readTransformInput(photo)
+		if err != nil {
+			return nil, err
+		}
+		info, ok := decodeImageHeader(b)
+		decoder, exists := decoders[info.format]
+		if !ok || !exists {
+			return bytes.NewReader(b), nil
+		}
+		if err := checkTransformPixels(info.width, info.height); err != nil {
+			return nil, err
+		}
+		src, err := decoder.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		var converted bytes.Buffer
+		if err := jpeg.Encode(&converted, flatten(src), &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		return &converted, nil
+	}
+}
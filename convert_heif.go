@@ -0,0 +1,39 @@
+//go:build flannel_heif && cgo
+
+package flannel
+
+import (
+	"image"
+	"io"
+	"io/ioutil"
+
+	"github.com/strukturag/libheif/go/heif"
+)
+
+func init() {
+	defaultImageDecoders["heif"] = ImageDecoderFunc(decodeHEIF)
+}
+
+// decodeHEIF decodes the primary image of a HEIF file using libheif.
+func decodeHEIF(r io.Reader) (image.Image, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := heif.NewContext()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.ReadFromMemory(b); err != nil {
+		return nil, err
+	}
+	handle, err := ctx.GetPrimaryImageHandle()
+	if err != nil {
+		return nil, err
+	}
+	img, err := handle.DecodeImage(heif.ColorspaceUndefined, heif.ChromaUndefined, nil)
+	if err != nil {
+		return nil, err
+	}
+	return img.GetImage()
+}
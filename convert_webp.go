@@ -0,0 +1,9 @@
+//go:build flannel_webp
+
+package flannel
+
+import "golang.org/x/image/webp"
+
+func init() {
+	defaultImageDecoders["webp"] = ImageDecoderFunc(webp.Decode)
+}
@@ -0,0 +1,84 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A RateProvider provides exchange rates, so that amounts in mixed currencies can be aggregated in a reporting
+// currency. Implementations must be safe for concurrent use.
+type RateProvider interface {
+
+	// Rate returns the value of one unit of the from currency in the to currency, using major units, for example
+	// dollars rather than cents.
+	Rate(ctx context.Context, from string, to string) (float64, error)
+}
+
+// RateProviderFunc adapts a func to a RateProvider.
+type RateProviderFunc func(ctx context.Context, from string, to string) (float64, error)
+
+// Rate calls f.
+func (f RateProviderFunc) Rate(ctx context.Context, from string, to string) (float64, error) {
+	return f(ctx, from, to)
+}
+
+// currencyExponents are the number of decimal places of the minor unit of currencies other than 2.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of the smallest unit of an ISO 4217 currency, in which
+// Facebook amounts are given, for example 2 for USD amounts in cents and 0 for zero-decimal currencies like JPY.
+func CurrencyExponent(currency string) int {
+	if exponent, exists := currencyExponents[strings.ToUpper(currency)]; exists {
+		return exponent
+	}
+	return 2
+}
+
+// ConvertAmount converts an amount in the smallest unit of the from currency to the smallest unit of the to
+// currency, rounding to the nearest unit.
+func ConvertAmount(ctx context.Context, rates RateProvider, amount int64, from string, to string) (int64, error) {
+	rate := 1.0
+	if !strings.EqualFold(from, to) {
+		var err error
+		if rate, err = rates.Rate(ctx, strings.ToUpper(from), strings.ToUpper(to)); err != nil {
+			return 0, fmt.Errorf("error getting %s to %s rate %v", from, to, err)
+		}
+	}
+	major := float64(amount) / math.Pow10(CurrencyExponent(from))
+	return int64(math.Round(major * rate * math.Pow10(CurrencyExponent(to)))), nil
+}
+
+// converter converts amounts to a reporting currency, getting the rate of each currency once.
+type converter struct {
+	rates RateProvider
+	to    string
+	memo  map[string]float64
+}
+
+func newConverter(rates RateProvider, to string) *converter {
+	if rates == nil || to == "" {
+		return nil
+	}
+	return &converter{rates: rates, to: to, memo: make(map[string]float64)}
+}
+
+func (c *converter) Rate(ctx context.Context, from string, to string) (float64, error) {
+	if rate, exists := c.memo[from]; exists {
+		return rate, nil
+	}
+	rate, err := c.rates.Rate(ctx, from, to)
+	if err == nil {
+		c.memo[from] = rate
+	}
+	return rate, err
+}
+
+func (c *converter) convert(ctx context.Context, amount int64, from string) (int64, error) {
+	return ConvertAmount(ctx, c, amount, from, c.to)
+}
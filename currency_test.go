@@ -0,0 +1,36 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConvertAmount(t *testing.T) {
+	rates := RateProviderFunc(func(ctx context.Context, from string, to string) (float64, error) {
+		switch from + to {
+		case "JPYUSD":
+			return 0.0067, nil
+		case "USDKWD":
+			return 0.307, nil
+		}
+		return 0, errors.New("unknown rate")
+	})
+	for _, test := range []struct {
+		amount   int64
+		from, to string
+		expected int64
+	}{
+		{amount: 10000, from: "jpy", to: "USD", expected: 6700},
+		{amount: 1050, from: "USD", to: "KWD", expected: 3224},
+		{amount: 1050, from: "USD", to: "usd", expected: 1050},
+	} {
+		converted, err := ConvertAmount(context.Background(), rates, test.amount, test.from, test.to)
+		if err != nil || converted != test.expected {
+			t.Errorf("expected %d %s to convert to %d %s, got %d %v", test.amount, test.from, test.expected, test.to, converted, err)
+		}
+	}
+	if _, err := ConvertAmount(context.Background(), rates, 100, "GBP", "USD"); err == nil {
+		t.Errorf("expected unknown rate to fail")
+	}
+}
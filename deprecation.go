@@ -0,0 +1,159 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A Deprecation describes a signal from Facebook that the Graph API version, or a feature used by an API call,
+// is deprecated or will be removed.
+type Deprecation struct {
+	Method   string
+	Endpoint string
+
+	// RequestedVersion is the Graph API version requested and ServedVersion the version which handled the request,
+	// as reported in the facebook-api-version header. Facebook serves requests to versions which are no longer
+	// supported with the oldest supported version.
+	RequestedVersion string
+	ServedVersion    string
+
+	// Sunset is when the deprecated feature will be removed, if reported in a Sunset header.
+	Sunset time.Time
+
+	// Message describes the deprecation.
+	Message string
+}
+
+// WithDeprecationHandler detects deprecation signals from Facebook, calling onDeprecation for each API call
+// which returns one. Signals include Facebook serving a different version than requested, Deprecation, Sunset and
+// Warning headers and, if WithGraphDebug is enabled, debug messages about deprecated features. If strict is set
+// then API calls returning deprecation signals fail with an error, which can be detected with IsDeprecated,
+// although the API call itself succeeded.
+func WithDeprecationHandler(onDeprecation func(ctx context.Context, d Deprecation), strict bool) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.deprecation = &deprecationHandler{onDeprecation: onDeprecation, strict: strict}
+		return nil
+	}
+}
+
+// WithStrictDeprecation fails API calls returning deprecation signals with an error, which can be detected with
+// IsDeprecated, whether or not a deprecation handler is set. It is intended for CI and staging, so that deprecations
+// are noticed before they reach production, where deprecations can be logged instead. Strict mode can also be
+// changed at runtime with a LiveConfig.
+func WithStrictDeprecation() func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.strictDeprecation = true
+		return nil
+	}
+}
+
+type deprecationHandler struct {
+	onDeprecation func(ctx context.Context, d Deprecation)
+	strict        bool
+}
+
+type deprecationError struct {
+	Deprecation
+}
+
+func (e deprecationError) Error() string {
+	if e.Method != http.MethodGet && e.Method != http.MethodHead {
+		return fmt.Sprintf("%s request to %s succeeded but is deprecated %s", e.Method, e.Endpoint, e.Message)
+	}
+	return fmt.Sprintf("%s request to %s is deprecated %s", e.Method, e.Endpoint, e.Message)
+}
+
+// IsDeprecated returns true if err was returned because an API call returned a deprecation signal in strict mode.
+// The API call itself succeeded, and its status and result are returned along with err. In particular writes, such
+// as creating or updating a fundraiser, have been made and must not be retried.
+func IsDeprecated(err error) bool {
+	var e deprecationError
+	return errors.As(err, &e)
+}
+
+// checkDeprecation reports any deprecation signals in the response, returning an error in strict mode.
+func (c APIClient) checkDeprecation(req *http.Request, res *http.Response, result map[string]interface{}) error {
+	strict := c.isStrictDeprecation()
+	if c.deprecation == nil && !strict {
+		return nil
+	}
+	d, deprecated := detectDeprecation(req, c.graphVersion(req), res, result)
+	if !deprecated {
+		return nil
+	}
+	if c.deprecation != nil && c.deprecation.onDeprecation != nil {
+		c.deprecation.onDeprecation(req.Context(), d)
+	} else if c.logger != nil {
+		c.logf(req.Context(), "facebook api %s request to %s is deprecated %s\n", req.Method, redactString(req.URL.String()), d.Message)
+	}
+	if strict {
+		return deprecationError{d}
+	}
+	return nil
+}
+
+// isStrictDeprecation returns whether API calls returning deprecation signals fail.
+func (c APIClient) isStrictDeprecation() bool {
+	if c.live != nil {
+		c.live.mu.RLock()
+		defer c.live.mu.RUnlock()
+		if c.live.strictDeprecation != nil {
+			return *c.live.strictDeprecation
+		}
+	}
+	return c.strictDeprecation || (c.deprecation != nil && c.deprecation.strict)
+}
+
+// detectDeprecation returns the deprecation signals in the response to req, which requested version.
+func detectDeprecation(req *http.Request, version string, res *http.Response, result map[string]interface{}) (d Deprecation, deprecated bool) {
+	d = Deprecation{
+		Method:           req.Method,
+		Endpoint:         statsEndpoint(req),
+		RequestedVersion: version,
+		ServedVersion:    res.Header.Get("facebook-api-version"),
+	}
+	var messages []string
+	if d.ServedVersion != "" && d.RequestedVersion != "" && d.ServedVersion != d.RequestedVersion {
+		messages = append(messages, fmt.Sprintf("version %s was requested but served by %s", d.RequestedVersion, d.ServedVersion))
+	}
+	if h := res.Header.Get("Deprecation"); h != "" && h != "false" {
+		messages = append(messages, "deprecation header "+h)
+	}
+	for _, h := range res.Header.Values("Warning") {
+		// such as 299 - "Deprecated API"
+		if strings.Contains(strings.ToLower(h), "deprecat") {
+			messages = append(messages, "warning header "+h)
+		}
+	}
+	if h := res.Header.Get("Sunset"); h != "" {
+		if sunset, err := http.ParseTime(h); err == nil {
+			d.Sunset = sunset
+			messages = append(messages, "sunset "+sunset.Format(time.RFC3339))
+		}
+	}
+	if debug, ok := result["__debug__"].(map[string]interface{}); ok {
+		list, _ := debug["messages"].([]interface{})
+		for _, m := range list {
+			if m, ok := m.(map[string]interface{}); ok {
+				if message, _ := m["message"].(string); strings.Contains(strings.ToLower(message), "deprecat") {
+					messages = append(messages, message)
+				}
+			}
+		}
+	}
+	d.Message = strings.Join(messages, ", ")
+	return d, len(messages) > 0
+}
+
+// requestedVersion returns the Graph API version in the path of req, if any.
+func requestedVersion(req *http.Request) string {
+	segments := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	if strings.HasPrefix(segments[0], "v") && strings.Contains(segments[0], ".") {
+		return segments[0]
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDeprecationHandler(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("facebook-api-version", "v3.0")
+		w.Header().Set("Sunset", "Tue, 01 Jun 2027 00:00:00 GMT")
+		w.Write([]byte(`{"id":"1234"}`))
+	})
+
+	var deprecations []Deprecation
+	onDeprecation := func(ctx context.Context, d Deprecation) {
+		deprecations = append(deprecations, d)
+	}
+	c := newTestClient(t, handler, WithDeprecationHandler(onDeprecation, false))
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if len(deprecations) != 1 {
+		t.Fatalf("expected 1 deprecation, got %d", len(deprecations))
+	}
+	d := deprecations[0]
+	if d.RequestedVersion != "v2.8" || d.ServedVersion != "v3.0" || d.Sunset.Year() != 2027 || d.Message == "" {
+		t.Errorf("unexpected deprecation %+v", d)
+	}
+
+	c = newTestClient(t, handler, WithDeprecationHandler(nil, true))
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); !IsDeprecated(err) {
+		t.Errorf("expected deprecated error in strict mode, got %v", err)
+	}
+	it := c.ListFundraisers(context.Background(), "token")
+	defer it.Close()
+	if it.Next() || !IsDeprecated(it.Err()) {
+		t.Errorf("expected deprecated error listing in strict mode, got %v", it.Err())
+	}
+}
+
+func TestStrictDeprecation(t *testing.T) {
+	warn := true
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if warn {
+			w.Header().Add("Warning", `299 - "Deprecated field amount_raised"`)
+		}
+		w.Write([]byte(`{"id":"1234"}`))
+	}), WithStrictDeprecation())
+	ctx := context.Background()
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); !IsDeprecated(err) {
+		t.Errorf("expected deprecated error in strict mode, got %v", err)
+	}
+	warn = false
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil {
+		t.Errorf("unexpected error without deprecation signals %v", err)
+	}
+
+	// strict mode is turned off in production, where deprecations are only reported
+	warn = true
+	live := NewLiveConfig()
+	var deprecations int
+	c = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `299 - "Deprecated field amount_raised"`)
+		w.Header().Set("facebook-api-version", "v3.0")
+		w.Write([]byte(`{"id":"1234"}`))
+	}), WithDeprecationHandler(func(ctx context.Context, d Deprecation) { deprecations++ }, true), WithLiveConfig(live))
+	live.SetStrictDeprecation(false)
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil || deprecations != 1 {
+		t.Errorf("expected deprecation to be reported without error, got %d %v", deprecations, err)
+	}
+
+	// requesting the version set on a LiveConfig is not a deprecation
+	live.SetGraphVersion("v3.0")
+	var d Deprecation
+	c = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("facebook-api-version", "v3.0")
+		w.Write([]byte(`{"id":"1234"}`))
+	}), WithDeprecationHandler(func(ctx context.Context, got Deprecation) { d = got }, false), WithLiveConfig(live))
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil || d.Message != "" {
+		t.Errorf("unexpected deprecation %+v %v", d, err)
+	}
+}
+
+func TestStrictDeprecationWrite(t *testing.T) {
+	var created int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created++
+		w.Header().Set("Deprecation", "true")
+		fundraiserCreated(w, r)
+	}), WithStrictDeprecation(), WithIdempotentCreation(NewMemoryCache(10), false))
+	params := CreateFundraiserParams{ExternalID: "abc"}
+	status, result, err := c.CreateFundraiser(params)
+	if !IsDeprecated(err) || !strings.Contains(err.Error(), "succeeded") {
+		t.Errorf("expected deprecated error in strict mode, got %v", err)
+	}
+	if status != http.StatusOK || result["id"] == nil {
+		t.Errorf("expected result of the write to be returned with the error, got %d %v", status, result)
+	}
+	// the fundraiser was created, so it is not created again
+	if _, result, err := c.CreateFundraiser(params); err != nil || result["id"] == nil || created != 1 {
+		t.Errorf("expected deprecated creation to be recorded, got %d creations %v %v", created, result, err)
+	}
+}
@@ -0,0 +1,202 @@
+package flannel
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DownloadFailure classifies why a cover photo could not be downloaded.
+type DownloadFailure int
+
+// Download failures.
+const (
+	DownloadFailureUnknown DownloadFailure = iota
+	DownloadFailureDNS
+	DownloadFailureConnect
+	DownloadFailureTLS
+	DownloadFailureTimeout
+	DownloadFailureStatus
+)
+
+func (f DownloadFailure) String() string {
+	switch f {
+	case DownloadFailureDNS:
+		return "dns"
+	case DownloadFailureConnect:
+		return "connect"
+	case DownloadFailureTLS:
+		return "tls"
+	case DownloadFailureTimeout:
+		return "timeout"
+	case DownloadFailureStatus:
+		return "status"
+	}
+	return "unknown"
+}
+
+type downloadError struct {
+	Failure DownloadFailure
+	Status  int
+	Err     error
+}
+
+func (e downloadError) Error() string {
+	if e.Failure == DownloadFailureStatus {
+		return fmt.Sprintf("error downloading cover photo invalid response %d", e.Status)
+	}
+	return fmt.Sprintf("error downloading cover photo %s %s", e.Failure, redactString(e.Err.Error()))
+}
+
+func (e downloadError) Unwrap() error {
+	return e.Err
+}
+
+// CoverPhotoDownloadFailure returns why a cover photo added with WithFundraiserCoverPhotoURL could not be downloaded,
+// and the HTTP status code if the failure is DownloadFailureStatus. The failure is DownloadFailureUnknown if err was not
+// returned because of a failed download.
+func CoverPhotoDownloadFailure(err error) (failure DownloadFailure, status int) {
+	var e downloadError
+	if errors.As(err, &e) {
+		return e.Failure, e.Status
+	}
+	return DownloadFailureUnknown, 0
+}
+
+// WithCoverPhotoDownloadTimeout sets the timeout of each attempt at downloading a cover photo added with
+// WithFundraiserCoverPhotoURL, including reading the photo. Otherwise the timeout of the APIClient's http.Client applies.
+func WithCoverPhotoDownloadTimeout(timeout time.Duration) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.coverPhotoDownloadTimeout = timeout
+		return nil
+	}
+}
+
+// fetch downloads the file at u, returning its content and size or -1 if unknown.
+// Failed downloads are retried using the retry policy of the APIClient.
+func (f *fundraiserForm) fetch(u url.URL) (body io.ReadCloser, size int64, err error) {
+	ctx := f.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpClient := f.client.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Second * 20}
+	}
+	for attempt := 1; ; attempt++ {
+		actx, cancel := ctx, context.CancelFunc(func() {})
+		if f.client.coverPhotoDownloadTimeout > 0 {
+			actx, cancel = context.WithTimeout(ctx, f.client.coverPhotoDownloadTimeout)
+		}
+		req, err := http.NewRequestWithContext(actx, "GET", u.String(), nil)
+		if err != nil {
+			cancel()
+			return nil, 0, err
+		}
+		res, err := f.client.sendHTTP(httpClient, req)
+		if f.client.retryPolicy.retryable(ctx, attempt, res, err) {
+			if err != nil {
+				f.client.logRequest("cover photo", req, 0, nil, err)
+			} else {
+				f.client.logRequest("cover photo", req, res.StatusCode, nil, fmt.Errorf("invalid response %d", res.StatusCode))
+				drainAndClose(res.Body)
+			}
+			cancel()
+			if waitErr := f.client.retryPolicy.wait(ctx, f.client.clock, attempt); waitErr != nil {
+				return nil, 0, waitErr
+			}
+			continue
+		}
+		if err != nil {
+			err = downloadError{Failure: classifyDownloadError(err), Err: err}
+			f.client.logRequest("cover photo", req, 0, nil, err)
+			cancel()
+			return nil, 0, err
+		}
+		if res.StatusCode != http.StatusOK {
+			drainAndClose(res.Body)
+			err = downloadError{Failure: DownloadFailureStatus, Status: res.StatusCode}
+		} else if contentType := res.Header.Get("Content-Type"); !isRasterContentType(contentType) {
+			drainAndClose(res.Body)
+			err = fmt.Errorf("%w content type %s", errPhotoFormatUnsupported, contentType)
+		} else if res.ContentLength > f.client.coverPhotoMaxSize() && len(f.coverPhotoTransforms) == 0 {
+			// fail before downloading a photo which is too large
+			res.Body.Close()
+			err = coverPhotoError(coverPhotoFormatSubcode, coverPhotoFormatMessage)
+		}
+		f.client.logRequest("cover photo", req, res.StatusCode, nil, err)
+		if err != nil {
+			cancel()
+			return nil, 0, err
+		}
+		return &cancelOnClose{ReadCloser: res.Body, cancel: cancel}, res.ContentLength, nil
+	}
+}
+
+var errPhotoFormatUnsupported = errors.New("cover photo format unsupported")
+
+// IsErrorPhotoFormatUnsupported returns true if err was returned because a cover photo is not an image format accepted
+// by Facebook, such as an HTML error page or SVG image returned for a cover photo URL. This includes the error Facebook
+// returns for unsupported formats, which Facebook also returns for photos larger than 4 MB.
+func IsErrorPhotoFormatUnsupported(err error) bool {
+	if errors.Is(err, errPhotoFormatUnsupported) {
+		return true
+	}
+	code, subcode := ErrorCodes(err)
+	return code == 100 && subcode == coverPhotoFormatSubcode
+}
+
+// isRasterContentType returns true unless contentType is known not to be a raster image accepted by Facebook.
+// Content types which do not identify the format, such as application/octet-stream, are accepted and the
+// format is checked from the content of the photo.
+func isRasterContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/octet-stream", "binary/octet-stream":
+		return true
+	case "image/svg+xml":
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/")
+}
+
+// classifyDownloadError returns the stage at which a download failed without a response.
+func classifyDownloadError(err error) DownloadFailure {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &dnsErr):
+		return DownloadFailureDNS
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return DownloadFailureTimeout
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthorityErr), errors.As(err, &hostnameErr), errors.As(err, &recordErr):
+		return DownloadFailureTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return DownloadFailureConnect
+	}
+	return DownloadFailureUnknown
+}
+
+func isTimeout(err error) bool {
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
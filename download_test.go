@@ -0,0 +1,94 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCoverPhotoDownloadRetried(t *testing.T) {
+	photo := testPhoto(1024)
+	downloads := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.png":
+			downloads++
+			if downloads == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write(photo)
+		case "/missing.png":
+			w.WriteHeader(http.StatusNotFound)
+		case "/slow.png":
+			time.Sleep(200 * time.Millisecond)
+			w.Write(photo)
+		default:
+			fundraiserCreated(w, r)
+		}
+	}), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}), WithCoverPhotoDownloadTimeout(50*time.Millisecond))
+
+	u, _ := url.Parse("https://photos.example.com/photo.png")
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("photo.png", *u)); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if downloads != 2 {
+		t.Errorf("expected failed download to be retried, got %d downloads", downloads)
+	}
+
+	u, _ = url.Parse("https://photos.example.com/missing.png")
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("missing.png", *u))
+	if failure, status := CoverPhotoDownloadFailure(err); failure != DownloadFailureStatus || status != http.StatusNotFound {
+		t.Errorf("expected status failure 404, got %v %d %v", failure, status, err)
+	}
+	if !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error, got %v", err)
+	}
+
+	u, _ = url.Parse("https://photos.example.com/slow.png")
+	_, _, err = c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("slow.png", *u))
+	if failure, _ := CoverPhotoDownloadFailure(err); failure != DownloadFailureTimeout {
+		t.Errorf("expected timeout failure, got %v %v", failure, err)
+	}
+}
+
+func TestClassifyDownloadError(t *testing.T) {
+	for expected, err := range map[DownloadFailure]error{
+		DownloadFailureDNS:     &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid"}},
+		DownloadFailureConnect: &net.OpError{Op: "dial", Err: bytes.ErrTooLarge},
+		DownloadFailureTimeout: context.DeadlineExceeded,
+	} {
+		if actual := classifyDownloadError(&url.Error{Op: "Get", URL: "https://example.invalid", Err: err}); actual != expected {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestCoverPhotoURLRejectsNonRaster(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error.png":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html>Sorry</html>"))
+		case "/vector.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte("<svg></svg>"))
+		case "/mislabelled.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("<html>Sorry</html>"))
+		default:
+			t.Error("expected non-raster cover photo not to be uploaded")
+		}
+	}))
+	for _, name := range []string{"error.png", "vector.svg", "mislabelled.png"} {
+		u, _ := url.Parse("https://photos.example.com/" + name)
+		_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL(name, *u))
+		if !IsErrorPhotoFormatUnsupported(err) || !IsErrorWithFundraiserCoverPhoto(err) {
+			t.Errorf("expected %s to be an unsupported format, got %v", name, err)
+		}
+	}
+}
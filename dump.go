@@ -0,0 +1,55 @@
+package flannel
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+)
+
+// WithHTTPDump writes every HTTP request and response made by the APIClient to w, as formatted by
+// httputil.DumpRequestOut and httputil.DumpResponse, with credentials redacted. This is intended for
+// debugging, such as when raising bugs with Facebook platform support, as response bodies are buffered.
+// Multipart request bodies, which include cover photos, are not dumped.
+func WithHTTPDump(w io.Writer) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.httpDump = &httpDump{w: w}
+		return nil
+	}
+}
+
+type httpDump struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// dumpRequest writes req to the dump, if enabled.
+func (c APIClient) dumpRequest(req *http.Request) {
+	if c.httpDump == nil {
+		return
+	}
+	body := !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+	b, err := httputil.DumpRequestOut(req, body)
+	c.httpDump.write(">", b, err)
+}
+
+// dumpResponse writes res to the dump, if enabled.
+func (c APIClient) dumpResponse(res *http.Response) {
+	if c.httpDump == nil {
+		return
+	}
+	b, err := httputil.DumpResponse(res, true)
+	c.httpDump.write("<", b, err)
+}
+
+func (d *httpDump) write(prefix string, b []byte, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(d.w, "%s error dumping %v\n\n", prefix, err)
+		return
+	}
+	fmt.Fprintf(d.w, "%s\n%s\n\n", prefix, strings.TrimRight(redactString(string(b)), "\r\n"))
+}
@@ -0,0 +1,33 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTPDump(t *testing.T) {
+	var dump bytes.Buffer
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"app_id":"1","access_token":"response-secret"}}`))
+	}), WithHTTPDump(&dump))
+
+	_, result, err := c.DebugToken(context.Background(), "app-secret", "input-secret")
+	if err != nil {
+		t.Fatalf("failed to debug token %v", err)
+	}
+	if result["data"] == nil {
+		t.Errorf("expected response to be readable after dumping, got %v", result)
+	}
+	s := dump.String()
+	if !strings.Contains(s, "GET /v2.8/debug_token") || !strings.Contains(s, "200 OK") {
+		t.Errorf("expected request and response to be dumped, got %s", s)
+	}
+	for _, secret := range []string{"app-secret", "input-secret", "response-secret"} {
+		if strings.Contains(s, secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, s)
+		}
+	}
+}
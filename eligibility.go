@@ -0,0 +1,81 @@
+package flannel
+
+import (
+	"context"
+	"strings"
+)
+
+// IneligibilityReason is why a charity cannot receive Facebook Fundraisers.
+type IneligibilityReason string
+
+// Reasons charities are ineligible.
+const (
+	// CharityNotFound is returned for IDs which are not Facebook Pages.
+	CharityNotFound IneligibilityReason = "not_found"
+
+	// CharityDonateButtonUnsupported is returned for Pages which do not support the donate button, as reported by
+	// the supports_donate_button_in_live_video field, which Pages only do once set up to receive donations.
+	CharityDonateButtonUnsupported IneligibilityReason = "donate_button_unsupported"
+
+	// CharityWrongCountry is returned for Pages located outside the countries fundraisers are created for.
+	CharityWrongCountry IneligibilityReason = "wrong_country"
+)
+
+// CharityEligibility is whether a charity can receive Facebook Fundraisers.
+type CharityEligibility struct {
+	CharityID string
+	Name      string
+	Country   string
+
+	// Eligible is set if the charity can receive fundraisers, otherwise Reason is why not.
+	Eligible bool
+	Reason   IneligibilityReason
+}
+
+// charityEligibilityFields are the fields of the charity's Page read to check its eligibility.
+var charityEligibilityFields = []string{"id", "name", "location", "supports_donate_button_in_live_video"}
+
+// CheckCharityEligibility checks that the Page of the charity with charityID supports the donate button, so that
+// fundraisers are not created for charities which cannot receive donations. The Graph API does not expose whether
+// a charity is onboarded for fundraisers, so an eligible charity may still be rejected when a fundraiser is created.
+// If any countries are given, such as "United Kingdom", the charity must also be located in one of them. Charities
+// which are not found are ineligible, other failed API calls return an error. Results are cached if configured
+// with WithMetadataCache.
+func (c APIClient) CheckCharityEligibility(ctx context.Context, accessToken string, charityID string, countries ...string) (CharityEligibility, error) {
+	e := CharityEligibility{CharityID: charityID}
+	_, result, err := c.GetCharity(ctx, accessToken, charityID, charityEligibilityFields...)
+	if err != nil {
+		if code, subcode := ErrorCodes(err); code == 100 && subcode == 33 {
+			e.Reason = CharityNotFound
+			return e, nil
+		}
+		return e, err
+	}
+	var page struct {
+		Name     string `json:"name"`
+		Location struct {
+			Country string `json:"country"`
+		} `json:"location"`
+		DonateButton bool `json:"supports_donate_button_in_live_video"`
+	}
+	if err := decodeResult(result, &page); err != nil {
+		return e, err
+	}
+	e.Name, e.Country = page.Name, page.Location.Country
+	if !page.DonateButton {
+		e.Reason = CharityDonateButtonUnsupported
+		return e, nil
+	}
+	if len(countries) > 0 {
+		inCountry := false
+		for _, country := range countries {
+			inCountry = inCountry || strings.EqualFold(country, e.Country)
+		}
+		if !inCountry {
+			e.Reason = CharityWrongCountry
+			return e, nil
+		}
+	}
+	e.Eligible = true
+	return e, nil
+}
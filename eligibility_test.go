@@ -0,0 +1,41 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckCharityEligibility(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/v2.8/") {
+		case "1":
+			fmt.Fprint(w, `{"id":"1","name":"Cats","location":{"country":"United Kingdom"},"supports_donate_button_in_live_video":true}`)
+		case "2":
+			fmt.Fprint(w, `{"id":"2","name":"Dogs","location":{"country":"France"},"supports_donate_button_in_live_video":true}`)
+		case "3":
+			fmt.Fprint(w, `{"id":"3","name":"Birds","location":{"country":"United Kingdom"}}`)
+		case "4":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Unsupported get request","code":100,"error_subcode":33}}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"message":"An unknown error occurred","code":1}}`)
+		}
+	}))
+	ctx := context.Background()
+	for id, expected := range map[string]IneligibilityReason{"1": "", "2": CharityWrongCountry, "3": CharityDonateButtonUnsupported, "4": CharityNotFound} {
+		e, err := c.CheckCharityEligibility(ctx, "token", id, "united kingdom")
+		if err != nil {
+			t.Fatalf("failed to check charity %s %v", id, err)
+		}
+		if e.Reason != expected || e.Eligible != (expected == "") {
+			t.Errorf("unexpected eligibility of charity %s %+v", id, e)
+		}
+	}
+	if _, err := c.CheckCharityEligibility(ctx, "token", "5"); err == nil {
+		t.Error("expected failed api call to return an error")
+	}
+}
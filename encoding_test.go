@@ -0,0 +1,75 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestEncoding(t *testing.T) {
+	var contentTypes []string
+	var names []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := strings.SplitN(r.Header.Get("Content-Type"), ";", 2)[0]
+		contentTypes = append(contentTypes, contentType)
+		if contentType == "application/json" {
+			var body map[string]string
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &body)
+			names = append(names, body["name"])
+		} else {
+			names = append(names, r.FormValue("name"))
+		}
+		fundraiserCreated(w, r)
+	}), WithRequestEncoding(RequestEncodingJSON))
+
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Plain"}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Photo"}, WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(testPhoto(1024)))); err != nil {
+		t.Fatalf("failed to create fundraiser with cover photo %v", err)
+	}
+	if _, _, err := c.UpdateFundraiser(context.Background(), "token", "1", map[string]string{"name": "Updated"}); err != nil {
+		t.Fatalf("failed to update fundraiser %v", err)
+	}
+	if strings.Join(contentTypes, " ") != "application/json multipart/form-data application/json" {
+		t.Errorf("unexpected content types %v", contentTypes)
+	}
+	if strings.Join(names, " ") != "Plain Photo Updated" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	if _, err := CreateAPIClient(WithRequestEncoding(RequestEncoding(9))); err == nil {
+		t.Error("expected unknown encoding to fail")
+	}
+}
+
+func TestMultipartBoundary(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		fundraiserCreated(w, r)
+	}), WithMultipartBoundary("flannel-test"))
+
+	params := CreateFundraiserParams{CharityID: "1", Title: "Marathon", Goal: 100, Currency: "GBP", ExternalID: "abc"}
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.CreateFundraiser(params, WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(testPhoto(1024)))); err != nil {
+			t.Fatalf("failed to create fundraiser %v", err)
+		}
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Fatalf("expected identical request bodies")
+	}
+	if !strings.HasPrefix(bodies[0], "--flannel-test\r\nContent-Disposition: form-data; name=\"charity_id\"\r\n\r\n1\r\n--flannel-test\r\n") {
+		t.Errorf("unexpected request body %q", bodies[0][:80])
+	}
+
+	if _, err := CreateAPIClient(WithMultipartBoundary("invalid boundary\n")); err == nil {
+		t.Error("expected invalid boundary to fail")
+	}
+}
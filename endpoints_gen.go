@@ -0,0 +1,135 @@
+// Code generated by flannelgen from endpoints.json. DO NOT EDIT.
+
+package flannel
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// FundraiserDetails are the details of a Facebook Fundraiser.
+type FundraiserDetails struct {
+
+	// ID is the ID of the fundraiser.
+	ID string `json:"id"`
+
+	// Name is the title of the fundraiser.
+	Name string `json:"name"`
+
+	// Description is the description of the fundraiser.
+	Description string `json:"description"`
+
+	// GoalAmount is the goal in the currency's smallest unit.
+	GoalAmount int64 `json:"goal_amount"`
+
+	// AmountRaised is the amount raised in the currency's smallest unit.
+	AmountRaised int64 `json:"amount_raised"`
+
+	// Currency is the ISO 4217 code of the currency.
+	Currency string `json:"currency"`
+
+	// EndTime is when the fundraiser stops accepting donations.
+	EndTime string `json:"end_time"`
+
+	// ExternalID identifies the fundraiser in your system.
+	ExternalID string `json:"external_id"`
+
+	// URI is the URI of the fundraiser on Facebook.
+	URI string `json:"uri"`
+}
+
+// CharityDetails are the details of a Facebook Charity.
+type CharityDetails struct {
+
+	// ID is the ID of the charity.
+	ID string `json:"id"`
+
+	// Name is the name of the charity.
+	Name string `json:"name"`
+
+	// Link is the URL of the charity's Facebook Page.
+	Link string `json:"link"`
+}
+
+// SuccessResult is the result of updating a Facebook object.
+type SuccessResult struct {
+
+	// Success is set if the update succeeded.
+	Success bool `json:"success"`
+}
+
+// GetFundraiserDetailsParams are the parameters of GetFundraiserDetails.
+type GetFundraiserDetailsParams struct {
+
+	// FundraiserID is the ID of the fundraiser. It is required.
+	FundraiserID string
+}
+
+// GetFundraiserDetails reads the details of a Facebook Fundraiser.
+// It requires the manage_fundraisers scope.
+func (c APIClient) GetFundraiserDetails(ctx context.Context, accessToken string, params GetFundraiserDetailsParams) (result FundraiserDetails, err error) {
+	if params.FundraiserID == "" {
+		return result, errors.New("fundraiser_id is required")
+	}
+	values := url.Values{}
+	values.Set("fields", "id,name,description,goal_amount,amount_raised,currency,end_time,external_id,uri")
+	_, m, err := c.get(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(params.FundraiserID), values)
+	if err != nil {
+		return result, err
+	}
+	return result, c.decode("GetFundraiserDetails", m, &result)
+}
+
+// GetCharityDetailsParams are the parameters of GetCharityDetails.
+type GetCharityDetailsParams struct {
+
+	// CharityID is the ID of the charity. It is required.
+	CharityID string
+}
+
+// GetCharityDetails reads the details of a Facebook Charity.
+func (c APIClient) GetCharityDetails(ctx context.Context, accessToken string, params GetCharityDetailsParams) (result CharityDetails, err error) {
+	if params.CharityID == "" {
+		return result, errors.New("charity_id is required")
+	}
+	values := url.Values{}
+	values.Set("fields", "id,name,link")
+	_, m, err := c.get(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(params.CharityID), values)
+	if err != nil {
+		return result, err
+	}
+	return result, c.decode("GetCharityDetails", m, &result)
+}
+
+// SetFundraiserGoalParams are the parameters of SetFundraiserGoal.
+type SetFundraiserGoalParams struct {
+
+	// FundraiserID is the ID of the fundraiser. It is required.
+	FundraiserID string
+
+	// GoalAmount is the new goal in the currency's smallest unit. It is required.
+	GoalAmount int
+}
+
+// SetFundraiserGoal changes the goal of a Facebook Fundraiser.
+// It requires the manage_fundraisers scope.
+func (c APIClient) SetFundraiserGoal(ctx context.Context, accessToken string, params SetFundraiserGoalParams) (result SuccessResult, err error) {
+	if params.FundraiserID == "" {
+		return result, errors.New("fundraiser_id is required")
+	}
+	values := url.Values{}
+	values.Set("goal_amount", strconv.Itoa(params.GoalAmount))
+	_, m, err := c.post(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(params.FundraiserID), values)
+	if err != nil {
+		return result, err
+	}
+	return result, c.decode("SetFundraiserGoal", m, &result)
+}
+
+// generatedScopes are the permission scopes required by each generated endpoint binding.
+var generatedScopes = map[string][]string{
+	"GetFundraiserDetails": {"manage_fundraisers"},
+	"SetFundraiserGoal":    {"manage_fundraisers"},
+}
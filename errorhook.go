@@ -0,0 +1,48 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+)
+
+// CallInfo describes a failed API call passed to an error hook.
+type CallInfo struct {
+	Method string
+
+	// Endpoint is as reported by Stats, without the Graph API version and with ids replaced by {id}.
+	Endpoint string
+
+	// Tenant is set if the API call was made with ContextWithTenant.
+	Tenant string
+
+	// RequestID is set if the API call was made with ContextWithRequestID.
+	RequestID string
+
+	// Status is the HTTP status code of the response, or zero if no response was received.
+	Status int
+
+	// Code and Subcode are the codes of the Facebook error returned, if any.
+	Code    int
+	Subcode int
+}
+
+// WithErrorHook calls hook with the details of every API call which fails, whether no response was received or
+// Facebook returned an error, for example to raise alerts. The hook is called synchronously, so should not block.
+func WithErrorHook(hook func(ctx context.Context, call CallInfo, err error)) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.errorHook = hook
+		return nil
+	}
+}
+
+// callFailed calls the error hook, if any, with the details of req which failed with err.
+func (c APIClient) callFailed(ctx context.Context, req *http.Request, status int, err error) {
+	if c.errorHook == nil || err == nil {
+		return
+	}
+	call := CallInfo{Method: req.Method, Endpoint: statsEndpoint(req), Status: status}
+	call.Tenant, _ = TenantFromContext(ctx)
+	call.RequestID, _ = RequestIDFromContext(ctx)
+	call.Code, call.Subcode = ErrorCodes(err)
+	c.errorHook(ctx, call, err)
+}
@@ -0,0 +1,38 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestErrorHook(t *testing.T) {
+	var calls []CallInfo
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/2"):
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		case strings.HasSuffix(r.URL.Path, "/3"):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Unsupported get request","code":100,"error_subcode":33}}`)
+		default:
+			fmt.Fprint(w, `{"id":"1"}`)
+		}
+	}), WithErrorHook(func(ctx context.Context, call CallInfo, err error) { calls = append(calls, call) }))
+	ctx := ContextWithTenant(context.Background(), "acme")
+	for _, id := range []string{"1", "2", "3"} {
+		c.GetFundraiser(ctx, "token", id)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected hook to be called for each failed call, got %+v", calls)
+	}
+	if call := calls[0]; call.Status != 0 || call.Endpoint != "/{id}" || call.Tenant != "acme" {
+		t.Errorf("unexpected transport failure %+v", call)
+	}
+	if call := calls[1]; call.Status != http.StatusBadRequest || call.Code != 100 || call.Subcode != 33 || call.Method != http.MethodGet {
+		t.Errorf("unexpected facebook error %+v", call)
+	}
+}
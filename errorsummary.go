@@ -0,0 +1,154 @@
+package flannel
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorSummarySlots is the number of slots the window of an ErrorSummary is divided into.
+const errorSummarySlots = 60
+
+// An ErrorSummary counts the Facebook errors returned by API calls over a sliding window, by endpoint, code and
+// subcode, so that the most common failures can be seen at a glance during an incident.
+//
+//	summary := flannel.NewErrorSummary(15 * time.Minute)
+//	c, err := flannel.CreateAPIClient(flannel.WithErrorSummary(summary))
+//	summary.Report().WriteTo(os.Stdout)
+type ErrorSummary struct {
+	window time.Duration
+	slot   time.Duration
+	clock  Clock
+
+	mu    sync.Mutex
+	slots [errorSummarySlots]errorSummarySlot
+}
+
+type errorSummarySlot struct {
+	start  time.Time
+	counts map[errorKey]int64
+}
+
+type errorKey struct {
+	method   string
+	endpoint string
+	code     int
+	subcode  int
+}
+
+// NewErrorSummary creates a new ErrorSummary counting errors over window, which defaults to 15 minutes.
+func NewErrorSummary(window time.Duration) *ErrorSummary {
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	slot := window / errorSummarySlots
+	if slot <= 0 {
+		slot = 1
+	}
+	return &ErrorSummary{window: window, slot: slot}
+}
+
+// SetClock sets the Clock used to slide the window, which defaults to SystemClock. It must be set before the
+// summary is used.
+func (s *ErrorSummary) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// WithErrorSummary counts the Facebook errors returned by API calls with s.
+func WithErrorSummary(s *ErrorSummary) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.errorSummary = s
+		return nil
+	}
+}
+
+// observe counts an error returned by an API call.
+func (s *ErrorSummary) observe(method string, endpoint string, code int, subcode int) {
+	start := clockOrSystem(s.clock).Now().Truncate(s.slot)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot := &s.slots[int(start.UnixNano()/int64(s.slot))%errorSummarySlots]
+	if !slot.start.Equal(start) {
+		slot.start = start
+		slot.counts = make(map[errorKey]int64)
+	}
+	slot.counts[errorKey{method: method, endpoint: endpoint, code: code, subcode: subcode}]++
+}
+
+// ErrorCount is the number of errors returned by an endpoint with a code and subcode.
+type ErrorCount struct {
+	Method   string
+	Endpoint string
+	Code     int
+	Subcode  int
+	Count    int64
+
+	// Percent is the percentage of all errors in the window.
+	Percent float64
+}
+
+// ErrorReport is a snapshot of the errors counted by an ErrorSummary.
+type ErrorReport struct {
+	Window time.Duration
+	Total  int64
+
+	// Errors are sorted by count, most common first.
+	Errors []ErrorCount
+}
+
+// Report returns a snapshot of the errors counted in the window.
+func (s *ErrorSummary) Report() ErrorReport {
+	cutoff := clockOrSystem(s.clock).Now().Add(-s.window)
+	counts := make(map[errorKey]int64)
+	r := ErrorReport{Window: s.window}
+	s.mu.Lock()
+	for _, slot := range s.slots {
+		if !slot.start.After(cutoff) {
+			continue
+		}
+		for key, n := range slot.counts {
+			counts[key] += n
+			r.Total += n
+		}
+	}
+	s.mu.Unlock()
+	for key, n := range counts {
+		r.Errors = append(r.Errors, ErrorCount{
+			Method:   key.method,
+			Endpoint: key.endpoint,
+			Code:     key.code,
+			Subcode:  key.subcode,
+			Count:    n,
+			Percent:  100 * float64(n) / float64(r.Total),
+		})
+	}
+	sort.Slice(r.Errors, func(i, j int) bool {
+		a, b := r.Errors[i], r.Errors[j]
+		switch {
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		case a.Endpoint != b.Endpoint:
+			return a.Endpoint < b.Endpoint
+		case a.Method != b.Method:
+			return a.Method < b.Method
+		case a.Code != b.Code:
+			return a.Code < b.Code
+		}
+		return a.Subcode < b.Subcode
+	})
+	return r
+}
+
+// WriteTo writes the report in a readable form to w.
+func (r ErrorReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors in the last %s\n", r.Total, r.Window)
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "%5.1f%% %6d %s %s code %d subcode %d\n", e.Percent, e.Count, e.Method, e.Endpoint, e.Code, e.Subcode)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
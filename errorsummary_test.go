@@ -0,0 +1,45 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorSummary(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if strings.HasSuffix(r.URL.Path, "/2") {
+			fmt.Fprint(w, `{"error":{"message":"Unsupported get request","code":100,"error_subcode":33}}`)
+			return
+		}
+		fmt.Fprint(w, `{"error":{"message":"Invalid parameter","code":100,"error_subcode":1366046}}`)
+	}), WithErrorSummary(NewErrorSummary(time.Minute)))
+	summary := c.errorSummary
+	for _, id := range []string{"1", "1", "1", "2"} {
+		c.GetFundraiser(context.Background(), "token", id)
+	}
+
+	report := summary.Report()
+	if report.Total != 4 || len(report.Errors) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if e := report.Errors[0]; e.Subcode != 1366046 || e.Count != 3 || e.Percent != 75 || e.Endpoint != "/{id}" {
+		t.Errorf("unexpected most common error %+v", e)
+	}
+	var b bytes.Buffer
+	report.WriteTo(&b)
+	if !strings.Contains(b.String(), " 75.0%      3 GET /{id} code 100 subcode 1366046\n") {
+		t.Errorf("unexpected report\n%s", b.String())
+	}
+
+	// errors outside the window are not reported
+	summary.window = 0
+	if report := summary.Report(); report.Total != 0 {
+		t.Errorf("expected no errors in the window, got %d", report.Total)
+	}
+}
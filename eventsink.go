@@ -0,0 +1,106 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// An EventSink publishes webhook events to downstream services, such as a channel or a message bus.
+// Implementations must be safe for concurrent use.
+type EventSink interface {
+	Publish(ctx context.Context, event WebhookEvent) error
+}
+
+// The EventSinkFunc type is an adapter to allow the use of ordinary functions as EventSinks.
+type EventSinkFunc func(ctx context.Context, event WebhookEvent) error
+
+// Publish calls f(ctx, event).
+func (f EventSinkFunc) Publish(ctx context.Context, event WebhookEvent) error {
+	return f(ctx, event)
+}
+
+// PublishTo returns a func publishing each event to all of sinks in turn, which can be used as the OnEvent of
+// WebhookSettings or WebhookQueueSettings. It returns the first error publishing, so that the event is retried.
+//
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{AppSecret: secret, OnEvent: flannel.PublishTo(sqsSink, auditSink)})
+func PublishTo(sinks ...EventSink) func(ctx context.Context, event WebhookEvent) error {
+	return func(ctx context.Context, event WebhookEvent) error {
+		for _, sink := range sinks {
+			if err := sink.Publish(ctx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ChannelSink returns an EventSink sending events to ch, waiting until each is received or ctx is done.
+func ChannelSink(ch chan<- WebhookEvent) EventSink {
+	return EventSinkFunc(func(ctx context.Context, event WebhookEvent) error {
+		select {
+		case ch <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// FundraiserID returns the id of the fundraiser the event is for, or the id of the entry for other events.
+func (e WebhookEvent) FundraiserID() string {
+	switch {
+	case e.Donation != nil && e.Donation.FundraiserID != "":
+		return e.Donation.FundraiserID
+	case e.Fundraiser != nil && e.Fundraiser.ID != "":
+		return e.Fundraiser.ID
+	}
+	return e.EntryID
+}
+
+// eventMessage is the JSON encoding of a WebhookEvent published to message buses, its fields must only be added to
+// so that consumers are not broken.
+type eventMessage struct {
+	Type         WebhookEventType   `json:"type"`
+	Object       string             `json:"object"`
+	EntryID      string             `json:"entry_id"`
+	FundraiserID string             `json:"fundraiser_id"`
+	Time         time.Time          `json:"time"`
+	Field        string             `json:"field"`
+	Donation     *WebhookDonation   `json:"donation,omitempty"`
+	Fundraiser   *WebhookFundraiser `json:"fundraiser,omitempty"`
+	Value        json.RawMessage    `json:"value,omitempty"`
+}
+
+// MarshalEvent encodes event as JSON with a stable schema, for publishing to message buses.
+func MarshalEvent(event WebhookEvent) ([]byte, error) {
+	return json.Marshal(eventMessage{
+		Type:         event.Type,
+		Object:       event.Object,
+		EntryID:      event.EntryID,
+		FundraiserID: event.FundraiserID(),
+		Time:         event.Time.UTC(),
+		Field:        event.Field,
+		Donation:     event.Donation,
+		Fundraiser:   event.Fundraiser,
+		Value:        event.Value,
+	})
+}
+
+// UnmarshalEvent decodes an event encoded by MarshalEvent.
+func UnmarshalEvent(b []byte) (WebhookEvent, error) {
+	var m eventMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return WebhookEvent{}, err
+	}
+	return WebhookEvent{
+		Type:       m.Type,
+		Object:     m.Object,
+		EntryID:    m.EntryID,
+		Time:       m.Time,
+		Field:      m.Field,
+		Donation:   m.Donation,
+		Fundraiser: m.Fundraiser,
+		Value:      m.Value,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPublishTo(t *testing.T) {
+	ch := make(chan WebhookEvent, 1)
+	failed := errors.New("unavailable")
+	publish := PublishTo(ChannelSink(ch), EventSinkFunc(func(ctx context.Context, event WebhookEvent) error { return failed }))
+	event := WebhookEvent{Type: DonationCreated, EntryID: "1", Donation: &WebhookDonation{ID: "2", FundraiserID: "1234"}}
+	if err := publish(context.Background(), event); err != failed {
+		t.Errorf("expected error publishing to be returned, got %v", err)
+	}
+	if received := <-ch; received.Donation.ID != "2" {
+		t.Errorf("unexpected event received %+v", received)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := ChannelSink(make(chan WebhookEvent)).Publish(ctx, event); err != context.DeadlineExceeded {
+		t.Errorf("expected publishing to stop when the context is done, got %v", err)
+	}
+}
+
+func TestMarshalEvent(t *testing.T) {
+	event := WebhookEvent{
+		Type:     DonationCreated,
+		Object:   "fundraiser",
+		EntryID:  "1",
+		Time:     time.Unix(1767366245, 0).UTC(),
+		Field:    "donations",
+		Donation: &WebhookDonation{ID: "2", FundraiserID: "1234", Amount: 500},
+		Value:    []byte(`{"id":"2","fundraiser_id":"1234","amount":500}`),
+	}
+	b, err := MarshalEvent(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"donation_created","object":"fundraiser","entry_id":"1","fundraiser_id":"1234","time":"2026-01-02T15:04:05Z","field":"donations","donation":{"id":"2","fundraiser_id":"1234","amount":500,"currency":"","created_time":""},"value":{"id":"2","fundraiser_id":"1234","amount":500}}`
+	if string(b) != expected {
+		t.Errorf("unexpected encoding\n%s", b)
+	}
+	decoded, err := UnmarshalEvent(b)
+	if err != nil || !reflect.DeepEqual(decoded, event) {
+		t.Errorf("expected event to round trip, got %+v %v", decoded, err)
+	}
+}
@@ -0,0 +1,144 @@
+package flannel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventTotalsSettings configures EventTotals.
+type EventTotalsSettings struct {
+
+	// AccessToken is used to get each fundraiser.
+	AccessToken string
+
+	// Concurrency is the number of fundraisers fetched at the same time, defaults to 4.
+	Concurrency int
+
+	// CountDonations lists the donations of each fundraiser to count them, which requires further API calls.
+	CountDonations bool
+
+	// Cache, if set, holds the totals of each fundraiser for CacheTTL, donation counts are not cached.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// ReportingCurrency, if set with Rates, is the currency of the ReportingTotal of each event.
+	ReportingCurrency string
+	Rates             RateProvider
+}
+
+// EventTotal is the total raised by the fundraisers of an event.
+type EventTotal struct {
+
+	// Name and URI are the external_event_name and external_event_uri of the event's fundraisers.
+	Name string
+	URI  string
+
+	Fundraisers int
+
+	// AmountsRaised are the amounts raised by currency, in the smallest unit of each currency.
+	AmountsRaised map[string]int64
+
+	// ReportingTotal is the sum of AmountsRaised in the ReportingCurrency, in its smallest unit, if set.
+	ReportingTotal int64
+
+	// Donations is the number of donations made, set if CountDonations is set.
+	Donations int
+}
+
+// EventTotals are the totals of each event, as returned by EventTotals.
+type EventTotals struct {
+	Events []EventTotal
+
+	// Failed are the errors getting fundraisers, by ID, which are left out of the totals.
+	Failed map[string]error
+}
+
+// EventTotals gets fundraisers concurrently and sums the amount raised, and optionally the number of donations, by
+// event. Fundraisers are grouped by their external_event_uri, or external_event_name if they have no URI, and
+// fundraisers without either are left out. Events are ordered by name. Fundraisers which cannot be got are
+// reported in Failed. Amounts in other currencies are summed in the ReportingCurrency if set, failing if a rate
+// cannot be got.
+func (c APIClient) EventTotals(ctx context.Context, fundraiserIDs []string, settings EventTotalsSettings) (EventTotals, error) {
+	if settings.Concurrency < 1 {
+		settings.Concurrency = 4
+	}
+	totals, failed, err := c.fundraiserTotals(ctx, fundraiserIDs, LeaderboardSettings{
+		AccessToken: settings.AccessToken,
+		Concurrency: settings.Concurrency,
+		Cache:       settings.Cache,
+		CacheTTL:    settings.CacheTTL,
+	})
+	if err != nil {
+		return EventTotals{}, err
+	}
+	var donations []int
+	if settings.CountDonations {
+		donations = make([]int, len(totals))
+		errs := concurrently(ctx, len(totals), settings.Concurrency, func(i int) error {
+			it := c.ListDonations(ctx, settings.AccessToken, totals[i].ID, "id")
+			defer it.Close()
+			for it.Next() {
+				donations[i]++
+			}
+			return it.Err()
+		})
+		if err := ctx.Err(); err != nil {
+			return EventTotals{}, err
+		}
+		for i, err := range errs {
+			if err != nil {
+				failed[totals[i].ID] = err
+			}
+		}
+	}
+
+	events := make(map[string]*EventTotal)
+	for i, t := range totals {
+		if _, exists := failed[t.ID]; exists {
+			continue
+		}
+		key := strings.TrimSpace(t.ExternalEventURI)
+		if key == "" {
+			key = "name:" + strings.TrimSpace(t.ExternalEventName)
+		}
+		if key == "name:" {
+			continue
+		}
+		event, exists := events[key]
+		if !exists {
+			event = &EventTotal{Name: t.ExternalEventName, URI: t.ExternalEventURI, AmountsRaised: make(map[string]int64)}
+			events[key] = event
+		}
+		if event.Name == "" {
+			event.Name = t.ExternalEventName
+		}
+		event.Fundraisers++
+		event.AmountsRaised[t.Currency] += t.AmountRaised
+		if donations != nil {
+			event.Donations += donations[i]
+		}
+	}
+	result := EventTotals{Failed: failed}
+	conv := newConverter(settings.Rates, settings.ReportingCurrency)
+	for _, event := range events {
+		if conv != nil {
+			for currency, amount := range event.AmountsRaised {
+				converted, err := conv.convert(ctx, amount, currency)
+				if err != nil {
+					return EventTotals{}, err
+				}
+				event.ReportingTotal += converted
+			}
+		}
+		result.Events = append(result.Events, *event)
+	}
+	sort.Slice(result.Events, func(i, j int) bool {
+		if result.Events[i].Name != result.Events[j].Name {
+			return result.Events[i].Name < result.Events[j].Name
+		}
+		return result.Events[i].URI < result.Events[j].URI
+	})
+	return result, nil
+}
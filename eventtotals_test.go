@@ -0,0 +1,55 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEventTotals(t *testing.T) {
+	fundraisers := map[string]string{
+		"1": `"amount_raised":500,"currency":"GBP","external_event_name":"Marathon","external_event_uri":"https://example.com/marathon"`,
+		"2": `"amount_raised":1500,"currency":"GBP","external_event_uri":"https://example.com/marathon"`,
+		"3": `"amount_raised":1000,"currency":"USD","external_event_name":"Marathon","external_event_uri":"https://example.com/marathon"`,
+		"4": `"amount_raised":200,"currency":"GBP","external_event_name":"Bake Sale"`,
+		"5": `"amount_raised":300,"currency":"GBP"`,
+	}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2.8/")
+		if id := strings.TrimSuffix(path, "/donations"); id != path {
+			fmt.Fprint(w, `{"data":[{"id":"a"},{"id":"b"}]}`)
+			return
+		}
+		fields, exists := fundraisers[path]
+		if !exists {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Unsupported get request","code":100,"error_subcode":33}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,%s}`, path, fields)
+	}))
+
+	totals, err := c.EventTotals(context.Background(), []string{"1", "2", "3", "4", "5", "6"}, EventTotalsSettings{
+		AccessToken:       "token",
+		CountDonations:    true,
+		ReportingCurrency: "GBP",
+		Rates: RateProviderFunc(func(ctx context.Context, from string, to string) (float64, error) {
+			return 0.8, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("failed to get event totals %v", err)
+	}
+	var events []string
+	for _, event := range totals.Events {
+		events = append(events, fmt.Sprintf("%s %d %v %d %d", event.Name, event.Fundraisers, event.AmountsRaised, event.ReportingTotal, event.Donations))
+	}
+	if fmt.Sprintf("%q", events) != `["Bake Sale 1 map[GBP:200] 200 2" "Marathon 3 map[GBP:2000 USD:1000] 2800 6"]` {
+		t.Errorf("unexpected events %q", events)
+	}
+	if len(totals.Failed) != 1 || totals.Failed["6"] == nil {
+		t.Errorf("expected fundraiser 6 to fail, got %v", totals.Failed)
+	}
+}
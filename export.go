@@ -0,0 +1,185 @@
+package flannel
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// ExportFormat is the file format written by ExportFundraisers and ExportDonations.
+type ExportFormat string
+
+// Export formats.
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// Default fields exported if none are set.
+var (
+	DefaultFundraiserExportFields = []string{"id", "name", "goal_amount", "amount_raised", "currency", "end_time", "external_id"}
+	DefaultDonationExportFields   = []string{"id", "amount", "currency", "created_time"}
+)
+
+// ExportSettings configures ExportFundraisers and ExportDonations.
+type ExportSettings struct {
+
+	// Format is the file format written, defaults to ExportCSV.
+	Format ExportFormat
+
+	// Fields are the fields requested and written, in order, defaults to the default export fields.
+	Fields []string
+
+	// Cursor resumes an interrupted export, as passed to OnCheckpoint. The CSV header is not written when resuming,
+	// so the output can be appended to the file already written.
+	Cursor string
+
+	// OnCheckpoint is called with the cursor from which the export can be resumed each time a page has been
+	// written, and with an empty cursor once the export completes. Items of a page being written when an export
+	// is interrupted are written again when it is resumed.
+	OnCheckpoint func(cursor string) error
+}
+
+// ExportFundraisers pages through the Facebook Fundraisers created by the user identified by accessToken, writing
+// each to w as it is read. It returns the number of fundraisers written.
+func (c APIClient) ExportFundraisers(ctx context.Context, accessToken string, w io.Writer, settings ExportSettings) (int, error) {
+	ctx = backgroundContext(ctx)
+	if len(settings.Fields) == 0 {
+		settings.Fields = DefaultFundraiserExportFields
+	}
+	return c.export(c.list(ctx, accessToken, CreateFundraiserEndpoint, settings.Fields), w, settings)
+}
+
+// ExportDonations pages through the donations made to a Facebook Fundraiser, writing each to w as it is read.
+// It returns the number of donations written.
+func (c APIClient) ExportDonations(ctx context.Context, accessToken string, fundraiserID string, w io.Writer, settings ExportSettings) (int, error) {
+	ctx = backgroundContext(ctx)
+	if len(settings.Fields) == 0 {
+		settings.Fields = DefaultDonationExportFields
+	}
+	return c.export(c.list(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(fundraiserID)+"/donations", settings.Fields), w, settings)
+}
+
+// exportWriter writes a single item in an export format.
+type exportWriter interface {
+	write(item map[string]interface{}) error
+	flush() error
+}
+
+func (c APIClient) export(it *ListIterator, w io.Writer, settings ExportSettings) (int, error) {
+	defer it.Close()
+	var ew exportWriter
+	switch settings.Format {
+	case ExportCSV, "":
+		cw := &csvExportWriter{w: csv.NewWriter(w), fields: settings.Fields}
+		if settings.Cursor == "" {
+			if err := cw.w.Write(settings.Fields); err != nil {
+				return 0, err
+			}
+		}
+		ew = cw
+	case ExportNDJSON:
+		ew = &ndjsonExportWriter{enc: json.NewEncoder(w), fields: settings.Fields}
+	default:
+		return 0, fmt.Errorf("unsupported export format %s", settings.Format)
+	}
+
+	checkpoint := func(cursor string) error {
+		if err := ew.flush(); err != nil {
+			return err
+		}
+		if settings.OnCheckpoint == nil {
+			return nil
+		}
+		return settings.OnCheckpoint(cursor)
+	}
+
+	it.StartAfter(settings.Cursor)
+	n := 0
+	page := settings.Cursor
+	for it.Next() {
+		// the previous page has been written in full once the next page is read
+		if cursor := it.PageCursor(); cursor != page {
+			if err := checkpoint(cursor); err != nil {
+				return n, err
+			}
+			page = cursor
+		}
+		if err := ew.write(it.Item()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		if ferr := ew.flush(); ferr != nil {
+			return n, ferr
+		}
+		return n, err
+	}
+	return n, checkpoint("")
+}
+
+type csvExportWriter struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func (cw *csvExportWriter) write(item map[string]interface{}) error {
+	record := make([]string, len(cw.fields))
+	for i, field := range cw.fields {
+		v, err := exportValue(item[field])
+		if err != nil {
+			return fmt.Errorf("error exporting %s %v", field, err)
+		}
+		record[i] = v
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvExportWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// exportValue formats a value decoded from JSON as a CSV field, nested objects and arrays are written as JSON.
+func exportValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+type ndjsonExportWriter struct {
+	enc    *json.Encoder
+	fields []string
+}
+
+func (nw *ndjsonExportWriter) write(item map[string]interface{}) error {
+	selected := make(map[string]interface{}, len(nw.fields))
+	for _, field := range nw.fields {
+		if v, exists := item[field]; exists {
+			selected[field] = v
+		}
+	}
+	if err := nw.enc.Encode(selected); err != nil {
+		return fmt.Errorf("error encoding item %v", err)
+	}
+	return nil
+}
+
+func (nw *ndjsonExportWriter) flush() error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// donationPages serves two pages of donations, the second after cursor b.
+var donationPages = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("after") {
+	case "":
+		fmt.Fprint(w, `{"data":[{"id":"1","amount":500,"currency":"GBP","tags":["a"]},{"id":"2","amount":1000.5,"currency":"GBP"}],"paging":{"cursors":{"after":"b"},"next":"https://graph.facebook.com/v2.8/1234/donations?after=b"}}`)
+	case "b":
+		fmt.Fprint(w, `{"data":[{"id":"3","amount":250,"currency":"GBP"}],"paging":{"cursors":{"after":"c"}}}`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"Invalid cursor","code":100}}`)
+	}
+})
+
+func TestExportDonationsCSV(t *testing.T) {
+	c := newTestClient(t, donationPages)
+
+	var b bytes.Buffer
+	var checkpoints []string
+	n, err := c.ExportDonations(context.Background(), "token", "1234", &b, ExportSettings{
+		Fields: []string{"id", "amount", "tags"},
+		OnCheckpoint: func(cursor string) error {
+			checkpoints = append(checkpoints, cursor)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to export donations %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 donations exported, got %d", n)
+	}
+	expected := "id,amount,tags\n1,500,\"[\"\"a\"\"]\"\n2,1000.5,\n3,250,\n"
+	if b.String() != expected {
+		t.Errorf("unexpected export\n%s", b.String())
+	}
+	if fmt.Sprintf("%q", checkpoints) != `["b" ""]` {
+		t.Errorf("unexpected checkpoints %q", checkpoints)
+	}
+}
+
+func TestExportDonationsResumeNDJSON(t *testing.T) {
+	c := newTestClient(t, donationPages)
+
+	var b bytes.Buffer
+	n, err := c.ExportDonations(context.Background(), "token", "1234", &b, ExportSettings{
+		Format: ExportNDJSON,
+		Fields: []string{"id", "amount"},
+		Cursor: "b",
+	})
+	if err != nil {
+		t.Fatalf("failed to export donations %v", err)
+	}
+	if n != 1 || b.String() != "{\"amount\":250,\"id\":\"3\"}\n" {
+		t.Errorf("unexpected export of %d donations\n%s", n, b.String())
+	}
+
+	b.Reset()
+	if _, err = c.ExportDonations(context.Background(), "token", "1234", &b, ExportSettings{Cursor: "x"}); err == nil {
+		t.Errorf("expected invalid cursor to fail")
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected no header when resuming, got %q", b.String())
+	}
+}
@@ -0,0 +1,91 @@
+package flannel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GenerateExternalID returns a stable external ID for a fundraiser, prefix followed by a hash of key, such as the
+// ID of a campaign and a supporter, so that the same fundraiser is always given the same external ID.
+//
+//	externalID := flannel.GenerateExternalID("marathon2020", supporterID)
+func GenerateExternalID(prefix string, key ...string) string {
+	h := sha256.New()
+	for _, k := range key {
+		// length prefixed so that ("ab", "c") and ("a", "bc") differ
+		fmt.Fprintf(h, "%d:%s", len(k), k)
+	}
+	id := hex.EncodeToString(h.Sum(nil))[:32]
+	if prefix == "" {
+		return id
+	}
+	return prefix + "-" + id
+}
+
+type externalIDCollisionError struct {
+	// collisions are the fundraiser IDs using each external ID, empty for external IDs repeated in the same check.
+	collisions map[string]string
+}
+
+func (e externalIDCollisionError) Error() string {
+	externalIDs := make([]string, 0, len(e.collisions))
+	for externalID := range e.collisions {
+		externalIDs = append(externalIDs, externalID)
+	}
+	sort.Strings(externalIDs)
+	messages := make([]string, len(externalIDs))
+	for i, externalID := range externalIDs {
+		if id := e.collisions[externalID]; id != "" {
+			messages[i] = fmt.Sprintf("external id %s is used by fundraiser %s", externalID, id)
+		} else {
+			messages[i] = fmt.Sprintf("external id %s is repeated", externalID)
+		}
+	}
+	return strings.Join(messages, ", ")
+}
+
+// ExternalIDCollisions returns the external IDs which are already used if err was returned by CheckExternalIDs,
+// mapped to the ID of the fundraiser using them, which is empty for external IDs repeated in the same check.
+func ExternalIDCollisions(err error) map[string]string {
+	var e externalIDCollisionError
+	if errors.As(err, &e) {
+		return e.collisions
+	}
+	return nil
+}
+
+// CheckExternalIDs checks that none of externalIDs are used by the fundraisers already created by the user
+// identified by accessToken, or repeated, returning an error for which ExternalIDCollisions returns those which are.
+// Fundraisers created with an external ID which is already used fail with an unhelpful Graph API error.
+// It requires the manage_fundraisers scope.
+func (c APIClient) CheckExternalIDs(ctx context.Context, accessToken string, externalIDs ...string) error {
+	collisions := make(map[string]string)
+	checked := make(map[string]bool, len(externalIDs))
+	for _, externalID := range externalIDs {
+		if checked[externalID] {
+			collisions[externalID] = ""
+		}
+		checked[externalID] = true
+	}
+	it := c.ListFundraisers(ctx, accessToken, "id", "external_id")
+	defer it.Close()
+	for it.Next() {
+		externalID, _ := it.Item()["external_id"].(string)
+		if checked[externalID] {
+			id, _ := it.Item()["id"].(string)
+			collisions[externalID] = id
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if len(collisions) > 0 {
+		return externalIDCollisionError{collisions: collisions}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateExternalID(t *testing.T) {
+	id := GenerateExternalID("marathon", "campaign", "supporter")
+	if id != GenerateExternalID("marathon", "campaign", "supporter") {
+		t.Errorf("expected external id to be stable")
+	}
+	if len(id) != len("marathon-")+32 || id[:len("marathon-")] != "marathon-" {
+		t.Errorf("unexpected external id %s", id)
+	}
+	if GenerateExternalID("", "ab", "c") == GenerateExternalID("", "a", "bc") {
+		t.Errorf("expected different keys to generate different external ids")
+	}
+}
+
+func TestCheckExternalIDs(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"1","external_id":"used"},{"id":"2","external_id":"other"}]}`)
+	}))
+	ctx := context.Background()
+	if err := c.CheckExternalIDs(ctx, "token", "new", "unused"); err != nil {
+		t.Errorf("unexpected error checking unused external ids %v", err)
+	}
+	err := c.CheckExternalIDs(ctx, "token", "used", "new", "repeated", "repeated")
+	if got, want := ExternalIDCollisions(err), map[string]string{"used": "1", "repeated": ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected collisions %v, got %v", want, got)
+	}
+	if err == nil || err.Error() != "external id repeated is repeated, external id used is used by fundraiser 1" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package flannel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FairSchedulerSettings configures a FairScheduler.
+type FairSchedulerSettings struct {
+
+	// Rate is the number of API calls permitted per second across all tenants, such as the app's Facebook quota,
+	// with bursts of up to Burst API calls. A zero Rate does not limit API calls across tenants.
+	Rate  float64
+	Burst int
+
+	// TenantRate, if set, is the budget of API calls permitted per second for each tenant, with bursts of up to
+	// TenantBurst API calls, so that no tenant can use the whole Rate.
+	TenantRate  float64
+	TenantBurst int
+
+	// Weights are the shares of the Rate given to tenants whilst they compete for it, which default to 1.
+	// API calls made without a tenant, set with ContextWithTenant, are made by the tenant "".
+	Weights map[string]float64
+
+	// Clock refills the rate and budgets, defaults to SystemClock.
+	Clock Clock
+}
+
+// A FairScheduler is a RateLimiter sharing a rate of API calls between tenants with weighted fair queuing, so that
+// one tenant's bulk import cannot consume the app's quota and block the API calls of every other tenant.
+// Waiting API calls are permitted in order of their virtual finish time, which advances by the inverse of the
+// weight of their tenant for each API call, and each tenant can also be given a budget.
+//
+//	scheduler := flannel.NewFairScheduler(flannel.FairSchedulerSettings{Rate: 50, Burst: 10, TenantRate: 20, TenantBurst: 5})
+//	c, err := flannel.CreateAPIClient(flannel.WithRateLimiter(scheduler))
+//	c.CreateFundraiserWithContext(flannel.ContextWithTenant(ctx, charityID), params)
+type FairScheduler struct {
+	settings FairSchedulerSettings
+
+	mu      sync.Mutex
+	global  bucket
+	tenants map[string]*fairTenant
+	pending []*fairRequest
+	virtual float64
+	stop    func() bool
+
+	// sweep is the number of tenants at which idle tenants are next evicted
+	sweep int
+}
+
+type fairTenant struct {
+	budget bucket
+	weight float64
+
+	// finish is the virtual finish time of the tenant's last API call
+	finish float64
+}
+
+type fairRequest struct {
+	tenant *fairTenant
+	finish float64
+	ready  chan struct{}
+
+	// previous is the virtual finish time of the tenant before the request, restored if it is cancelled
+	previous float64
+}
+
+// fairTenantSweep is the fewest tenants at which idle tenants are evicted.
+const fairTenantSweep = 64
+
+// NewFairScheduler creates a new FairScheduler.
+func NewFairScheduler(settings FairSchedulerSettings) *FairScheduler {
+	return &FairScheduler{
+		settings: settings,
+		global:   newBucket(settings.Rate, settings.Burst),
+		tenants:  make(map[string]*fairTenant),
+		sweep:    fairTenantSweep,
+	}
+}
+
+// Wait blocks until the API call is permitted or ctx is done.
+func (s *FairScheduler) Wait(ctx context.Context) error {
+	name, _ := TenantFromContext(ctx)
+	now := clockOrSystem(s.settings.Clock).Now()
+	s.mu.Lock()
+	s.evict(now)
+	t := s.tenant(name)
+	start := t.finish
+	if s.virtual > start {
+		start = s.virtual
+	}
+	r := &fairRequest{tenant: t, finish: start + 1/t.weight, ready: make(chan struct{}), previous: t.finish}
+	t.finish = r.finish
+	s.pending = append(s.pending, r)
+	s.dispatch(now)
+	s.mu.Unlock()
+
+	select {
+	case <-r.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.pending {
+		if p == r {
+			s.pending = append(s.pending[:i:i], s.pending[i+1:]...)
+			if r.tenant.finish == r.finish {
+				// the tenant has not waited since, so it is not charged for the cancelled API call
+				r.tenant.finish = r.previous
+			}
+			return ctx.Err()
+		}
+	}
+	// permitted as ctx was done
+	return nil
+}
+
+// tenant returns the state of the tenant with name, s.mu must be held.
+func (s *FairScheduler) tenant(name string) *fairTenant {
+	t, exists := s.tenants[name]
+	if !exists {
+		weight, ok := s.settings.Weights[name]
+		if !ok || weight <= 0 {
+			weight = 1
+		}
+		t = &fairTenant{budget: newBucket(s.settings.TenantRate, s.settings.TenantBurst), weight: weight}
+		s.tenants[name] = t
+	}
+	return t
+}
+
+// evict removes idle tenants, which have no API calls waiting, a full budget and no virtual time ahead of the
+// scheduler, and so are the same as new tenants. It only sweeps once the number of tenants has doubled since the
+// last sweep, s.mu must be held.
+func (s *FairScheduler) evict(now time.Time) {
+	if len(s.tenants) < s.sweep {
+		return
+	}
+	waiting := make(map[*fairTenant]bool, len(s.pending))
+	for _, r := range s.pending {
+		waiting[r.tenant] = true
+	}
+	for name, t := range s.tenants {
+		t.budget.refill(now)
+		if !waiting[t] && t.finish <= s.virtual && t.budget.full() {
+			delete(s.tenants, name)
+		}
+	}
+	s.sweep = 2 * len(s.tenants)
+	if s.sweep < fairTenantSweep {
+		s.sweep = fairTenantSweep
+	}
+}
+
+// dispatch permits waiting API calls in order of their virtual finish time whilst the rate and the budgets of
+// their tenants allow, scheduling itself to run again once they next will, s.mu must be held.
+func (s *FairScheduler) dispatch(now time.Time) {
+	s.global.refill(now)
+	for len(s.pending) > 0 {
+		next := -1
+		var wait time.Duration
+		for i, r := range s.pending {
+			r.tenant.budget.refill(now)
+			if !r.tenant.budget.available() {
+				if d := r.tenant.budget.wait(); wait == 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+			if next == -1 || r.finish < s.pending[next].finish {
+				next = i
+			}
+		}
+		if next == -1 {
+			s.schedule(wait)
+			return
+		}
+		if !s.global.available() {
+			s.schedule(s.global.wait())
+			return
+		}
+		r := s.pending[next]
+		s.pending = append(s.pending[:next:next], s.pending[next+1:]...)
+		s.global.take()
+		r.tenant.budget.take()
+		s.virtual = r.finish
+		close(r.ready)
+	}
+}
+
+// schedule runs dispatch after d, s.mu must be held.
+func (s *FairScheduler) schedule(d time.Duration) {
+	if s.stop != nil {
+		s.stop()
+	}
+	clock := clockOrSystem(s.settings.Clock)
+	s.stop = clock.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.dispatch(clock.Now())
+	})
+}
+
+// bucket is a token bucket, which is unlimited if its rate is zero.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+func (b *bucket) available() bool {
+	return b.rate <= 0 || b.tokens >= 1
+}
+
+func (b *bucket) full() bool {
+	return b.rate <= 0 || b.tokens >= b.burst
+}
+
+func (b *bucket) take() {
+	if b.rate > 0 {
+		b.tokens--
+	}
+}
+
+// wait returns how long until a token will be available.
+func (b *bucket) wait() time.Duration {
+	if b.rate <= 0 || b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
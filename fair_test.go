@@ -0,0 +1,109 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairScheduler(t *testing.T) {
+	s := NewFairScheduler(FairSchedulerSettings{Rate: 200, Burst: 1})
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	waitFor := func(n int) {
+		for {
+			s.mu.Lock()
+			pending := len(s.pending)
+			s.mu.Unlock()
+			if pending >= n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	call := func(tenant string) {
+		defer wg.Done()
+		if err := s.Wait(ContextWithTenant(context.Background(), tenant)); err != nil {
+			t.Errorf("failed waiting %v", err)
+		}
+		mu.Lock()
+		order = append(order, tenant)
+		mu.Unlock()
+	}
+
+	// a bulk import queues many API calls before another tenant makes any
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go call("bulk")
+	}
+	waitFor(8)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go call("interactive")
+	}
+	wg.Wait()
+	last := 0
+	for i, tenant := range order {
+		if tenant == "interactive" {
+			last = i
+		}
+	}
+	if last > 5 {
+		t.Errorf("expected other tenant not to wait for the bulk import, got %v", order)
+	}
+
+	// tenants cannot exceed their budget, even when the rate is available
+	s = NewFairScheduler(FairSchedulerSettings{Rate: 1000, Burst: 10, TenantRate: 0.1, TenantBurst: 1})
+	ctx := ContextWithTenant(context.Background(), "bulk")
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("failed waiting %v", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(timeout); err == nil {
+		t.Errorf("expected tenant to wait for its budget")
+	}
+	if err := s.Wait(ContextWithTenant(context.Background(), "other")); err != nil {
+		t.Errorf("expected other tenant to have its own budget, got %v", err)
+	}
+}
+
+func TestFairSchedulerIdleTenants(t *testing.T) {
+	clock := newTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewFairScheduler(FairSchedulerSettings{TenantRate: 0.1, TenantBurst: 1, Clock: clock})
+	wait := func(tenant string) {
+		t.Helper()
+		if err := s.Wait(ContextWithTenant(context.Background(), tenant)); err != nil {
+			t.Fatalf("failed waiting %v", err)
+		}
+	}
+	wait("bulk")
+	finish := s.tenants["bulk"].finish
+	timeout, cancel := context.WithTimeout(ContextWithTenant(context.Background(), "bulk"), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(timeout); err == nil {
+		t.Fatalf("expected tenant to wait for its budget")
+	}
+	if s.tenants["bulk"].finish != finish {
+		t.Errorf("expected cancelled API call not to advance the virtual finish time of its tenant")
+	}
+
+	// tenants are evicted once their budgets refill, but not whilst their budget is spent
+	for i := 0; i < fairTenantSweep; i++ {
+		wait(fmt.Sprint("a", i))
+	}
+	clock.advance(10 * time.Second)
+	wait("bulk")
+	for i := 0; i < fairTenantSweep; i++ {
+		wait(fmt.Sprint("b", i))
+	}
+	if _, exists := s.tenants["a0"]; exists || len(s.tenants) >= 2*fairTenantSweep {
+		t.Errorf("expected idle tenants to be evicted, got %d tenants", len(s.tenants))
+	}
+	if _, exists := s.tenants["bulk"]; !exists {
+		t.Error("expected tenant with a spent budget not to be evicted")
+	}
+}
@@ -1,23 +1,72 @@
 package flannel
 
 import (
-	"bytes"
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
 // APIClient represents a HTTP client to the Facebook APIs.
 type APIClient struct {
-	httpClient       *http.Client
-	logger           Logger
-	debugModeEnabled bool
+	httpClient         *http.Client
+	logger             Logger
+	debugModeEnabled   bool
+	rateLimiter        RateLimiter
+	tenantRateLimiters map[string]RateLimiter
+	circuitBreaker     *CircuitBreaker
+	concurrency        *concurrencyLimiter
+	hedger             *hedger
+	maxResponseSize    int64
+	responseCache      Cache
+	metadataCache      Cache
+	metadataCacheTTL   time.Duration
+	retryPolicy        RetryPolicy
+	idempotency        *idempotency
+	transfer           *transferCounters
+	stats              *statsCollector
+	redaction          RedactionLevel
+	logSampling        *logSampling
+	metrics            Metrics
+	connectionTracing  *connectionTracing
+	auditSink          AuditSink
+	requestIDHeader    string
+	httpDump           *httpDump
+	graphDebug         *graphDebug
+	deprecation        *deprecationHandler
+	maxCoverPhotoSize  int64
+
+	coverPhotoDownloadTimeout time.Duration
+	coverPhotoBandwidth       int64
+	appSecrets                *appSecrets
+	sanitizer                 *Sanitizer
+	contentChecker            ContentChecker
+	requestEncoding           RequestEncoding
+	compatibilityCheck        bool
+	errorSummary              *ErrorSummary
+	endpointRetryPolicies     map[EndpointClass]RetryPolicy
+	clock                     Clock
+	multipartBoundary         string
+	live                      *LiveConfig
+	baseHosts                 []*url.URL
+	dialer                    *net.Dialer
+	strictDecoding            *strictDecoding
+	strictDeprecation         bool
+	sunsetWarnings            *sunsetWarnings
+	errorHook                 func(ctx context.Context, call CallInfo, err error)
+	mirror                    MirrorStore
 }
 
 // Logger is the interface implemented by the APIClient when logging API calls.
@@ -39,6 +88,10 @@ type flannelError struct {
 	Err  error
 }
 
+func (e flannelError) Unwrap() error {
+	return e.Err
+}
+
 func (e flannelError) Error() string {
 	if e.Err == nil {
 		return ""
@@ -48,33 +101,41 @@ func (e flannelError) Error() string {
 
 const (
 	errorWithFundraiserCoverPhoto = iota
+	errorResponseTooLarge
 )
 
 // A RestrictedReader wraps the provided Reader restricting the
 // amount of data read to the specified MaxSize of bytes.
-// Each call to Read updates BytesRead to reflect the new total.
-// If the MaxSize is exceeded an error is returned.
+// Each call to Read updates BytesRead to reflect the new total,
+// which is also reported to the optional Progress func.
+// If the MaxSize is exceeded an error wrapping ErrMaxSizeExceeded is returned.
+//
+// Deprecated: use NewMeteredReader, which also supports bandwidth throttling.
 type RestrictedReader struct {
 	Reader    io.Reader
 	MaxSize   int
 	BytesRead int
+	Progress  func(bytesRead int)
 }
 
-var errMaxSizeExceeded = errors.New("max size exceeded")
-
 func (r *RestrictedReader) Read(p []byte) (n int, err error) {
 	n, err = r.Reader.Read(p)
 	r.BytesRead = r.BytesRead + n
+	if r.Progress != nil && n > 0 {
+		r.Progress(r.BytesRead)
+	}
 	if r.BytesRead > r.MaxSize {
 		// if we have exceeded the max size then override the error
-		err = errMaxSizeExceeded
+		err = fmt.Errorf("%w reading more than %d bytes", ErrMaxSizeExceeded, r.MaxSize)
 	}
 	return
 }
 
 // IsMaxSizeExceeded returns true if err is max size exceeded.
+//
+// Deprecated: use errors.Is(err, ErrMaxSizeExceeded).
 func (r *RestrictedReader) IsMaxSizeExceeded(err error) bool {
-	return err == errMaxSizeExceeded
+	return errors.Is(err, ErrMaxSizeExceeded)
 }
 
 type facebookError struct {
@@ -88,7 +149,7 @@ type facebookError struct {
 }
 
 func (e facebookError) Error() string {
-	return fmt.Sprintf("%s %d %v", e.Endpoint, e.Status, e.ErrorMap)
+	return redactString(fmt.Sprintf("%s %d %v", e.Endpoint, e.Status, e.ErrorMap))
 }
 
 func (e facebookError) ErrorCodes() (code int, subcode int) {
@@ -117,7 +178,8 @@ func (e facebookError) Messages() (message string, errorusertitle string, erroru
 
 // Facebook API endpoints.
 const (
-	CreateFundraiserEndpoint = "https://graph.facebook.com/v2.8/me/fundraisers"
+	GraphAPIEndpoint         = "https://graph.facebook.com/v2.8"
+	CreateFundraiserEndpoint = GraphAPIEndpoint + "/me/fundraisers"
 )
 
 // CreateFundraiserParams is the set of parameters required to create a Facebook Fundraiser.
@@ -154,10 +216,32 @@ type CreateFundraiserParams struct {
 // FundraiserCoverPhotoImageMaxSize defines the maximum size for fundraiser cover photo images.
 const FundraiserCoverPhotoImageMaxSize = (4 * 1024 * 1024) - 1
 
+// WithCoverPhotoMaxSize sets the maximum size of cover photo images, defaults to FundraiserCoverPhotoImageMaxSize.
+// Cover photos of a known size, including those downloaded with a Content-Length, are checked before they are read.
+func WithCoverPhotoMaxSize(maxSize int64) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if maxSize < 1 {
+			return fmt.Errorf("invalid cover photo max size %d", maxSize)
+		}
+		c.maxCoverPhotoSize = maxSize
+		return nil
+	}
+}
+
+func (c APIClient) coverPhotoMaxSize() int64 {
+	if c.maxCoverPhotoSize > 0 {
+		return c.maxCoverPhotoSize
+	}
+	return FundraiserCoverPhotoImageMaxSize
+}
+
 // CreateAPIClient creates a new HTTP client to the Facebook APIs with the provided options.
 func CreateAPIClient(options ...func(*APIClient) error) (APIClient, error) {
 	c := APIClient{
-		httpClient: &http.Client{Timeout: time.Second * 20},
+		httpClient:      &http.Client{Timeout: time.Second * 20},
+		transfer:        &transferCounters{},
+		stats:           &statsCollector{},
+		maxResponseSize: DefaultMaxResponseSize,
 	}
 	for _, option := range options {
 		if err := option(&c); err != nil {
@@ -180,91 +264,252 @@ func WithLogger(logger Logger, debug bool) func(*APIClient) error {
 // CreateFundraiser creates a new Facebook Fundraiser.
 // Required parameters are set with params.
 // Optional parameters  are set with options.
+// Options writing to the multipart.Writer directly, rather than the options of this package, are called each time
+// the request body is written, including when a failed API call is retried.
+// It requires the manage_fundraisers scope.
 func (c APIClient) CreateFundraiser(params CreateFundraiserParams, options ...func(*multipart.Writer) error) (status int, result map[string]interface{}, err error) {
+	return c.CreateFundraiserWithContext(context.Background(), params, options...)
+}
+
+// CreateFundraiserWithContext creates a new Facebook Fundraiser using the provided context.
+// The context is used when waiting on any configured rate limiters and for the lifetime of the API call.
+// It requires the manage_fundraisers scope.
+func (c APIClient) CreateFundraiserWithContext(ctx context.Context, params CreateFundraiserParams, options ...func(*multipart.Writer) error) (status int, result map[string]interface{}, err error) {
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	// add required fields
-	fields := map[string]string{
-		"charity_id":      params.CharityID,
-		"name":            params.Title,
-		"description":     params.Description,
-		"goal_amount":     fmt.Sprintf("%d", params.Goal),
-		"currency":        params.Currency,
-		"end_time":        fmt.Sprintf("%d", params.EndTime.Unix()),
-		"external_id":     params.ExternalID,
-		"fundraiser_type": "person_for_charity",
+	if c.sanitizer != nil {
+		if err := c.sanitizer.Apply(&params); err != nil {
+			return 0, nil, err
+		}
 	}
-	for k, v := range fields {
-		err = writer.WriteField(k, v)
+
+	if c.idempotency != nil && params.ExternalID != "" {
+		unlock := c.idempotency.lock(params.ExternalID)
+		defer unlock()
+		existing, found, err := c.existingFundraiser(ctx, params)
 		if err != nil {
-			break
+			return 0, nil, err
+		}
+		if found {
+			return http.StatusOK, existing, nil
 		}
+		defer func() {
+			if err == nil || IsDeprecated(err) {
+				c.recordFundraiser(ctx, params, result)
+			}
+		}()
 	}
+
+	release, err := c.acquire(ctx)
 	if err != nil {
 		return 0, nil, err
 	}
+	defer release()
+
+	form := &fundraiserForm{ctx: ctx, client: c}
+	// add required fields, in a fixed order so that request bodies are deterministic
+	form.addField("charity_id", params.CharityID)
+	form.addField("name", params.Title)
+	form.addField("description", params.Description)
+	form.addField("goal_amount", fmt.Sprintf("%d", params.Goal))
+	form.addField("currency", params.Currency)
+	form.addField("end_time", fmt.Sprintf("%d", params.EndTime.Unix()))
+	form.addField("external_id", params.ExternalID)
+	form.addField("fundraiser_type", "person_for_charity")
 	// add optional fields
-	for _, option := range options {
-		if err := option(writer); err != nil {
-			return 0, nil, err
-		}
+	if err := form.applyOptions(options); err != nil {
+		return 0, nil, err
 	}
-	err = writer.Close()
-	if err != nil {
+	if err := form.checkContent(params); err != nil {
+		return 0, nil, err
+	}
+	defer form.close()
+	if err := form.preflight(); err != nil {
 		return 0, nil, err
 	}
+	defer func() {
+		c.audit(ctx, "POST", CreateFundraiserEndpoint, params.ExternalID, form, status, result, err)
+	}()
 	var req *http.Request
-	req, err = http.NewRequest("POST", CreateFundraiserEndpoint, body)
-	if err != nil {
-		return 0, nil, fmt.Errorf("error preparing request %v", err)
+	var bodies *multipartBodies
+	if len(form.files) == 0 && len(form.writers) == 0 && c.requestEncoding != RequestEncodingDefault {
+		values := url.Values{}
+		for _, field := range form.fields {
+			values.Set(field.name, field.value)
+		}
+		if req, err = c.newPostRequest(ctx, params.AccessToken, CreateFundraiserEndpoint, values); err != nil {
+			return 0, nil, err
+		}
+	} else {
+		// stream the multipart body so that cover photos are never held in memory
+		bodies = form.multipartBodies()
+		body, _ := bodies.next()
+		req, err = http.NewRequestWithContext(ctx, "POST", CreateFundraiserEndpoint, body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("error preparing request %v", err)
+		}
+		req.GetBody = bodies.next
+		req.Header.Set("Authorization", "Bearer "+params.AccessToken)
+		req.Header.Set("Content-Type", bodies.contentType)
+	}
+
+	if err := c.checkCompatibility(req, form.fieldNames()); err != nil {
+		if bodies != nil {
+			bodies.err()
+		}
+		return 0, nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+params.AccessToken)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	var res *http.Response
-	res, err = c.httpClient.Do(req)
+	res, err = c.do(ctx, req)
+	if bodies != nil {
+		formErr := bodies.err()
+		c.logMultipart("facebook api", req, bodies.parts())
+		if formErr != nil {
+			if res != nil {
+				drainAndClose(res.Body)
+			}
+			return 0, nil, formErr
+		}
+	}
 	if err != nil {
-		return 0, nil, fmt.Errorf("error transporting request %v", err)
+		return 0, nil, err
 	}
 
 	return c.readResponse(CreateFundraiserEndpoint, req, res, http.StatusOK)
 }
 
 // WithFundraiserCoverPhotoImage adds an optional cover photo image when creating a new Facebook Fundraiser.
+// If content is an io.ReadSeeker it is rewound when a failed API call is retried,
+// otherwise API calls including the cover photo image are not retried.
 func WithFundraiserCoverPhotoImage(name string, content io.Reader) func(*multipart.Writer) error {
-	return func(w *multipart.Writer) error {
-		part, err := w.CreateFormFile("cover_photo", name)
-		if err != nil {
+	open, replayable := readerSource(content)
+	return WithFundraiserCoverPhotoSource(name, open, replayable)
+}
+
+// WithFundraiserCoverPhotoSource adds an optional cover photo image when creating a new Facebook Fundraiser.
+// The open func is called to read the image each time the API call is attempted and the returned content is closed
+// after reading. Set replayable if open can be called more than once, so that failed API calls can be retried.
+func WithFundraiserCoverPhotoSource(name string, open func() (io.ReadCloser, error), replayable bool) func(*multipart.Writer) error {
+	return formOption(func(f *fundraiserForm) error {
+		f.addCoverPhoto(name, replayable, func() (io.ReadCloser, int64, error) {
+			content, err := open()
+			if err != nil {
+				return nil, 0, err
+			}
+			return content, contentSize(content), nil
+		})
+		return nil
+	})
+}
+
+// WithFundraiserCoverPhotoFile adds an optional cover photo image read from the file at path when creating a new
+// Facebook Fundraiser. The file name and MIME type are inferred from path, and the size of the file is checked before
+// it is read. The file is opened each time the API call is attempted and closed after reading.
+func WithFundraiserCoverPhotoFile(path string) func(*multipart.Writer) error {
+	return formOption(func(f *fundraiserForm) error {
+		if _, err := os.Stat(path); err != nil {
 			return flannelError{errorWithFundraiserCoverPhoto, err}
 		}
-		_, err = io.Copy(part, &RestrictedReader{Reader: content, MaxSize: FundraiserCoverPhotoImageMaxSize})
-		if err != nil {
-			return flannelError{errorWithFundraiserCoverPhoto, err}
+		f.addCoverPhoto(filepath.Base(path), true, func() (io.ReadCloser, int64, error) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			fi, err := file.Stat()
+			if err != nil {
+				file.Close()
+				return nil, 0, err
+			}
+			return file, fi.Size(), nil
+		})
+		if f.coverPhotoContentType == "" {
+			if contentType := mime.TypeByExtension(filepath.Ext(path)); strings.HasPrefix(contentType, "image/") {
+				f.files[len(f.files)-1].contentType = contentType
+			}
 		}
 		return nil
-	}
+	})
 }
 
 // WithFundraiserCoverPhotoURL adds an optional cover photo when creating a new Facebook Fundraiser.
+// The cover photo is downloaded using the APIClient's HTTP client and the context of the API call.
 func WithFundraiserCoverPhotoURL(name string, content url.URL) func(*multipart.Writer) error {
-	return func(w *multipart.Writer) error {
-		part, err := w.CreateFormFile("cover_photo", name)
+	return formOption(func(f *fundraiserForm) error {
+		f.addCoverPhoto(name, true, func() (io.ReadCloser, int64, error) {
+			return f.fetch(content)
+		})
+		return nil
+	})
+}
+
+// WithFundraiserCoverPhotoProgress reports the progress of uploading the cover photo when creating a new Facebook Fundraiser.
+// The progress func is called as the cover photo is read with the number of bytes transferred so far, and the total size
+// of the cover photo or -1 if unknown. For cover photos added with WithFundraiserCoverPhotoURL this includes downloading
+// the photo, which is streamed as it is uploaded.
+func WithFundraiserCoverPhotoProgress(progress func(transferred int64, total int64)) func(*multipart.Writer) error {
+	return formOption(func(f *fundraiserForm) error {
+		f.coverPhotoProgress = progress
+		return nil
+	})
+}
+
+// addCoverPhoto adds the cover photo file, applying any transforms, validating its format, dimensions and size and reporting progress.
+// The cover photo is validated before the request is sent.
+func (f *fundraiserForm) addCoverPhoto(name string, replayable bool, open func() (io.ReadCloser, int64, error)) {
+	f.addFile("cover_photo", name, errorWithFundraiserCoverPhoto, replayable, func() (io.ReadCloser, error) {
+		content, size, err := open()
 		if err != nil {
-			return flannelError{errorWithFundraiserCoverPhoto, err}
+			return nil, err
 		}
-		httpClient := &http.Client{Timeout: time.Second * 20}
-		res, err := httpClient.Get(content.String())
-		if err != nil {
-			return flannelError{errorWithFundraiserCoverPhoto, err}
+		var photo io.Reader = content
+		if len(f.coverPhotoTransforms) > 0 {
+			for _, transform := range f.coverPhotoTransforms {
+				if photo, err = transform(photo); err != nil {
+					content.Close()
+					return nil, err
+				}
+			}
+			size = contentSize(photo)
 		}
-		defer res.Body.Close()
-		_, err = io.Copy(part, &RestrictedReader{Reader: res.Body, MaxSize: FundraiserCoverPhotoImageMaxSize})
-		if err != nil {
-			return flannelError{errorWithFundraiserCoverPhoto, err}
+		maxSize := f.client.coverPhotoMaxSize()
+		if size > maxSize {
+			content.Close()
+			return nil, coverPhotoError(coverPhotoFormatSubcode, coverPhotoFormatMessage)
+		}
+		br := bufio.NewReaderSize(photo, imageHeaderSize)
+		header, _ := br.Peek(imageHeaderSize)
+		if err := validateCoverPhoto(header); err != nil {
+			content.Close()
+			return nil, err
+		}
+		settings := MeteredReaderSettings{MaxSize: maxSize, BytesPerSecond: f.client.coverPhotoBandwidth}
+		if progress := f.coverPhotoProgress; progress != nil {
+			settings.Progress = func(bytesRead int64) {
+				progress(bytesRead, size)
+			}
+		}
+		r := NewMeteredReader(br, settings)
+		return struct {
+			io.Reader
+			io.Closer
+		}{r, content}, nil
+	})
+	f.files[len(f.files)-1].preflight = true
+	f.files[len(f.files)-1].contentType = f.coverPhotoContentType
+}
+
+// WithFundraiserCoverPhotoContentType sets the MIME type of the cover photo when creating a new Facebook Fundraiser,
+// such as image/jpeg. Otherwise the MIME type is detected from the content of the cover photo.
+func WithFundraiserCoverPhotoContentType(contentType string) func(*multipart.Writer) error {
+	return formOption(func(f *fundraiserForm) error {
+		f.coverPhotoContentType = contentType
+		for i := range f.files {
+			if f.files[i].fieldName == "cover_photo" {
+				f.files[i].contentType = contentType
+			}
 		}
 		return nil
-	}
+	})
 }
 
 // WithFundraiserField adds an optional field when creating a new Facebook Fundraiser.
@@ -280,9 +525,150 @@ func WithFundraiserCoverPhotoURL(name string, content url.URL) func(*multipart.W
 // external_event_start_time - Unix timestamp of the day when the event takes place
 //
 func WithFundraiserField(name string, value string) func(*multipart.Writer) error {
-	return func(w *multipart.Writer) error {
-		return w.WriteField(name, value)
+	return formOption(func(f *fundraiserForm) error {
+		f.addField(name, value)
+		return nil
+	})
+}
+
+// GetFundraiser reads an existing Facebook Fundraiser.
+// The fields to return can optionally be set with fields, otherwise Facebook returns its default fields.
+// It requires the manage_fundraisers scope.
+func (c APIClient) GetFundraiser(ctx context.Context, accessToken string, fundraiserID string, fields ...string) (status int, result map[string]interface{}, err error) {
+	return c.get(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(fundraiserID), fieldsQuery(fields))
+}
+
+// UpdateFundraiser updates the fields of an existing Facebook Fundraiser, such as name, description, goal_amount and end_time.
+// It requires the manage_fundraisers scope.
+func (c APIClient) UpdateFundraiser(ctx context.Context, accessToken string, fundraiserID string, fields map[string]string) (status int, result map[string]interface{}, err error) {
+	if c.sanitizer != nil {
+		if fields, err = c.sanitizer.applyFields(fields); err != nil {
+			return 0, nil, err
+		}
+	}
+	_, name := fields["name"]
+	_, description := fields["description"]
+	if name || description {
+		content := FundraiserContent{FundraiserID: fundraiserID, Title: fields["name"], Description: fields["description"]}
+		if err := c.checkContent(ctx, content); err != nil {
+			return 0, nil, err
+		}
 	}
+	form := url.Values{}
+	for k, v := range fields {
+		form.Set(k, v)
+	}
+	return c.post(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(fundraiserID), form)
+}
+
+// post sends form to the Facebook object at endpoint.
+func (c APIClient) post(ctx context.Context, accessToken string, endpoint string, form url.Values) (status int, result map[string]interface{}, err error) {
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer release()
+
+	var req *http.Request
+	req, err = c.newPostRequest(ctx, accessToken, endpoint, form)
+	if err != nil {
+		return 0, nil, err
+	}
+	fields := make([]string, 0, len(form))
+	for k := range form {
+		fields = append(fields, k)
+	}
+	if err := c.checkCompatibility(req, fields); err != nil {
+		return 0, nil, err
+	}
+
+	var res *http.Response
+	res, err = c.do(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return c.readResponse(endpoint, req, res, http.StatusOK)
+}
+
+// get reads the Facebook object at endpoint.
+func (c APIClient) get(ctx context.Context, accessToken string, endpoint string, query url.Values) (status int, result map[string]interface{}, err error) {
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer release()
+
+	u := endpoint
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error preparing request %v", err)
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	if err := c.checkCompatibility(req, queryFields(query)); err != nil {
+		return 0, nil, err
+	}
+
+	var res *http.Response
+	res, err = c.doCached(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return c.readResponse(endpoint, req, res, http.StatusOK)
+}
+
+func fieldsQuery(fields []string) url.Values {
+	query := url.Values{}
+	if len(fields) > 0 {
+		query.Set("fields", strings.Join(fields, ","))
+	}
+	return query
+}
+
+// DefaultMaxResponseSize defines the default maximum size of API call responses.
+const DefaultMaxResponseSize = 10 * 1024 * 1024
+
+// WithMaxResponseSize limits the size of API call responses which are read into memory to maxSize bytes.
+// Responses exceeding the limit return an error detectable with IsErrorResponseTooLarge.
+func WithMaxResponseSize(maxSize int64) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.maxResponseSize = maxSize
+		return nil
+	}
+}
+
+// readBody reads body up to the maximum response size into a pooled buffer,
+// the returned release func must be called once the body is no longer used.
+func (c APIClient) readBody(body io.Reader) (b []byte, release func(), err error) {
+	buf := getResponseBuffer()
+	release = func() { putResponseBuffer(buf) }
+	if c.maxResponseSize <= 0 {
+		_, err = buf.ReadFrom(body)
+		return buf.Bytes(), release, err
+	}
+	_, err = buf.ReadFrom(io.LimitReader(body, c.maxResponseSize+1))
+	b = buf.Bytes()
+	if err == nil && int64(len(b)) > c.maxResponseSize {
+		return b[:c.maxResponseSize], release, flannelError{errorResponseTooLarge, fmt.Errorf("response exceeds max size of %d bytes", c.maxResponseSize)}
+	}
+	return b, release, err
+}
+
+// IsErrorResponseTooLarge returns true if err was returned because an API call response exceeded the max size.
+func IsErrorResponseTooLarge(err error) bool {
+	if e, ok := err.(flannelError); ok {
+		return e.Type == errorResponseTooLarge
+	}
+	return false
 }
 
 // IsErrorWithFundraiserCoverPhoto returns true if err was returned from WithFundraiserCoverPhotoURL option.
@@ -321,35 +707,119 @@ func ErrorCodes(err error) (code int, subcode int) {
 	return 0, 0
 }
 
-func (c APIClient) readResponse(endpoint string, req *http.Request, res *http.Response, expectedstatus int) (status int, result map[string]interface{}, err error) {
-	var body []byte
-	if res != nil {
-		status = res.StatusCode
-		if res.ContentLength > 0 || res.ContentLength == -1 { // -1 represents unknown content length
-			body, err = ioutil.ReadAll(res.Body)
-			if err == nil {
-				// Defer closing of underlying connection so it can be re-used...
-				defer res.Body.Close()
+// do sends req, retrying failed attempts when configured with WithRetryPolicy or WithEndpointRetryPolicy.
+func (c APIClient) do(ctx context.Context, req *http.Request) (res *http.Response, err error) {
+	req = c.withGraphVersion(ctx, req)
+	c.checkSunset(ctx, req)
+	defer func() { c.callFailed(ctx, req, 0, err) }()
+	policy := c.retryPolicyFor(req)
+	for attempt := 1; ; attempt++ {
+		res, err := c.attemptWithTimeout(ctx, policy.Timeout, req)
+		if !policy.retryable(ctx, attempt, res, err) {
+			return res, err
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return res, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return res, err
 			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+		if res != nil {
+			drainAndClose(res.Body)
+		}
+		if c.metrics != nil {
+			c.metrics.ObserveRetry(req.Method, statsEndpoint(req))
+		}
+		if waitErr := policy.wait(ctx, c.clock, attempt); waitErr != nil {
+			return nil, waitErr
 		}
 	}
-	defer func() {
-		if c.logger != nil && (c.debugModeEnabled || err != nil) {
-			if len(body) > 0 {
-				c.logger.Logf("facebook api %s request to %s returned %d %s\n", req.Method, req.URL.String(), status, string(body))
-			} else {
-				c.logger.Logf("facebook api %s request to %s returned %d\n", req.Method, req.URL.String(), status)
-			}
+}
+
+// attempt sends req, hedging idempotent requests when configured with WithHedgedReads, and adding an
+// appsecret_proof when configured with WithAppSecretProof.
+func (c APIClient) attempt(ctx context.Context, req *http.Request) (*http.Response, error) {
+	send := c.send
+	if s := c.currentAppSecrets(); s != nil {
+		send = func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return c.sendWithProof(ctx, req, s)
+		}
+	}
+	if c.hedger != nil && req.Method == http.MethodGet {
+		return c.hedger.do(ctx, req, send)
+	}
+	return send(ctx, req)
+}
+
+// send sends req once permitted by any configured rate limiters and circuit breaker.
+func (c APIClient) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if err := c.waitRateLimiters(ctx); err != nil {
+		return nil, err
+	}
+	if c.circuitBreaker != nil {
+		if err := c.circuitBreaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	acceptGzip(req)
+	c.addGraphDebug(req)
+	if c.requestIDHeader != "" {
+		if requestID, ok := RequestIDFromContext(ctx); ok {
+			req.Header.Set(c.requestIDHeader, requestID)
 		}
+	}
+	req, traced := c.traceConnection(req)
+	c.dumpRequest(req)
+	start := time.Now()
+	res, err := c.sendHTTP(c.httpClient, req)
+	traced(err)
+	latency := time.Since(start)
+	c.stats.record(req, res, err, latency)
+	c.observeResponse(req, res, latency)
+	if c.circuitBreaker != nil {
+		c.circuitBreaker.record(err != nil || res.StatusCode >= http.StatusInternalServerError, latency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error transporting request %s", redactString(err.Error()))
+	}
+	c.decodeResponse(res)
+	c.dumpResponse(res)
+	return res, nil
+}
+
+// readResponse reads and parses the response to an API call, which is always drained and closed
+// so that the underlying connection can be re-used.
+func (c APIClient) readResponse(endpoint string, req *http.Request, res *http.Response, expectedstatus int) (status int, result map[string]interface{}, err error) {
+	status = res.StatusCode
+	body, release, err := c.readBody(res.Body)
+	defer release()
+	drainAndClose(res.Body)
+	defer func() {
+		c.logRequest("facebook api", req, status, body, err)
+		c.observeError(req, err)
+		c.callFailed(req.Context(), req, status, err)
 	}()
+	if IsErrorResponseTooLarge(err) {
+		return
+	}
 	if err != nil {
 		err = fmt.Errorf("error reading response %v", err)
+		return
 	}
 	result = make(map[string]interface{})
 	err = json.Unmarshal(body, &result)
 	if err != nil {
 		err = fmt.Errorf("error parsing response %v", err)
 	}
+	c.reportGraphDebug(req, res, result)
+	if deprecationErr := c.checkDeprecation(req, res, result); deprecationErr != nil && err == nil {
+		err = deprecationErr
+	}
 	if status != expectedstatus {
 		if e, exists := result["error"]; exists {
 			if m, ok := e.(map[string]interface{}); ok {
@@ -361,3 +831,13 @@ func (c APIClient) readResponse(endpoint string, req *http.Request, res *http.Re
 	}
 	return
 }
+
+// maxDrainSize limits how much of an unread response body is discarded in order to re-use its connection.
+const maxDrainSize = 64 * 1024
+
+// drainAndClose discards any unread response body before closing it, as the underlying
+// connection is only returned to the pool for re-use once the body has been read to EOF.
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, maxDrainSize))
+	body.Close()
+}
@@ -0,0 +1,103 @@
+package flanneltest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock is a flannel.Clock whose time only moves when it is advanced, so that time dependent behaviour can be
+// tested deterministically.
+//
+//	clock := flanneltest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+//	c, err := flannel.CreateAPIClient(flannel.WithClock(clock))
+//	clock.Advance(time.Hour)
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*clockTimer
+	changed chan struct{}
+}
+
+type clockTimer struct {
+	due time.Time
+	f   func()
+}
+
+// NewClock creates a new Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now, changed: make(chan struct{})}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc calls f in its own goroutine once the clock is advanced by at least d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &clockTimer{due: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.notify()
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, pending := range c.timers {
+			if pending == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				c.notify()
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Advance moves the clock forward by d, calling the funcs of timers which are then due in the order they are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].due.Before(c.timers[j].due) })
+	var pending []*clockTimer
+	for _, t := range c.timers {
+		if t.due.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		go t.f()
+	}
+	c.timers = pending
+	c.notify()
+}
+
+// Timers returns the number of timers waiting for the clock to be advanced.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until at least n timers are waiting for the clock to be advanced, for example until the
+// code under test is waiting to retry a request.
+func (c *Clock) WaitForTimers(n int) {
+	for {
+		c.mu.Lock()
+		waiting, changed := len(c.timers), c.changed
+		c.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// notify wakes any goroutines waiting for the timers to change, c.mu must be held.
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
@@ -0,0 +1,44 @@
+package flanneltest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Now()
+	clock := NewClock(start)
+	s := NewServer()
+	defer s.Close()
+	c, err := s.Client(flannel.WithClock(clock), flannel.WithRetryPolicy(flannel.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour, MaxBackoff: time.Hour}))
+	if err != nil {
+		t.Fatalf("failed to create client %v", err)
+	}
+	ctx := context.Background()
+	params := flannel.CreateFundraiserParams{AccessToken: "token", CharityID: "1", Title: "Marathon", Description: "Running",
+		Goal: 10000, Currency: "GBP", EndTime: start.Add(24 * time.Hour)}
+	_, result, err := c.CreateFundraiserWithContext(ctx, params)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	id := result["id"].(string)
+
+	s.FailNext(ServerError)
+	done := make(chan error)
+	go func() {
+		_, _, err := c.GetFundraiser(ctx, "token", id)
+		done <- err
+	}()
+	// the retry waits for the clock, rather than up to an hour
+	clock.WaitForTimers(1)
+	clock.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if s.Requests() != 3 {
+		t.Errorf("expected 3 requests, got %d", s.Requests())
+	}
+}
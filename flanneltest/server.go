@@ -0,0 +1,476 @@
+// Package flanneltest provides a fake Facebook Graph API server, so that code using flannel can be tested
+// without calling Facebook.
+//
+//	s := flanneltest.NewServer()
+//	defer s.Close()
+//	c, err := s.Client()
+//	_, result, err := c.CreateFundraiser(params)
+package flanneltest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+// A GraphError is an error response of the Graph API.
+// See https://developers.facebook.com/docs/graph-api/using-graph-api/error-handling/
+type GraphError struct {
+	Status      int
+	Code        int
+	Subcode     int
+	Type        string
+	Message     string
+	UserTitle   string
+	UserMessage string
+	IsTransient bool
+}
+
+// Canonical errors returned by the Graph API.
+var (
+	InvalidParameter = GraphError{Status: http.StatusBadRequest, Code: 100, Type: "OAuthException", Message: "(#100) Invalid parameter"}
+
+	CoverPhotoRejected = GraphError{Status: http.StatusBadRequest, Code: 100, Subcode: 1366046, Type: "OAuthException",
+		Message:     "Your photos couldn't be uploaded. Photos should be smaller than 4 MB and saved as JPG, PNG, GIF, TIFF, HEIF or WebP files.",
+		UserTitle:   "Photo Upload Failed",
+		UserMessage: "Your photos couldn't be uploaded. Photos should be smaller than 4 MB and saved as JPG, PNG, GIF, TIFF, HEIF or WebP files."}
+
+	InvalidAccessToken = GraphError{Status: http.StatusBadRequest, Code: 190, Type: "OAuthException", Message: "Invalid OAuth access token."}
+
+	ObjectNotFound = GraphError{Status: http.StatusBadRequest, Code: 100, Subcode: 33, Type: "GraphMethodException",
+		Message: "Unsupported get request. Object does not exist, cannot be loaded due to missing permissions, or does not support this operation."}
+
+	RateLimited = GraphError{Status: http.StatusBadRequest, Code: 4, Type: "OAuthException", Message: "(#4) Application request limit reached", IsTransient: true}
+
+	ServerError = GraphError{Status: http.StatusInternalServerError, Code: 2, Type: "OAuthException", Message: "An unexpected error has occurred. Please retry your request later.", IsTransient: true}
+)
+
+// Write writes the error as a Graph API response.
+func (e GraphError) Write(w http.ResponseWriter) {
+	body := map[string]interface{}{
+		"message":      e.Message,
+		"type":         e.Type,
+		"code":         e.Code,
+		"is_transient": e.IsTransient,
+		"fbtrace_id":   "flanneltest",
+	}
+	if e.Subcode != 0 {
+		body["error_subcode"] = e.Subcode
+	}
+	if e.UserTitle != "" {
+		body["error_user_title"] = e.UserTitle
+	}
+	if e.UserMessage != "" {
+		body["error_user_msg"] = e.UserMessage
+	}
+	writeJSON(w, e.Status, map[string]interface{}{"error": body})
+}
+
+// withMessage returns a copy of the error with message.
+func (e GraphError) withMessage(message string) GraphError {
+	e.Message = message
+	return e
+}
+
+// A Server is a fake Graph API serving fundraisers and donations from memory.
+// Fundraisers are owned by the access token which created them, any non-empty access token is valid until revoked.
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the number of items in each page of a list, defaults to 25.
+	PageSize int
+
+	mu          sync.Mutex
+	nextID      int
+	fundraisers map[string]*fundraiser
+	order       []string
+	revoked     map[string]bool
+	failures    []GraphError
+	requests    int
+}
+
+type fundraiser struct {
+	owner     string
+	fields    map[string]interface{}
+	donations []map[string]interface{}
+}
+
+// NewServer creates and starts a new Server, which must be closed once no longer used.
+func NewServer() *Server {
+	s := &Server{
+		PageSize:    25,
+		nextID:      1000,
+		fundraisers: make(map[string]*fundraiser),
+		revoked:     make(map[string]bool),
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client creates a flannel.APIClient whose API calls, to any host, are served by the Server.
+// Cover photo URLs are also requested from the Server, so are not found.
+func (s *Server) Client(options ...func(*flannel.APIClient) error) (flannel.APIClient, error) {
+	return flannel.CreateAPIClient(append([]func(*flannel.APIClient) error{flannel.WithHTTPTransport(s.Transport())}, options...)...)
+}
+
+// Transport returns an http.Transport which sends requests, to any host, to the Server.
+func (s *Server) Transport() *http.Transport {
+	t := s.Server.Client().Transport.(*http.Transport).Clone()
+	// the test certificate is valid for example.com
+	t.TLSClientConfig.ServerName = "example.com"
+	addr := s.Listener.Addr().String()
+	t.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// FailNext fails the next API calls with errs, one error for each call.
+func (s *Server) FailNext(errs ...GraphError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, errs...)
+}
+
+// RevokeToken makes accessToken invalid, so that API calls using it fail with InvalidAccessToken.
+func (s *Server) RevokeToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[accessToken] = true
+}
+
+// Requests returns the number of API calls served.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Fundraiser returns the fields of the fundraiser with id.
+func (s *Server) Fundraiser(id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, exists := s.fundraisers[id]
+	if !exists {
+		return nil, false
+	}
+	return copyFields(f.fields, nil), true
+}
+
+// AddDonation adds a donation of amount, in the currency's smallest unit, to the fundraiser with id,
+// returning the ID of the donation. The amount raised by the fundraiser is updated to include the donation.
+func (s *Server) AddDonation(fundraiserID string, amount int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, exists := s.fundraisers[fundraiserID]
+	if !exists {
+		return "", fmt.Errorf("fundraiser %s does not exist", fundraiserID)
+	}
+	id := s.newID()
+	f.donations = append(f.donations, map[string]interface{}{
+		"id":           id,
+		"amount":       float64(amount),
+		"currency":     f.fields["currency"],
+		"created_time": formatTime(time.Now()),
+	})
+	raised, _ := f.fields["amount_raised"].(float64)
+	f.fields["amount_raised"] = raised + float64(amount)
+	return id, nil
+}
+
+var versionPrefix = regexp.MustCompile(`^/(v[0-9]+\.[0-9]+)/`)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	version := versionPrefix.FindStringSubmatch(r.URL.Path)
+	if version == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("facebook-api-version", version[1])
+	if len(s.failures) > 0 {
+		err := s.failures[0]
+		s.failures = s.failures[1:]
+		err.Write(w)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" || s.revoked[token] {
+		InvalidAccessToken.Write(w)
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path[len(version[0]):], "/"), "/")
+	switch {
+	case len(path) == 2 && path[0] == "me" && path[1] == "fundraisers" && r.Method == http.MethodPost:
+		s.create(w, r, token)
+	case len(path) == 2 && path[0] == "me" && path[1] == "fundraisers" && r.Method == http.MethodGet:
+		var items []map[string]interface{}
+		for _, id := range s.order {
+			if f := s.fundraisers[id]; f.owner == token {
+				items = append(items, f.fields)
+			}
+		}
+		s.writeList(w, r, items)
+	case len(path) == 1 && r.Method == http.MethodGet:
+		if f := s.fundraisers[path[0]]; f != nil {
+			writeJSON(w, http.StatusOK, copyFields(f.fields, fields(r)))
+			return
+		}
+		ObjectNotFound.Write(w)
+	case len(path) == 1 && r.Method == http.MethodPost:
+		s.update(w, r, path[0], token)
+	case len(path) == 2 && path[1] == "donations" && r.Method == http.MethodGet:
+		if f := s.fundraisers[path[0]]; f != nil {
+			s.writeList(w, r, f.donations)
+			return
+		}
+		ObjectNotFound.Write(w)
+	default:
+		InvalidParameter.withMessage("Unknown path components: " + r.URL.Path).Write(w)
+	}
+}
+
+// requiredFields are the fields which must be set to create a fundraiser.
+var requiredFields = []string{"charity_id", "name", "description", "goal_amount", "currency", "end_time"}
+
+// parseBody parses the parameters of a POST request, sent as a multipart or URL encoded form or a JSON object,
+// returning the files of multipart forms.
+func parseBody(r *http.Request) (url.Values, map[string][]*multipart.FileHeader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(int64(flannel.FundraiserCoverPhotoImageMaxSize) * 2); err != nil {
+			return nil, nil, fmt.Errorf("(#100) Invalid multipart form %v", err)
+		}
+		return url.Values(r.MultipartForm.Value), r.MultipartForm.File, nil
+	case "application/json":
+		var object map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+			return nil, nil, fmt.Errorf("(#100) Invalid JSON object %v", err)
+		}
+		values := url.Values{}
+		for name, value := range object {
+			if s, ok := value.(string); ok {
+				values.Set(name, s)
+				continue
+			}
+			values.Set(name, fmt.Sprint(value))
+		}
+		return values, nil, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, fmt.Errorf("(#100) Invalid form %v", err)
+	}
+	return r.PostForm, nil, nil
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, token string) {
+	values, files, err := parseBody(r)
+	if err != nil {
+		InvalidParameter.withMessage(err.Error()).Write(w)
+		return
+	}
+	for _, name := range requiredFields {
+		if values.Get(name) == "" {
+			InvalidParameter.withMessage(fmt.Sprintf("(#100) The parameter %s is required", name)).Write(w)
+			return
+		}
+	}
+	goal, err := strconv.Atoi(values.Get("goal_amount"))
+	if err != nil || goal <= 0 {
+		InvalidParameter.withMessage("(#100) Param goal_amount must be a positive integer").Write(w)
+		return
+	}
+	endTime, err := strconv.ParseInt(values.Get("end_time"), 10, 64)
+	if err != nil || time.Unix(endTime, 0).Before(time.Now()) {
+		InvalidParameter.withMessage("(#100) Param end_time must be a future Unix timestamp").Write(w)
+		return
+	}
+	if photos := files["cover_photo"]; len(photos) > 0 && !validCoverPhoto(photos[0]) {
+		CoverPhotoRejected.Write(w)
+		return
+	}
+
+	id := s.newID()
+	f := &fundraiser{owner: token, fields: map[string]interface{}{
+		"id":            id,
+		"charity":       map[string]interface{}{"id": values.Get("charity_id")},
+		"goal_amount":   float64(goal),
+		"amount_raised": float64(0),
+		"end_time":      formatTime(time.Unix(endTime, 0)),
+	}}
+	for name := range values {
+		switch name {
+		case "charity_id", "goal_amount", "end_time":
+		default:
+			f.fields[name] = values.Get(name)
+		}
+	}
+	s.fundraisers[id] = f
+	s.order = append(s.order, id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id})
+}
+
+// updatableFields are the fields which can be updated.
+var updatableFields = map[string]bool{"name": true, "description": true, "goal_amount": true, "end_time": true,
+	"external_fundraiser_uri": true, "external_event_name": true, "external_event_uri": true, "external_event_start_time": true}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, id string, token string) {
+	f := s.fundraisers[id]
+	if f == nil {
+		ObjectNotFound.Write(w)
+		return
+	}
+	if f.owner != token {
+		InvalidParameter.withMessage("(#200) Permissions error").Write(w)
+		return
+	}
+	values, _, err := parseBody(r)
+	if err != nil {
+		InvalidParameter.withMessage(err.Error()).Write(w)
+		return
+	}
+	for name := range values {
+		if !updatableFields[name] {
+			InvalidParameter.withMessage(fmt.Sprintf("(#100) Param %s cannot be updated", name)).Write(w)
+			return
+		}
+	}
+	for name := range values {
+		value := values.Get(name)
+		switch name {
+		case "goal_amount":
+			goal, err := strconv.Atoi(value)
+			if err != nil || goal <= 0 {
+				InvalidParameter.withMessage("(#100) Param goal_amount must be a positive integer").Write(w)
+				return
+			}
+			f.fields[name] = float64(goal)
+		case "end_time":
+			endTime, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				InvalidParameter.withMessage("(#100) Param end_time must be a Unix timestamp").Write(w)
+				return
+			}
+			f.fields[name] = formatTime(time.Unix(endTime, 0))
+		default:
+			f.fields[name] = value
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// writeList writes a page of items, the after cursor is the index of the next item.
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, items []map[string]interface{}) {
+	start := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		b, err := base64.RawURLEncoding.DecodeString(after)
+		if start, err = strconv.Atoi(string(b)); err != nil || start < 0 || start > len(items) {
+			InvalidParameter.withMessage("(#100) Invalid after cursor").Write(w)
+			return
+		}
+	}
+	end := start + s.PageSize
+	if s.PageSize <= 0 || end > len(items) {
+		end = len(items)
+	}
+	data := make([]map[string]interface{}, 0, end-start)
+	for _, item := range items[start:end] {
+		data = append(data, copyFields(item, fields(r)))
+	}
+	cursor := func(i int) string { return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(i))) }
+	paging := map[string]interface{}{"cursors": map[string]string{"before": cursor(start), "after": cursor(end)}}
+	if end < len(items) {
+		query := r.URL.Query()
+		query.Set("after", cursor(end))
+		paging["next"] = (&url.URL{Scheme: "https", Host: "graph.facebook.com", Path: r.URL.Path, RawQuery: query.Encode()}).String()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging})
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// validCoverPhoto returns true if the cover photo would be accepted by Facebook.
+func validCoverPhoto(header *multipart.FileHeader) bool {
+	if header.Size > flannel.FundraiserCoverPhotoImageMaxSize {
+		return false
+	}
+	file, err := header.Open()
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	b := make([]byte, 512)
+	n, _ := io.ReadFull(file, b)
+	switch contentType := http.DetectContentType(b[:n]); contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	case "application/octet-stream":
+		// TIFF and HEIF are not sniffed, so are trusted if declared
+		declared := header.Header.Get("Content-Type")
+		return declared == "image/tiff" || declared == "image/heif" || declared == "image/heic"
+	default:
+		return false
+	}
+}
+
+// fields returns the fields requested by r, or nil for all fields.
+func fields(r *http.Request) []string {
+	if f := r.URL.Query().Get("fields"); f != "" {
+		return strings.Split(f, ",")
+	}
+	return nil
+}
+
+// copyFields copies the named fields of m, which always include the id, or all fields if names is nil.
+func copyFields(m map[string]interface{}, names []string) map[string]interface{} {
+	c := make(map[string]interface{})
+	if names == nil {
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	c["id"] = m["id"]
+	for _, name := range names {
+		if v, exists := m[name]; exists {
+			c[name] = v
+		}
+	}
+	return c
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05-0700")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,99 @@
+package flanneltest
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.PageSize = 1
+	c, err := s.Client()
+	if err != nil {
+		t.Fatalf("failed to create client %v", err)
+	}
+	ctx := context.Background()
+
+	var photo bytes.Buffer
+	png.Encode(&photo, image.NewGray(image.Rect(0, 0, 16, 16)))
+	params := flannel.CreateFundraiserParams{AccessToken: "token", CharityID: "1", Title: "Marathon", Description: "Running",
+		Goal: 10000, Currency: "GBP", EndTime: time.Now().Add(24 * time.Hour), ExternalID: "abc"}
+	_, result, err := c.CreateFundraiserWithContext(ctx, params, flannel.WithFundraiserCoverPhotoImage("photo.png", bytes.NewReader(photo.Bytes())))
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	id := result["id"].(string)
+
+	if _, _, err := c.UpdateFundraiser(ctx, "token", id, map[string]string{"name": "Half Marathon"}); err != nil {
+		t.Fatalf("failed to update fundraiser %v", err)
+	}
+	s.AddDonation(id, 500)
+	s.AddDonation(id, 1000)
+	_, result, err = c.GetFundraiser(ctx, "token", id, "name", "amount_raised")
+	if err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if result["name"] != "Half Marathon" || result["amount_raised"] != float64(1500) || len(result) != 3 {
+		t.Errorf("unexpected fundraiser %v", result)
+	}
+
+	it := c.ListDonations(ctx, "token", id)
+	var donations int
+	for it.Next() {
+		donations++
+	}
+	if it.Err() != nil || donations != 2 {
+		t.Errorf("expected 2 donations, got %d %v", donations, it.Err())
+	}
+
+	if _, _, err := c.GetFundraiser(ctx, "token", "1"); err == nil {
+		t.Error("expected error getting missing fundraiser")
+	}
+	s.FailNext(CoverPhotoRejected)
+	if _, _, err := c.CreateFundraiserWithContext(ctx, params); !flannel.IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error, got %v", err)
+	}
+	s.RevokeToken("token")
+	if _, _, err := c.GetFundraiser(ctx, "token", id); err == nil {
+		t.Error("expected error using revoked token")
+	} else if code, _ := flannel.ErrorCodes(err); code != 190 {
+		t.Errorf("expected invalid access token error, got %v", err)
+	}
+}
+
+func TestServerRequestEncodings(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	for _, encoding := range []flannel.RequestEncoding{flannel.RequestEncodingForm, flannel.RequestEncodingJSON} {
+		c, err := s.Client(flannel.WithRequestEncoding(encoding))
+		if err != nil {
+			t.Fatalf("failed to create client %v", err)
+		}
+		params := flannel.CreateFundraiserParams{AccessToken: "token", CharityID: "1", Title: "Marathon", Description: "Running",
+			Goal: 10000, Currency: "GBP", EndTime: time.Now().Add(24 * time.Hour)}
+		_, result, err := c.CreateFundraiserWithContext(ctx, params)
+		if err != nil {
+			t.Fatalf("failed to create fundraiser with encoding %d %v", encoding, err)
+		}
+		id := result["id"].(string)
+		if _, _, err := c.UpdateFundraiser(ctx, "token", id, map[string]string{"name": "Half Marathon", "goal_amount": "5000"}); err != nil {
+			t.Fatalf("failed to update fundraiser with encoding %d %v", encoding, err)
+		}
+		_, result, err = c.GetFundraiser(ctx, "token", id, "name", "goal_amount", "charity")
+		if err != nil {
+			t.Fatalf("failed to get fundraiser %v", err)
+		}
+		charity, _ := result["charity"].(map[string]interface{})
+		if result["name"] != "Half Marathon" || result["goal_amount"] != float64(5000) || charity["id"] != "1" {
+			t.Errorf("unexpected fundraiser with encoding %d %v", encoding, result)
+		}
+	}
+}
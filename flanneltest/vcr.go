@@ -0,0 +1,240 @@
+package flanneltest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/homemade/flannel"
+)
+
+// A RecorderMode sets whether a Recorder records or replays API calls.
+type RecorderMode int
+
+const (
+	// Replay serves API calls from the fixture file, failing any call which was not recorded.
+	Replay RecorderMode = iota
+
+	// Record sends API calls using the underlying transport and records them to the fixture file.
+	Record
+)
+
+// RecorderModeFromEnv returns Record if the FLANNEL_RECORD environment variable is set, otherwise Replay,
+// so that fixtures are replayed in CI and re-recorded on demand.
+func RecorderModeFromEnv() RecorderMode {
+	if os.Getenv("FLANNEL_RECORD") != "" {
+		return Record
+	}
+	return Replay
+}
+
+// A Recorder is an http.RoundTripper which records API calls to a fixture file and replays them, so that tests
+// run deterministically without calling Facebook. Access tokens and other secrets are scrubbed before recording.
+//
+// Recorded API calls are matched by method and URL, and replayed in the order they were recorded.
+//
+//	r, err := flanneltest.NewRecorder("testdata/create.json", flanneltest.RecorderModeFromEnv(), nil)
+//	defer r.Save()
+//	c, err := r.Client()
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int
+}
+
+// An Interaction is an API call recorded by a Recorder.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// A RecordedRequest is the request of a recorded API call, with secrets scrubbed.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// A RecordedResponse is the response of a recorded API call, with secrets scrubbed.
+type RecordedResponse struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// NewRecorder creates a Recorder using the fixture file at path. When replaying, the fixture file must exist.
+// When recording, API calls are sent using transport, which defaults to http.DefaultTransport.
+func NewRecorder(path string, mode RecorderMode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, transport: transport, replayed: make(map[string]int)}
+	if mode == Record {
+		return r, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r.interactions); err != nil {
+		return nil, fmt.Errorf("error parsing fixture file %s %v", path, err)
+	}
+	return r, nil
+}
+
+// Client creates a flannel.APIClient whose API calls are recorded or replayed by the Recorder.
+func (r *Recorder) Client(options ...func(*flannel.APIClient) error) (flannel.APIClient, error) {
+	return flannel.CreateAPIClient(append([]func(*flannel.APIClient) error{flannel.WithHTTPTransport(r)}, options...)...)
+}
+
+// RoundTrip records or replays the API call req.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{Method: req.Method, URL: scrubURL(req.URL)}
+	if len(body) <= maxRecordedBodySize && utf8.Valid(body) {
+		recorded.Body = scrubBody(string(body))
+	}
+	if r.mode == Replay {
+		return r.replay(req, recorded)
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+	response := RecordedResponse{Status: res.StatusCode, Header: header}
+	if utf8.Valid(resBody) {
+		response.Body = scrubBody(string(resBody))
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(resBody)
+		response.BodyEncoding = "base64"
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return res, nil
+}
+
+// replay returns the next recorded response to a request matching recorded.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := recorded.Method + " " + recorded.URL
+	n := r.replayed[key]
+	for _, interaction := range r.interactions {
+		if interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL {
+			continue
+		}
+		if n > 0 {
+			n--
+			continue
+		}
+		r.replayed[key]++
+		body := []byte(interaction.Response.Body)
+		if interaction.Response.BodyEncoding == "base64" {
+			var err error
+			if body, err = base64.StdEncoding.DecodeString(interaction.Response.Body); err != nil {
+				return nil, fmt.Errorf("error decoding recorded response %v", err)
+			}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded response for %s in %s", key, r.path)
+}
+
+// Save writes the recorded API calls to the fixture file, it does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+// maxRecordedBodySize limits the size of request bodies recorded, so that cover photos are not recorded.
+const maxRecordedBodySize = 64 * 1024
+
+// secretParams are query parameters and JSON keys scrubbed from recordings.
+var secretParams = []string{"access_token", "appsecret_proof", "input_token", "client_secret", "fb_exchange_token", "code"}
+
+var secretJSON = regexp.MustCompile(`"(access_token|appsecret_proof|input_token|client_secret|fb_exchange_token)"\s*:\s*"[^"]*"`)
+
+const scrubbed = "SCRUBBED"
+
+func scrubURL(u *url.URL) string {
+	c := *u
+	query := c.Query()
+	for _, param := range secretParams {
+		if _, exists := query[param]; exists {
+			query.Set(param, scrubbed)
+		}
+	}
+	c.RawQuery = query.Encode()
+	return c.String()
+}
+
+func scrubBody(body string) string {
+	if query, err := url.ParseQuery(body); err == nil && len(query) > 0 && !secretJSON.MatchString(body) {
+		scrubbedQuery := false
+		for _, param := range secretParams {
+			if _, exists := query[param]; exists {
+				query.Set(param, scrubbed)
+				scrubbedQuery = true
+			}
+		}
+		if scrubbedQuery {
+			return query.Encode()
+		}
+	}
+	return secretJSON.ReplaceAllString(body, `"$1":"`+scrubbed+`"`)
+}
@@ -0,0 +1,57 @@
+package flanneltest
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/homemade/flannel"
+)
+
+func TestRecorder(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fixtures", "get.json")
+
+	r, err := NewRecorder(path, Record, s.Transport())
+	if err != nil {
+		t.Fatalf("failed to create recorder %v", err)
+	}
+	c, _ := r.Client()
+	if _, _, err := c.GetFundraiser(ctx, "secret-token", "1234"); err == nil {
+		t.Fatal("expected missing fundraiser error")
+	}
+	it := c.ListFundraisers(ctx, "secret-token")
+	for it.Next() {
+	}
+	if err := r.Save(); err != nil {
+		t.Fatalf("failed to save recording %v", err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(b), "secret-token") {
+		t.Errorf("expected access token to be scrubbed\n%s", b)
+	}
+
+	s.Close()
+	r, err = NewRecorder(path, Replay, nil)
+	if err != nil {
+		t.Fatalf("failed to create recorder %v", err)
+	}
+	c, _ = r.Client()
+	_, _, err = c.GetFundraiser(ctx, "other-token", "1234")
+	if code, subcode := flannel.ErrorCodes(err); code != 100 || subcode != 33 {
+		t.Errorf("expected replayed object not found error, got %v", err)
+	}
+	it = c.ListFundraisers(ctx, "other-token")
+	for it.Next() {
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("failed to replay list %v", err)
+	}
+	if _, _, err := c.GetFundraiser(ctx, "other-token", "5678"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected unrecorded API call to fail, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package flanneltest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/homemade/flannel"
+)
+
+// SignWebhook returns the X-Hub-Signature-256 header value of a webhook request body signed with appSecret.
+func SignWebhook(appSecret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookRequest returns a webhook request delivering payload, signed with appSecret as Facebook does,
+// for passing to the ServeHTTP method of a webhook handler.
+func NewWebhookRequest(appSecret string, payload flannel.WebhookPayload) *http.Request {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Hub-Signature-256", SignWebhook(appSecret, body))
+	return r
+}
+
+// NewDonationCreatedRequest returns a signed webhook request notifying that donation was made.
+func NewDonationCreatedRequest(appSecret string, donation flannel.WebhookDonation) *http.Request {
+	if donation.CreatedTime == "" {
+		donation.CreatedTime = formatTime(time.Now())
+	}
+	return NewWebhookRequest(appSecret, newPayload(donation.FundraiserID, "donations", donation))
+}
+
+// NewFundraiserUpdatedRequest returns a signed webhook request notifying that fundraiser was updated.
+func NewFundraiserUpdatedRequest(appSecret string, fundraiser flannel.WebhookFundraiser) *http.Request {
+	return NewWebhookRequest(appSecret, newPayload(fundraiser.ID, "fundraiser", fundraiser))
+}
+
+// NewWebhookVerificationRequest returns the request sent by Facebook to verify a webhook subscription.
+func NewWebhookVerificationRequest(verifyToken string, challenge string) *http.Request {
+	query := url.Values{"hub.mode": {"subscribe"}, "hub.verify_token": {verifyToken}, "hub.challenge": {challenge}}
+	return httptest.NewRequest(http.MethodGet, "/webhook?"+query.Encode(), nil)
+}
+
+func newPayload(id string, field string, value interface{}) flannel.WebhookPayload {
+	v, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return flannel.WebhookPayload{
+		Object: "fundraiser",
+		Entry: []flannel.WebhookEntry{{
+			ID:      id,
+			Time:    time.Now().Unix(),
+			Changes: []flannel.WebhookChange{{Field: field, Value: v}},
+		}},
+	}
+}
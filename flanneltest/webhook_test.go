@@ -0,0 +1,49 @@
+package flanneltest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/homemade/flannel"
+)
+
+func TestWebhookRequests(t *testing.T) {
+	var events []flannel.WebhookEvent
+	h := flannel.NewWebhookHandler(flannel.WebhookSettings{
+		AppSecret:   "secret",
+		VerifyToken: "verify",
+		OnEvent: func(ctx context.Context, event flannel.WebhookEvent) error {
+			events = append(events, event)
+			return nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, NewWebhookVerificationRequest("verify", "challenge"))
+	if w.Code != http.StatusOK || w.Body.String() != "challenge" {
+		t.Errorf("unexpected verification response %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, NewDonationCreatedRequest("secret", flannel.WebhookDonation{ID: "1", FundraiserID: "1234", Amount: 500, Currency: "GBP"}))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected donation response %d %s", w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, NewFundraiserUpdatedRequest("secret", flannel.WebhookFundraiser{ID: "1234", ChangedFields: []string{"name"}}))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected fundraiser response %d %s", w.Code, w.Body)
+	}
+	if len(events) != 2 || events[0].Type != flannel.DonationCreated || events[0].Donation.Amount != 500 ||
+		events[1].Type != flannel.FundraiserUpdated || events[1].Fundraiser.ID != "1234" {
+		t.Errorf("unexpected events %+v", events)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, NewDonationCreatedRequest("wrong", flannel.WebhookDonation{ID: "2", FundraiserID: "1234"}))
+	if w.Code != http.StatusForbidden || len(events) != 2 {
+		t.Errorf("expected request signed with the wrong secret to be rejected, got %d", w.Code)
+	}
+}
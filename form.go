@@ -0,0 +1,438 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+)
+
+// fundraiserForm collects the fields and files sent when creating a Facebook Fundraiser.
+type fundraiserForm struct {
+	// ctx and client are used to download files added by URL
+	ctx    context.Context
+	client APIClient
+
+	fields []formField
+	files  []formFile
+
+	// writers are options writing to the multipart.Writer directly, they are written to multipart bodies after the fields
+	writers []func(*multipart.Writer) error
+
+	// coverPhotoProgress is called as the cover photo is read
+	coverPhotoProgress func(transferred int64, total int64)
+
+	// coverPhotoTransforms are applied to the cover photo before it is validated
+	coverPhotoTransforms []CoverPhotoTransform
+
+	// coverPhotoContentType is the MIME type of the cover photo, if set
+	coverPhotoContentType string
+
+	// preopened is the content of files opened by preflight
+	preopened []io.ReadCloser
+}
+
+type formField struct {
+	name  string
+	value string
+}
+
+type formFile struct {
+	fieldName string
+	fileName  string
+
+	// errType classifies any error opening or reading the file
+	errType int
+
+	// open returns the file content, it is called each time the file is written to a request body
+	open func() (io.ReadCloser, error)
+
+	// replayable is set when open can be called more than once, so that failed requests can be retried
+	replayable bool
+
+	// preflight is set when the file should be opened, and so validated, before the request is sent
+	preflight bool
+
+	// contentType is the MIME type of the file, it is sniffed from the content if not set
+	contentType string
+}
+
+func (f *fundraiserForm) addField(name string, value string) {
+	f.fields = append(f.fields, formField{name: name, value: value})
+}
+
+func (f *fundraiserForm) addFile(fieldName string, fileName string, errType int, replayable bool, open func() (io.ReadCloser, error)) {
+	f.files = append(f.files, formFile{fieldName: fieldName, fileName: fileName, errType: errType, open: open, replayable: replayable})
+}
+
+// formWriters maps each multipart.Writer passed to options by CreateFundraiser to the form being built, so that the
+// options of this package add fields and files to the form rather than writing them. The form can then validate
+// cover photos before the request is sent, and write the body again when a failed API call is retried.
+var formWriters sync.Map
+
+type formWriter struct {
+	form *fundraiserForm
+
+	// written is set when the multipart.Writer is writing a body, as the options of this package have been applied
+	written bool
+}
+
+var errNotFundraiserForm = errors.New("fundraiser options must be passed to CreateFundraiser")
+
+// formOption returns an option calling add with the form its multipart.Writer belongs to.
+func formOption(add func(f *fundraiserForm) error) func(*multipart.Writer) error {
+	return func(w *multipart.Writer) error {
+		v, exists := formWriters.Load(w)
+		if !exists {
+			return errNotFundraiserForm
+		}
+		if fw := v.(formWriter); !fw.written {
+			return add(fw.form)
+		}
+		return nil
+	}
+}
+
+// applyOptions applies options to the form. Options writing to the multipart.Writer directly, rather than
+// using the options of this package, are called again to write each multipart body.
+func (f *fundraiserForm) applyOptions(options []func(*multipart.Writer) error) error {
+	for _, option := range options {
+		var ow optionWriter
+		w := multipart.NewWriter(&ow)
+		formWriters.Store(w, formWriter{form: f})
+		err := option(w)
+		formWriters.Delete(w)
+		if err != nil {
+			return err
+		}
+		if ow.written {
+			f.writers = append(f.writers, option)
+		}
+	}
+	return nil
+}
+
+// optionWriter records whether an option wrote to its multipart.Writer.
+type optionWriter struct {
+	written bool
+}
+
+func (w *optionWriter) Write(p []byte) (int, error) {
+	w.written = w.written || len(p) > 0
+	return len(p), nil
+}
+
+// fieldNames returns the names of the fields and files of the form.
+func (f *fundraiserForm) fieldNames() []string {
+	var names []string
+	for _, field := range f.fields {
+		names = append(names, field.name)
+	}
+	for _, file := range f.files {
+		names = append(names, file.fieldName)
+	}
+	return names
+}
+
+// preflight opens each file requiring validation before the request is sent, so that invalid files fail
+// without sending the request. The opened content is used by the first body written, and must be closed
+// with close if the request is not sent.
+func (f *fundraiserForm) preflight() error {
+	for i := range f.files {
+		file := &f.files[i]
+		if file.fieldName == "cover_photo" && len(f.coverPhotoTransforms) > 0 {
+			// the MIME type of the original cover photo may not match the transformed photo, so it is sniffed
+			file.contentType = ""
+		}
+		if !file.preflight {
+			continue
+		}
+		content, err := file.open()
+		if err != nil {
+			return file.error(err)
+		}
+		f.preopened = append(f.preopened, content)
+		open := file.open
+		var mu sync.Mutex
+		file.open = func() (io.ReadCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if content != nil {
+				c := content
+				content = nil
+				return c, nil
+			}
+			return open()
+		}
+	}
+	return nil
+}
+
+// close closes any content opened by preflight.
+func (f *fundraiserForm) close() {
+	for _, content := range f.preopened {
+		content.Close()
+	}
+	f.preopened = nil
+}
+
+// replayable returns true if the form can be written more than once.
+func (f *fundraiserForm) replayable() bool {
+	for _, file := range f.files {
+		if !file.replayable {
+			return false
+		}
+	}
+	return true
+}
+
+// readerSource returns an open func for content, which is replayable if content is an io.Seeker.
+func readerSource(content io.Reader) (open func() (io.ReadCloser, error), replayable bool) {
+	seeker, ok := content.(io.Seeker)
+	if !ok {
+		return func() (io.ReadCloser, error) {
+			return nopCloser{content}, nil
+		}, false
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return func() (io.ReadCloser, error) {
+			return nil, err
+		}, false
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return nopCloser{content}, nil
+	}, true
+}
+
+// nopCloser is ioutil.NopCloser, but keeps the Reader accessible to contentSize.
+type nopCloser struct {
+	io.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+// contentSize returns the number of bytes remaining to be read from content, or -1 if unknown.
+func contentSize(content io.Reader) int64 {
+	if n, ok := content.(nopCloser); ok {
+		content = n.Reader
+	}
+	switch c := content.(type) {
+	case interface{ Len() int }:
+		return int64(c.Len())
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := c.Stat(); err == nil && fi.Mode().IsRegular() {
+			if s, ok := content.(io.Seeker); ok {
+				if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+					return fi.Size() - offset
+				}
+			}
+			return fi.Size()
+		}
+	}
+	return -1
+}
+
+var errBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// multipartBodies writes the form as multipart bodies, each of which is written as it is read.
+// A new body is returned for each attempt at an API call, so that failed attempts can be retried.
+type multipartBodies struct {
+	form        *fundraiserForm
+	boundary    string
+	contentType string
+
+	mu      sync.Mutex
+	current *streamingBody
+	opened  int
+}
+
+func (f *fundraiserForm) multipartBodies() *multipartBodies {
+	w := multipart.NewWriter(ioutil.Discard)
+	if f.client.multipartBoundary != "" {
+		w.SetBoundary(f.client.multipartBoundary)
+	}
+	return &multipartBodies{form: f, boundary: w.Boundary(), contentType: w.FormDataContentType()}
+}
+
+// next returns a new body, it is suitable for use as http.Request GetBody.
+func (b *multipartBodies) next() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opened > 0 && !b.form.replayable() {
+		return nil, errBodyNotReplayable
+	}
+	if b.current != nil {
+		b.current.Close()
+	}
+	b.current = b.form.multipartBody(b.boundary)
+	b.opened++
+	return b.current, nil
+}
+
+// parts returns the parts written to the current body, it must only be called after err.
+func (b *multipartBodies) parts() []multipartPart {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current == nil {
+		return nil
+	}
+	return b.current.parts
+}
+
+// err closes the current body and returns any error opening or reading a file whilst writing it.
+func (b *multipartBodies) err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current == nil {
+		return nil
+	}
+	return b.current.err()
+}
+
+// multipartBody returns the form encoded as a multipart body, which is written as it is read.
+func (f *fundraiserForm) multipartBody(boundary string) *streamingBody {
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	w.SetBoundary(boundary)
+	b := &streamingBody{PipeReader: pr}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		err := f.writeMultipart(w, &b.parts)
+		switch err.(type) {
+		case flannelError, facebookError:
+			b.formErr = err
+		}
+		pw.CloseWithError(err)
+	}()
+	return b
+}
+
+// multipartPart describes a part written to a multipart body by its name and size, never its content.
+type multipartPart struct {
+	name string
+
+	// contentType is set for files
+	contentType string
+	size        int64
+}
+
+// writeMultipart writes the form to w, appending the parts written to parts.
+func (f *fundraiserForm) writeMultipart(w *multipart.Writer, parts *[]multipartPart) error {
+	for _, field := range f.fields {
+		if err := w.WriteField(field.name, field.value); err != nil {
+			return err
+		}
+		*parts = append(*parts, multipartPart{name: field.name, size: int64(len(field.value))})
+	}
+	if len(f.writers) > 0 {
+		formWriters.Store(w, formWriter{form: f, written: true})
+		defer formWriters.Delete(w)
+	}
+	for _, write := range f.writers {
+		if err := write(w); err != nil {
+			return err
+		}
+	}
+	for _, file := range f.files {
+		part, err := file.writePart(w)
+		if err != nil {
+			return err
+		}
+		*parts = append(*parts, part)
+	}
+	return w.Close()
+}
+
+func (file formFile) writePart(w *multipart.Writer) (multipartPart, error) {
+	written := multipartPart{name: file.fieldName}
+	content, err := file.open()
+	if err != nil {
+		return written, file.error(err)
+	}
+	defer content.Close()
+	var r io.Reader = content
+	contentType := file.contentType
+	if contentType == "" {
+		header := make([]byte, sniffLen)
+		n, err := io.ReadFull(content, header)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return written, file.error(err)
+		}
+		contentType = sniffContentType(header[:n])
+		r = io.MultiReader(bytes.NewReader(header[:n]), content)
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.fieldName), quoteEscaper.Replace(file.fileName)))
+	h.Set("Content-Type", contentType)
+	written.contentType = contentType
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return written, err
+	}
+	written.size, err = copyBuffer(part, r)
+	if err != nil {
+		if err == io.ErrClosedPipe {
+			return written, err
+		}
+		return written, file.error(err)
+	}
+	return written, nil
+}
+
+// sniffLen is the number of bytes used to detect the content type of a file.
+const sniffLen = 512
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// sniffContentType returns the MIME type of a file from its header, recognising all image formats accepted by Facebook.
+func sniffContentType(header []byte) string {
+	if info, ok := decodeImageHeader(header); ok {
+		return "image/" + info.format
+	}
+	return http.DetectContentType(header)
+}
+
+// error classifies an error opening or reading the file, Facebook errors from validating the file are returned as is.
+func (file formFile) error(err error) error {
+	if _, ok := err.(facebookError); ok {
+		return err
+	}
+	return flannelError{file.errType, err}
+}
+
+// streamingBody is a request body written by a goroutine as it is read.
+type streamingBody struct {
+	*io.PipeReader
+
+	wg      sync.WaitGroup
+	formErr error
+
+	// parts are written by the goroutine, they are read once it has exited
+	parts []multipartPart
+}
+
+// Close stops the goroutine writing the body and waits for it to exit.
+func (b *streamingBody) Close() error {
+	err := b.PipeReader.Close()
+	b.wg.Wait()
+	return err
+}
+
+// err closes the body and returns any error opening or reading a file whilst writing it.
+func (b *streamingBody) err() error {
+	b.Close()
+	return b.formErr
+}
@@ -0,0 +1,259 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateFundraiserStreamsMultipartBody(t *testing.T) {
+	photo := testPhoto(1024 * 1024)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 {
+			t.Errorf("expected a streamed body of unknown length, got %d", r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Errorf("failed to parse multipart form %v", err)
+		}
+		if got := r.FormValue("external_event_name"); got != "Marathon" {
+			t.Errorf("unexpected external_event_name %q", got)
+		}
+		file, header, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Fatalf("missing cover photo %v", err)
+		}
+		content, _ := ioutil.ReadAll(file)
+		if header.Filename != "photo.jpg" || !bytes.Equal(content, photo) {
+			t.Errorf("unexpected cover photo %s of %d bytes", header.Filename, len(content))
+		}
+		fundraiserCreated(w, r)
+	}))
+
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Test"},
+		WithFundraiserField("external_event_name", "Marathon"),
+		WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(photo)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoTooLarge(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		fundraiserCreated(w, r)
+	}))
+	large := strings.NewReader(strings.Repeat("x", FundraiserCoverPhotoImageMaxSize+1))
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("large.jpg", large))
+	if !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error, got %v", err)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoProgress(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		fundraiserCreated(w, r)
+	}))
+	photo := testPhoto(256 * 1024)
+	var transferred, total int64
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{},
+		WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(photo)),
+		WithFundraiserCoverPhotoProgress(func(n int64, size int64) {
+			if n < transferred {
+				t.Errorf("expected progress to increase, got %d after %d", n, transferred)
+			}
+			transferred, total = n, size
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if transferred != int64(len(photo)) || total != int64(len(photo)) {
+		t.Errorf("expected progress to reach %d of %d, got %d of %d", len(photo), len(photo), transferred, total)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoURL(t *testing.T) {
+	photo := testPhoto(64 * 1024)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Write(photo)
+		case "/slow.jpg":
+			<-r.Context().Done()
+		default:
+			file, _, err := r.FormFile("cover_photo")
+			if err != nil {
+				// the upload is aborted when the download is cancelled
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if content, _ := ioutil.ReadAll(file); !bytes.Equal(content, photo) {
+				t.Errorf("unexpected cover photo of %d bytes", len(content))
+			}
+			fundraiserCreated(w, r)
+		}
+	}))
+
+	// the test client resolves every host to the test server, so the photo is only found using the client's transport
+	u, _ := url.Parse("https://photos.example.com/photo.jpg")
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("photo.jpg", *u)); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	u, _ = url.Parse("https://photos.example.com/slow.jpg")
+	start := time.Now()
+	_, _, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("slow.jpg", *u))
+	if err == nil {
+		t.Fatal("expected cancelled cover photo download to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected cover photo download to be cancelled, took %v", elapsed)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoContentType(t *testing.T) {
+	var contentType string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Errorf("missing cover photo %v", err)
+			return
+		}
+		contentType = header.Header.Get("Content-Type")
+		fundraiserCreated(w, r)
+	}))
+
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo", bytes.NewReader(testPhoto(0)))); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if contentType != "image/png" {
+		t.Errorf("expected sniffed content type image/png, got %s", contentType)
+	}
+
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{},
+		WithFundraiserCoverPhotoContentType("image/x-png"),
+		WithFundraiserCoverPhotoImage("photo", bytes.NewReader(testPhoto(0))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if contentType != "image/x-png" {
+		t.Errorf("expected explicit content type image/x-png, got %s", contentType)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoFile(t *testing.T) {
+	photo := testPhoto(1024)
+	var fileName, contentType string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Errorf("missing cover photo %v", err)
+			return
+		}
+		if content, _ := ioutil.ReadAll(file); !bytes.Equal(content, photo) {
+			t.Errorf("unexpected cover photo of %d bytes", len(content))
+		}
+		fileName, contentType = header.Filename, header.Header.Get("Content-Type")
+		fundraiserCreated(w, r)
+	}))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.png")
+	if err := ioutil.WriteFile(path, photo, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoFile(path)); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if fileName != "photo.png" || contentType != "image/png" {
+		t.Errorf("unexpected cover photo %s %s", fileName, contentType)
+	}
+
+	large := filepath.Join(dir, "large.png")
+	if err := ioutil.WriteFile(large, testPhoto(FundraiserCoverPhotoImageMaxSize+1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoFile(large)); !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error for large file, got %v", err)
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoFile(filepath.Join(dir, "missing.png"))); !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error for missing file, got %v", err)
+	}
+}
+
+func TestCoverPhotoMaxSize(t *testing.T) {
+	var written int64
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large.jpg" {
+			w.Header().Set("Content-Length", strconv.Itoa(50*1024*1024))
+			chunk := make([]byte, 64*1024)
+			for i := 0; i < 800; i++ {
+				n, err := w.Write(chunk)
+				atomic.AddInt64(&written, int64(n))
+				if err != nil {
+					return
+				}
+			}
+			return
+		}
+		ioutil.ReadAll(r.Body)
+		fundraiserCreated(w, r)
+	}), WithCoverPhotoMaxSize(1024))
+
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.png", bytes.NewReader(testPhoto(2048))))
+	if !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error, got %v", err)
+	}
+
+	u, _ := url.Parse("https://photos.example.com/large.jpg")
+	_, _, err = c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoURL("large.jpg", *u))
+	if !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected cover photo error, got %v", err)
+	}
+	if n := atomic.LoadInt64(&written); n > 10*1024*1024 {
+		t.Errorf("expected large cover photo not to be downloaded, %d bytes were written", n)
+	}
+}
+
+func TestCreateFundraiserMultipartWriter(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Errorf("failed to parse multipart form %v", err)
+		}
+		for name, want := range map[string]string{"external_event_name": "Marathon", "external_event_uri": "https://example.com", "external_id": "1"} {
+			if got := r.MultipartForm.Value[name]; len(got) != 1 || got[0] != want {
+				t.Errorf("unexpected %s %q", name, got)
+			}
+		}
+		fundraiserCreated(w, r)
+	}), WithRequestEncoding(RequestEncodingForm))
+
+	// an option writing to the multipart.Writer directly, which also uses an option of this package
+	custom := func(w *multipart.Writer) error {
+		if err := WithFundraiserField("external_event_uri", "https://example.com")(w); err != nil {
+			return err
+		}
+		return w.WriteField("external_event_name", "Marathon")
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Test", ExternalID: "1"}, custom); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+
+	if err := WithFundraiserField("external_event_name", "Marathon")(multipart.NewWriter(ioutil.Discard)); err == nil {
+		t.Error("expected error using option outside CreateFundraiser")
+	}
+}
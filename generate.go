@@ -0,0 +1,20 @@
+package flannel
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//go:generate go run ./internal/flannelgen -in endpoints.json -out endpoints_gen.go -schema schema.json
+
+// decodeResult decodes the result of an API call into v, the typed result of a generated endpoint binding.
+func decodeResult(result map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error decoding result %v", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("error decoding result %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGeneratedBindings(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if got := r.URL.Query().Get("fields"); got != "id,name,description,goal_amount,amount_raised,currency,end_time,external_id,uri" {
+				t.Errorf("unexpected fields %q", got)
+			}
+			fmt.Fprint(w, `{"id":"1234","name":"Marathon","goal_amount":10000,"amount_raised":2500}`)
+		case "POST":
+			if got := r.FormValue("goal_amount"); got != "20000" {
+				t.Errorf("unexpected goal_amount %q", got)
+			}
+			fmt.Fprint(w, `{"success":true}`)
+		}
+	}))
+	ctx := context.Background()
+	details, err := c.GetFundraiserDetails(ctx, "token", GetFundraiserDetailsParams{FundraiserID: "1234"})
+	if err != nil {
+		t.Fatalf("failed to get fundraiser details %v", err)
+	}
+	if details.Name != "Marathon" || details.AmountRaised != 2500 {
+		t.Errorf("unexpected fundraiser details %+v", details)
+	}
+	result, err := c.SetFundraiserGoal(ctx, "token", SetFundraiserGoalParams{FundraiserID: "1234", GoalAmount: 20000})
+	if err != nil || !result.Success {
+		t.Errorf("failed to set fundraiser goal %+v %v", result, err)
+	}
+	if _, err := c.GetFundraiserDetails(ctx, "token", GetFundraiserDetailsParams{}); err == nil {
+		t.Error("expected missing fundraiser_id error")
+	}
+}
@@ -0,0 +1,87 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+)
+
+// GraphDebugMessage is a message returned by Facebook in debug mode, such as a warning that a field is deprecated.
+type GraphDebugMessage struct {
+	Type    string
+	Message string
+	Link    string
+}
+
+// GraphDebugInfo is the debug information returned by Facebook for an API call.
+type GraphDebugInfo struct {
+	Method   string
+	Endpoint string
+
+	// FBDebug is the X-FB-Debug header, which identifies the API call when reporting bugs to Facebook.
+	FBDebug string
+
+	// Messages are the __debug__ messages of the response.
+	Messages []GraphDebugMessage
+}
+
+// WithGraphDebug appends debug=all to every API call so that Facebook returns its own debug messages,
+// such as warnings about deprecated fields. The debug information of each API call returning any messages
+// is passed to report, or logged as a warning if report is nil.
+// See https://developers.facebook.com/docs/graph-api/guides/debugging/
+func WithGraphDebug(report func(ctx context.Context, info GraphDebugInfo)) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.graphDebug = &graphDebug{report: report}
+		return nil
+	}
+}
+
+type graphDebug struct {
+	report func(ctx context.Context, info GraphDebugInfo)
+}
+
+// addGraphDebug sets debug=all on req, if enabled.
+func (c APIClient) addGraphDebug(req *http.Request) {
+	if c.graphDebug == nil {
+		return
+	}
+	q := req.URL.Query()
+	q.Set("debug", "all")
+	req.URL.RawQuery = q.Encode()
+}
+
+// reportGraphDebug reports the debug messages in result, if enabled.
+func (c APIClient) reportGraphDebug(req *http.Request, res *http.Response, result map[string]interface{}) {
+	if c.graphDebug == nil {
+		return
+	}
+	info := GraphDebugInfo{
+		Method:   req.Method,
+		Endpoint: statsEndpoint(req),
+		FBDebug:  res.Header.Get("X-FB-Debug"),
+	}
+	if debug, ok := result["__debug__"].(map[string]interface{}); ok {
+		messages, _ := debug["messages"].([]interface{})
+		for _, m := range messages {
+			if m, ok := m.(map[string]interface{}); ok {
+				message := GraphDebugMessage{}
+				message.Type, _ = m["type"].(string)
+				message.Message, _ = m["message"].(string)
+				message.Link, _ = m["link"].(string)
+				info.Messages = append(info.Messages, message)
+			}
+		}
+	}
+	if len(info.Messages) == 0 {
+		return
+	}
+	if c.graphDebug.report != nil {
+		c.graphDebug.report(req.Context(), info)
+		return
+	}
+	if c.logger != nil {
+		for _, m := range info.Messages {
+			c.logf(req.Context(), "facebook api %s request to %s returned debug %s %s %s (x-fb-debug %s)\n",
+				req.Method, redactString(req.URL.String()), m.Type, m.Message, m.Link, info.FBDebug)
+		}
+	}
+}
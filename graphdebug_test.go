@@ -0,0 +1,29 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGraphDebug(t *testing.T) {
+	var infos []GraphDebugInfo
+	report := func(ctx context.Context, info GraphDebugInfo) {
+		infos = append(infos, info)
+	}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") != "all" {
+			t.Errorf("expected debug=all, got %s", r.URL.RawQuery)
+		}
+		w.Header().Set("X-FB-Debug", "abc")
+		w.Write([]byte(`{"id":"1234","__debug__":{"messages":[{"type":"warning","message":"field is deprecated","link":"https://developers.facebook.com"}]}}`))
+	}), WithGraphDebug(report))
+
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1234", "name"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if len(infos) != 1 || infos[0].FBDebug != "abc" || len(infos[0].Messages) != 1 ||
+		infos[0].Messages[0].Type != "warning" || infos[0].Messages[0].Message != "field is deprecated" {
+		t.Errorf("unexpected debug info %+v", infos)
+	}
+}
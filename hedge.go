@@ -0,0 +1,168 @@
+package flannel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgingSettings configures hedged requests for idempotent (GET) API calls.
+type HedgingSettings struct {
+
+	// Percentile (0 to 1) of recent API call latencies after which a hedged request is sent, defaults to 0.95.
+	Percentile float64
+
+	// InitialDelay is used until MinSamples latencies have been observed, defaults to 1 second.
+	InitialDelay time.Duration
+
+	// MinDelay is the shortest delay before a hedged request is sent, defaults to 50 milliseconds.
+	MinDelay time.Duration
+
+	// MinSamples is the number of latencies required before the percentile is used, defaults to 20.
+	MinSamples int
+
+	// WindowSize is the number of most recent latencies tracked, defaults to 100.
+	WindowSize int
+}
+
+// WithHedgedReads sends a second, hedged, request for idempotent API calls which have not responded within
+// the configured percentile of recent latencies. The first response received is used and the other request is cancelled.
+func WithHedgedReads(settings HedgingSettings) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if settings.Percentile <= 0 || settings.Percentile > 1 {
+			settings.Percentile = 0.95
+		}
+		if settings.InitialDelay <= 0 {
+			settings.InitialDelay = time.Second
+		}
+		if settings.MinDelay <= 0 {
+			settings.MinDelay = 50 * time.Millisecond
+		}
+		if settings.MinSamples <= 0 {
+			settings.MinSamples = 20
+		}
+		if settings.WindowSize < settings.MinSamples {
+			settings.WindowSize = 100
+			if settings.WindowSize < settings.MinSamples {
+				settings.WindowSize = settings.MinSamples
+			}
+		}
+		c.hedger = &hedger{settings: settings, latencies: make([]time.Duration, 0, settings.WindowSize)}
+		return nil
+	}
+}
+
+type hedger struct {
+	settings HedgingSettings
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// delay returns how long to wait for a response before sending a hedged request.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	if len(h.latencies) < h.settings.MinSamples {
+		h.mu.Unlock()
+		return h.settings.InitialDelay
+	}
+	sorted := append([]time.Duration(nil), h.latencies...)
+	h.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d := sorted[int(h.settings.Percentile*float64(len(sorted)-1))]
+	if d < h.settings.MinDelay {
+		return h.settings.MinDelay
+	}
+	return d
+}
+
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.latencies) < h.settings.WindowSize {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % h.settings.WindowSize
+}
+
+type hedgedResult struct {
+	attempt int
+	res     *http.Response
+	err     error
+	cancel  context.CancelFunc
+	latency time.Duration
+}
+
+// do sends req using send, sending a second request if the first has not responded in time.
+func (h *hedger) do(ctx context.Context, req *http.Request, send func(context.Context, *http.Request) (*http.Response, error)) (*http.Response, error) {
+	results := make(chan hedgedResult, 2)
+	var cancels []context.CancelFunc
+	attempt := func() {
+		actx, cancel := context.WithCancel(ctx)
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			start := time.Now()
+			res, err := send(actx, req.Clone(actx))
+			results <- hedgedResult{attempt: i, res: res, err: err, cancel: cancel, latency: time.Since(start)}
+		}()
+	}
+	attempt()
+	pending := 1
+
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			attempt()
+			pending++
+		case r := <-results:
+			pending--
+			if r.err != nil {
+				r.cancel()
+				if pending > 0 {
+					// the hedged request may still succeed
+					continue
+				}
+				return nil, r.err
+			}
+			if pending > 0 {
+				// cancel and discard the slower request
+				for i, cancel := range cancels {
+					if i != r.attempt {
+						cancel()
+					}
+				}
+				go func() {
+					slower := <-results
+					if slower.res != nil {
+						drainAndClose(slower.res.Body)
+					}
+				}()
+			}
+			h.observe(r.latency)
+			r.res.Body = &cancelOnClose{ReadCloser: r.res.Body, cancel: r.cancel}
+			return r.res, nil
+		}
+	}
+}
+
+// cancelOnClose cancels the context of a hedged request once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,35 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgedReads(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// the first request hangs until it is cancelled
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1234","amount_raised":500}`))
+	}), WithHedgedReads(HedgingSettings{InitialDelay: 20 * time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	status, result, err := c.GetFundraiser(ctx, "token", "1234", "id", "amount_raised")
+	if err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if status != http.StatusOK || result["id"] != "1234" {
+		t.Errorf("unexpected result %d %v", status, result)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected a hedged request to be sent, got %d requests", n)
+	}
+}
@@ -0,0 +1,55 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient creates an APIClient whose API calls, to any host, are all served by handler.
+func newTestClient(t testing.TB, handler http.Handler, options ...func(*APIClient) error) APIClient {
+	t.Helper()
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+	return newTestServerClient(t, srv, options...)
+}
+
+// newTestServerClient creates an APIClient whose API calls, to any host, are all served by srv.
+func newTestServerClient(t testing.TB, srv *httptest.Server, options ...func(*APIClient) error) APIClient {
+	t.Helper()
+	withTestServer := func(c *APIClient) error {
+		tr := srv.Client().Transport.(*http.Transport).Clone()
+		tr.TLSClientConfig.InsecureSkipVerify = true
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		}
+		c.httpClient.Transport = tr
+		return nil
+	}
+	c, err := CreateAPIClient(append([]func(*APIClient) error{withTestServer}, options...)...)
+	if err != nil {
+		t.Fatalf("failed to create api client %v", err)
+	}
+	return c
+}
+
+// fundraiserCreated responds as the Graph API does after successfully creating a fundraiser.
+var fundraiserCreated = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":"1234"}`))
+})
+
+// testPhoto returns a valid PNG image padded to size bytes, for use as a cover photo.
+func testPhoto(size int) []byte {
+	var b bytes.Buffer
+	png.Encode(&b, image.NewGray(image.Rect(0, 0, 16, 16)))
+	if b.Len() < size {
+		b.Write(make([]byte, size-b.Len()))
+	}
+	return b.Bytes()
+}
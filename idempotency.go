@@ -0,0 +1,89 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// WithIdempotentCreation prevents CreateFundraiser creating duplicate fundraisers when
+// retried with the same ExternalID, for example after a timeout.
+//
+// Successful creations are recorded in cache, keyed by ExternalID, and subsequent calls return the
+// recorded result instead of creating another fundraiser. If checkExisting is set, fundraisers not
+// found in the cache are also looked up amongst those already created by the user before creating one.
+// Concurrent calls with the same ExternalID through the same APIClient are serialized.
+func WithIdempotentCreation(cache Cache, checkExisting bool) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.idempotency = &idempotency{cache: cache, checkExisting: checkExisting, locks: make(map[string]*idempotencyLock)}
+		return nil
+	}
+}
+
+type idempotency struct {
+	cache         Cache
+	checkExisting bool
+
+	mu    sync.Mutex
+	locks map[string]*idempotencyLock
+}
+
+type idempotencyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock serializes creation of fundraisers with externalID, returning a func to unlock.
+func (i *idempotency) lock(externalID string) func() {
+	i.mu.Lock()
+	l, exists := i.locks[externalID]
+	if !exists {
+		l = &idempotencyLock{}
+		i.locks[externalID] = l
+	}
+	l.refs++
+	i.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		i.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(i.locks, externalID)
+		}
+		i.mu.Unlock()
+	}
+}
+
+func idempotencyKey(externalID string) string {
+	return "flannel:fundraiser:external_id:" + externalID
+}
+
+// existingFundraiser returns a fundraiser previously created with the ExternalID of params, if one exists.
+func (c APIClient) existingFundraiser(ctx context.Context, params CreateFundraiserParams) (result map[string]interface{}, found bool, err error) {
+	if v, hit := c.idempotency.cache.Get(ctx, idempotencyKey(params.ExternalID)); hit {
+		if json.Unmarshal(v, &result) == nil {
+			return result, true, nil
+		}
+	}
+	if !c.idempotency.checkExisting {
+		return nil, false, nil
+	}
+	it := c.ListFundraisers(ctx, params.AccessToken, "id", "external_id")
+	defer it.Close()
+	for it.Next() {
+		if it.Item()["external_id"] == params.ExternalID {
+			result = map[string]interface{}{"id": it.Item()["id"]}
+			c.recordFundraiser(ctx, params, result)
+			return result, true, nil
+		}
+	}
+	return nil, false, it.Err()
+}
+
+// recordFundraiser records a successfully created fundraiser.
+func (c APIClient) recordFundraiser(ctx context.Context, params CreateFundraiserParams, result map[string]interface{}) {
+	if v, err := json.Marshal(result); err == nil {
+		c.idempotency.cache.Set(ctx, idempotencyKey(params.ExternalID), v, 0)
+	}
+}
@@ -0,0 +1,33 @@
+package flannel
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotentCreation(t *testing.T) {
+	created := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data":[{"id":"fb-existing","external_id":"existing"}]}`)
+			return
+		}
+		created++
+		fmt.Fprintf(w, `{"id":"fb-%s"}`, r.FormValue("external_id"))
+	}), WithIdempotentCreation(NewMemoryCache(100), true))
+
+	for i := 0; i < 2; i++ {
+		_, result, err := c.CreateFundraiser(CreateFundraiserParams{ExternalID: "new"})
+		if err != nil || result["id"] != "fb-new" {
+			t.Fatalf("unexpected result creating fundraiser %v %v", result, err)
+		}
+	}
+	_, result, err := c.CreateFundraiser(CreateFundraiserParams{ExternalID: "existing"})
+	if err != nil || result["id"] != "fb-existing" {
+		t.Fatalf("expected existing fundraiser to be returned, got %v %v", result, err)
+	}
+	if created != 1 {
+		t.Errorf("expected a single fundraiser to be created, got %d", created)
+	}
+}
@@ -0,0 +1,197 @@
+package flannel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Facebook cover photo limits.
+// See https://developers.facebook.com/docs/graph-api/reference/fundraiser_for_story/
+const (
+	FundraiserCoverPhotoMaxDimension = 30000
+	FundraiserCoverPhotoMaxPixels    = 80000000
+)
+
+// Facebook error subcodes returned for invalid cover photos.
+const (
+	coverPhotoFormatSubcode    = 1366046
+	coverPhotoDimensionSubcode = 1366055
+)
+
+const (
+	coverPhotoFormatMessage    = "Your photos couldn't be uploaded. Photos should be smaller than 4 MB and saved as JPG, PNG, GIF, TIFF, HEIF or WebP files."
+	coverPhotoDimensionMessage = "Your photo couldn't be uploaded due to restrictions on image dimensions. Photos should be less than 30,000 pixels in any dimension, and less than 80,000,000 pixels in total size."
+)
+
+// imageHeaderSize is the number of bytes read to identify an image and its dimensions.
+const imageHeaderSize = 64 * 1024
+
+// imageInfo describes an image identified from its header, width and height are zero if unknown.
+type imageInfo struct {
+	format string
+	width  int
+	height int
+}
+
+// decodeImageHeader identifies the format and dimensions of an image accepted by Facebook from its header.
+func decodeImageHeader(header []byte) (info imageInfo, ok bool) {
+	r := bytes.NewReader(header)
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		info.format = "jpeg"
+		if config, err := jpeg.DecodeConfig(r); err == nil {
+			info.width, info.height = config.Width, config.Height
+		}
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		info.format = "png"
+		if config, err := png.DecodeConfig(r); err == nil {
+			info.width, info.height = config.Width, config.Height
+		}
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		info.format = "gif"
+		if config, err := gif.DecodeConfig(r); err == nil {
+			info.width, info.height = config.Width, config.Height
+		}
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		info.format = "tiff"
+		info.width, info.height = tiffDimensions(header)
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		info.format = "webp"
+		info.width, info.height = webpDimensions(header)
+	case isHEIF(header):
+		info.format = "heif"
+		info.width, info.height = heifDimensions(header)
+	default:
+		return info, false
+	}
+	return info, true
+}
+
+// tiffDimensions reads the ImageWidth and ImageLength tags of the first IFD.
+func tiffDimensions(header []byte) (width int, height int) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if header[0] == 'M' {
+		order = binary.BigEndian
+	}
+	if len(header) < 8 {
+		return 0, 0
+	}
+	offset := int(order.Uint32(header[4:8]))
+	if offset+2 > len(header) {
+		return 0, 0
+	}
+	entries := int(order.Uint16(header[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(header) {
+			break
+		}
+		tag := order.Uint16(header[entry:])
+		var value int
+		switch order.Uint16(header[entry+2:]) {
+		case 3: // SHORT
+			value = int(order.Uint16(header[entry+8:]))
+		case 4: // LONG
+			value = int(order.Uint32(header[entry+8:]))
+		default:
+			continue
+		}
+		switch tag {
+		case 256:
+			width = value
+		case 257:
+			height = value
+		}
+	}
+	return width, height
+}
+
+// webpDimensions reads the canvas size from the first chunk of a WebP image.
+// See https://developers.google.com/speed/webp/docs/riff_container
+func webpDimensions(header []byte) (width int, height int) {
+	if len(header) < 30 {
+		return 0, 0
+	}
+	switch string(header[12:16]) {
+	case "VP8 ":
+		if header[23] == 0x9d && header[24] == 0x01 && header[25] == 0x2a {
+			return int(binary.LittleEndian.Uint16(header[26:]) & 0x3fff), int(binary.LittleEndian.Uint16(header[28:]) & 0x3fff)
+		}
+	case "VP8L":
+		if header[20] == 0x2f {
+			bits := binary.LittleEndian.Uint32(header[21:])
+			return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1
+		}
+	case "VP8X":
+		return int(uint32(header[24])|uint32(header[25])<<8|uint32(header[26])<<16) + 1,
+			int(uint32(header[27])|uint32(header[28])<<8|uint32(header[29])<<16) + 1
+	}
+	return 0, 0
+}
+
+// isHEIF returns true if the ftyp box of header has a HEIF brand.
+func isHEIF(header []byte) bool {
+	if len(header) < 16 || string(header[4:8]) != "ftyp" {
+		return false
+	}
+	size := int(binary.BigEndian.Uint32(header))
+	if size < 16 || size > len(header) {
+		size = 16
+	}
+	for i := 8; i+4 <= size; i += 4 {
+		if i == 12 {
+			// minor version
+			continue
+		}
+		switch string(header[i : i+4]) {
+		case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+			return true
+		}
+	}
+	return false
+}
+
+// heifDimensions reads the first image spatial extents (ispe) property of a HEIF image.
+func heifDimensions(header []byte) (width int, height int) {
+	i := bytes.Index(header, []byte("ispe"))
+	if i < 0 || i+16 > len(header) {
+		return 0, 0
+	}
+	// skip the box version and flags
+	return int(binary.BigEndian.Uint32(header[i+8:])), int(binary.BigEndian.Uint32(header[i+12:]))
+}
+
+// validateCoverPhoto checks the header of a cover photo against the limits of Facebook, returning the
+// same error Facebook would so that doomed uploads fail before they are sent.
+func validateCoverPhoto(header []byte) error {
+	info, ok := decodeImageHeader(header)
+	if !ok {
+		return coverPhotoError(coverPhotoFormatSubcode, coverPhotoFormatMessage)
+	}
+	if info.width > FundraiserCoverPhotoMaxDimension || info.height > FundraiserCoverPhotoMaxDimension ||
+		info.width*info.height > FundraiserCoverPhotoMaxPixels {
+		return coverPhotoError(coverPhotoDimensionSubcode, coverPhotoDimensionMessage)
+	}
+	return nil
+}
+
+// coverPhotoError returns the Facebook error for an invalid cover photo, it is detected by IsErrorWithFundraiserCoverPhoto.
+func coverPhotoError(subcode int, message string) error {
+	return facebookError{
+		Endpoint: CreateFundraiserEndpoint,
+		Status:   http.StatusBadRequest,
+		ErrorMap: map[string]interface{}{
+			"message":          message,
+			"type":             "OAuthException",
+			"code":             float64(100),
+			"error_subcode":    float64(subcode),
+			"is_transient":     false,
+			"error_user_title": "Photo Upload Failed",
+			"error_user_msg":   message,
+		},
+	}
+}
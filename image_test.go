@@ -0,0 +1,75 @@
+package flannel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net/http"
+	"testing"
+)
+
+// pngHeader returns the header of a PNG image of any dimensions.
+func pngHeader(width int, height int) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	chunk := make([]byte, 17)
+	copy(chunk, "IHDR")
+	binary.BigEndian.PutUint32(chunk[4:], uint32(width))
+	binary.BigEndian.PutUint32(chunk[8:], uint32(height))
+	chunk[12] = 8 // bit depth
+	chunk[13] = 2 // truecolor
+	binary.Write(&b, binary.BigEndian, uint32(13))
+	b.Write(chunk)
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return b.Bytes()
+}
+
+func TestDecodeImageHeader(t *testing.T) {
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x02\x00" +
+		"\x00\x01\x03\x00\x01\x00\x00\x00\x40\x01\x00\x00" + // ImageWidth 320
+		"\x01\x01\x04\x00\x01\x00\x00\x00\xf0\x00\x00\x00") // ImageLength 240
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x3f\x01\x00\xef\x00\x00")
+	heif := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic" +
+		"\x00\x00\x00\x14ispe\x00\x00\x00\x00\x00\x00\x01\x40\x00\x00\x00\xf0")
+
+	for name, test := range map[string]struct {
+		header []byte
+		info   imageInfo
+	}{
+		"png":  {pngHeader(320, 240), imageInfo{"png", 320, 240}},
+		"tiff": {tiff, imageInfo{"tiff", 320, 240}},
+		"webp": {webp, imageInfo{"webp", 320, 240}},
+		"heif": {heif, imageInfo{"heif", 320, 240}},
+	} {
+		info, ok := decodeImageHeader(test.header)
+		if !ok || info != test.info {
+			t.Errorf("expected %s header to be %+v, got %+v", name, test.info, info)
+		}
+	}
+
+	if _, ok := decodeImageHeader([]byte("<svg></svg>")); ok {
+		t.Error("expected svg to be unsupported")
+	}
+}
+
+func TestCreateFundraiserValidatesCoverPhoto(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected invalid cover photo not to be sent")
+	}))
+	for name, test := range map[string]struct {
+		photo   []byte
+		subcode int
+	}{
+		"html":            {[]byte("<html>Not Found</html>"), 1366046},
+		"wide":            {pngHeader(30001, 1), 1366055},
+		"too many pixels": {pngHeader(10000, 10000), 1366055},
+	} {
+		_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo", bytes.NewReader(test.photo)))
+		if !IsErrorWithFundraiserCoverPhoto(err) {
+			t.Errorf("expected %s cover photo error, got %v", name, err)
+		}
+		if code, subcode := ErrorCodes(err); code != 100 || subcode != test.subcode {
+			t.Errorf("expected %s error 100 %d, got %d %d", name, test.subcode, code, subcode)
+		}
+	}
+}
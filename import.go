@@ -0,0 +1,325 @@
+package flannel
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ImportColumns maps the header names of a CSV file imported with ImportFundraisers to the fundraiser parameters.
+type ImportColumns struct {
+	AccessToken   string
+	CharityID     string
+	Title         string
+	Description   string
+	Goal          string
+	Currency      string
+	EndTime       string
+	ExternalID    string
+	CoverPhotoURL string
+
+	// Fields maps optional fundraiser fields, as set with WithFundraiserField, to header names.
+	Fields map[string]string
+}
+
+// DefaultImportColumns are the header names used by ImportFundraisers if none are set.
+var DefaultImportColumns = ImportColumns{
+	AccessToken:   "access_token",
+	CharityID:     "charity_id",
+	Title:         "title",
+	Description:   "description",
+	Goal:          "goal",
+	Currency:      "currency",
+	EndTime:       "end_time",
+	ExternalID:    "external_id",
+	CoverPhotoURL: "cover_photo_url",
+	Fields: map[string]string{
+		"external_fundraiser_uri":   "external_fundraiser_uri",
+		"external_event_name":       "external_event_name",
+		"external_event_uri":        "external_event_uri",
+		"external_event_start_time": "external_event_start_time",
+	},
+}
+
+// ImportSettings configures ImportFundraisers.
+type ImportSettings struct {
+
+	// AccessToken is used to create fundraisers for rows without an access token column.
+	AccessToken string
+
+	// Columns maps header names to fundraiser parameters, defaults to DefaultImportColumns.
+	Columns *ImportColumns
+
+	// Concurrency is the number of fundraisers created at the same time, defaults to 1.
+	Concurrency int
+}
+
+// ImportSummary counts the rows imported by ImportFundraisers.
+type ImportSummary struct {
+	Rows    int
+	Created int
+	Invalid int
+	Failed  int
+}
+
+// Import row statuses, as written to the results CSV.
+const (
+	ImportCreated = "created"
+	ImportInvalid = "invalid"
+	ImportFailed  = "failed"
+)
+
+type importRow struct {
+	seq  int
+	line int
+	job  FundraiserJob
+	err  error
+}
+
+type importResult struct {
+	seq        int
+	line       int
+	externalID string
+	status     string
+	id         string
+	err        error
+}
+
+// ImportFundraisers reads a CSV file of fundraisers from r, with a header row, validates each row and creates a
+// fundraiser for each valid row, making up to Concurrency API calls at the same time. A results CSV is written to w,
+// with the line, external_id, status, id and error of each row in the order read, so that failed rows can be fixed
+// and imported again. Only errors reading or writing the CSV files are returned.
+func (c APIClient) ImportFundraisers(ctx context.Context, r io.Reader, w io.Writer, settings ImportSettings) (ImportSummary, error) {
+	ctx = backgroundContext(ctx)
+	columns := DefaultImportColumns
+	if settings.Columns != nil {
+		columns = *settings.Columns
+	}
+	if settings.Concurrency < 1 {
+		settings.Concurrency = 1
+	}
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return ImportSummary{}, fmt.Errorf("error reading header %v", err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{columns.CharityID, columns.Title, columns.Goal, columns.Currency, columns.EndTime} {
+		if _, exists := index[name]; !exists {
+			return ImportSummary{}, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "external_id", "status", "id", "error"}); err != nil {
+		return ImportSummary{}, err
+	}
+
+	var summary ImportSummary
+	rows := make(chan importRow)
+	results := make(chan importResult)
+	var wg sync.WaitGroup
+	for i := 0; i < settings.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				results <- c.importRow(ctx, row)
+			}
+		}()
+	}
+
+	// read rows whilst results are written
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		for seq := 0; ; seq++ {
+			record, err := cr.Read()
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			var pe *csv.ParseError
+			if err != nil && (!errors.As(err, &pe) || pe.Err != csv.ErrFieldCount) {
+				readErr <- err
+				return
+			}
+			// FieldPos panics for records without fields
+			line := 0
+			if len(record) > 0 {
+				line, _ = cr.FieldPos(0)
+			} else if pe != nil {
+				line = pe.Line
+			}
+			row := importRow{seq: seq, line: line}
+			if err == nil {
+				row.job, row.err = parseImportRow(record, index, columns, settings.AccessToken, c.now())
+			} else {
+				row.err = err
+			}
+			select {
+			case rows <- row:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// results are written in the order read, once all earlier rows have completed
+	pending := make(map[int]importResult)
+	next := 0
+	var writeErr error
+	for result := range results {
+		pending[result.seq] = result
+		for {
+			r, exists := pending[next]
+			if !exists {
+				break
+			}
+			delete(pending, next)
+			next++
+			summary.Rows++
+			switch r.status {
+			case ImportCreated:
+				summary.Created++
+			case ImportInvalid:
+				summary.Invalid++
+			default:
+				summary.Failed++
+			}
+			msg := ""
+			if r.err != nil {
+				msg = r.err.Error()
+			}
+			if writeErr == nil {
+				writeErr = cw.Write([]string{strconv.Itoa(r.line), r.externalID, r.status, r.id, msg})
+			}
+		}
+	}
+	cw.Flush()
+	if err := <-readErr; err != nil {
+		return summary, fmt.Errorf("error reading CSV %v", err)
+	}
+	if writeErr != nil {
+		return summary, writeErr
+	}
+	return summary, cw.Error()
+}
+
+func (c APIClient) importRow(ctx context.Context, row importRow) importResult {
+	result := importResult{seq: row.seq, line: row.line, externalID: row.job.Params.ExternalID}
+	if row.err != nil {
+		result.status, result.err = ImportInvalid, row.err
+		return result
+	}
+	options, err := row.job.options()
+	if err == nil {
+		if err = ctx.Err(); err == nil {
+			var created map[string]interface{}
+			_, created, err = c.CreateFundraiserWithContext(ctx, row.job.Params, options...)
+			result.id, _ = created["id"].(string)
+		}
+	}
+	if err != nil {
+		result.status, result.err = ImportFailed, err
+		return result
+	}
+	result.status = ImportCreated
+	return result
+}
+
+// parseImportRow validates a row, returning the job creating its fundraiser.
+func parseImportRow(record []string, index map[string]int, columns ImportColumns, accessToken string, now time.Time) (FundraiserJob, error) {
+	value := func(column string) string {
+		if i, exists := index[column]; exists && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	job := FundraiserJob{Params: CreateFundraiserParams{
+		AccessToken: value(columns.AccessToken),
+		CharityID:   value(columns.CharityID),
+		Title:       value(columns.Title),
+		Description: value(columns.Description),
+		Currency:    strings.ToUpper(value(columns.Currency)),
+		ExternalID:  value(columns.ExternalID),
+	}}
+	if job.Params.AccessToken == "" {
+		job.Params.AccessToken = accessToken
+	}
+	var problems []string
+	if job.Params.AccessToken == "" {
+		problems = append(problems, "missing access token")
+	}
+	if job.Params.CharityID == "" {
+		problems = append(problems, "missing charity id")
+	}
+	if job.Params.Title == "" || utf8.RuneCountInString(job.Params.Title) > FundraiserTitleMaxLength {
+		problems = append(problems, "title must be 1 to 70 characters long")
+	}
+	if utf8.RuneCountInString(job.Params.Description) > FundraiserDescriptionMaxLength {
+		problems = append(problems, "description must be up to 50k characters long")
+	}
+	goal, err := strconv.Atoi(value(columns.Goal))
+	if err != nil || goal <= 0 {
+		problems = append(problems, "goal must be a positive whole number")
+	}
+	job.Params.Goal = goal
+	if len(job.Params.Currency) != 3 {
+		problems = append(problems, "currency must be an ISO 4217 code")
+	}
+	endTime, err := parseImportTime(value(columns.EndTime))
+	if err != nil || !endTime.After(now) || endTime.After(now.AddDate(5, 0, 0)) {
+		problems = append(problems, "end time must be within 5 years from now")
+	}
+	job.Params.EndTime = endTime
+	if photo := value(columns.CoverPhotoURL); photo != "" {
+		if u, err := url.Parse(photo); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, "cover photo url must be an http or https URL")
+		}
+		job.CoverPhotoURL = photo
+	}
+	for field, column := range columns.Fields {
+		if v := value(column); v != "" {
+			if job.Fields == nil {
+				job.Fields = make(map[string]string)
+			}
+			job.Fields[field] = v
+		}
+	}
+	if len(problems) > 0 {
+		return job, errors.New(strings.Join(problems, ", "))
+	}
+	return job, nil
+}
+
+// parseImportTime parses an RFC 3339 timestamp, a date or a Unix timestamp.
+func parseImportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Unix(i, 0), nil
+}
@@ -0,0 +1,61 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestImportFundraisers(t *testing.T) {
+	var created int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("external_id") == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid parameter","code":100}}`)
+			return
+		}
+		if r.FormValue("external_event_name") != "Marathon" {
+			t.Errorf("expected optional field to be imported, got %q", r.FormValue("external_event_name"))
+		}
+		fmt.Fprintf(w, `{"id":"%d"}`, 1000+atomic.AddInt32(&created, 1))
+	}))
+	end := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	in := strings.Join([]string{
+		"Charity,Title,Goal,Currency,Ends,Ref,external_event_name",
+		"1,First,10000,gbp," + end + ",a,Marathon",
+		"1,,10000,GBP," + end + ",b,Marathon",
+		"1,Third,0,GBP,2000-01-01,c,Marathon",
+		"1,Fourth,5000,GBP," + end + ",fail,Marathon",
+		"1,Fifth,5000,GBP," + end + ",e,Marathon",
+	}, "\n")
+	columns := DefaultImportColumns
+	columns.CharityID, columns.Title, columns.Goal, columns.Currency, columns.EndTime, columns.ExternalID = "Charity", "Title", "Goal", "Currency", "Ends", "Ref"
+
+	var out bytes.Buffer
+	summary, err := c.ImportFundraisers(context.Background(), strings.NewReader(in), &out, ImportSettings{AccessToken: "token", Columns: &columns, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("failed to import fundraisers %v", err)
+	}
+	if summary != (ImportSummary{Rows: 5, Created: 2, Invalid: 2, Failed: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 || !strings.HasPrefix(lines[1], "2,a,created,10") || !strings.HasPrefix(lines[2], "3,b,invalid,,title") ||
+		!strings.Contains(lines[3], "goal must be") || !strings.Contains(lines[3], "end time") || !strings.HasPrefix(lines[4], "5,fail,failed") {
+		t.Errorf("unexpected results\n%s", out.String())
+	}
+
+	if _, err := c.ImportFundraisers(context.Background(), strings.NewReader("title\nx"), &out, ImportSettings{}); err == nil {
+		t.Error("expected missing column error")
+	}
+
+	malformed := "Charity,Title,Goal,Currency,Ends\n" + `"x"y,1`
+	if _, err := c.ImportFundraisers(context.Background(), strings.NewReader(malformed), &out, ImportSettings{Columns: &columns}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected malformed row error, got %v", err)
+	}
+}
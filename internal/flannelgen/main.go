@@ -0,0 +1,324 @@
+// Command flannelgen generates typed bindings of Graph API endpoints from a declarative definition file,
+// so that adding an endpoint is a definition edit rather than hand-written request plumbing.
+// It also generates a JSON Schema of the params, results and payloads defined.
+//
+//	go run ./internal/flannelgen -in endpoints.json -out endpoints_gen.go -schema schema.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// definition is the format of the endpoint definition file.
+type definition struct {
+	Endpoints []endpoint `json:"endpoints"`
+	Results   []result   `json:"results"`
+
+	// Payloads are only included in the JSON Schema, as they are sent by hand-written API calls
+	Payloads []result `json:"payloads"`
+}
+
+type endpoint struct {
+	Name   string  `json:"name"`
+	Doc    string  `json:"doc"`
+	Method string  `json:"method"`
+	Path   string  `json:"path"`
+	Params []field `json:"params"`
+	Result string  `json:"result"`
+
+	// Scopes are the permission scopes required by the endpoint
+	Scopes []string `json:"scopes"`
+}
+
+type result struct {
+	Name   string  `json:"name"`
+	Doc    string  `json:"doc"`
+	Fields []field `json:"fields"`
+}
+
+type field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Doc      string `json:"doc"`
+}
+
+func main() {
+	in := flag.String("in", "endpoints.json", "endpoint definition file")
+	out := flag.String("out", "endpoints_gen.go", "generated Go file")
+	schema := flag.String("schema", "", "generated JSON Schema file, if set")
+	flag.Parse()
+	def, err := ioutil.ReadFile(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	src, err := generate(*in, def)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *schema == "" {
+		return
+	}
+	if src, err = generateSchema(filepath.Base(*in), def); err == nil {
+		err = ioutil.WriteFile(*schema, src, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var goTypes = map[string]bool{"string": true, "int": true, "int64": true, "float64": true, "bool": true}
+
+var pathParam = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// generate returns the Go source of the bindings defined by def, read from the file name.
+func generate(name string, def []byte) ([]byte, error) {
+	var d definition
+	if err := json.Unmarshal(def, &d); err != nil {
+		return nil, fmt.Errorf("error parsing %s %v", name, err)
+	}
+	results := make(map[string]result)
+	for _, r := range d.Results {
+		if err := checkFields(r.Name, r.Fields); err != nil {
+			return nil, err
+		}
+		results[r.Name] = r
+	}
+	imports := map[string]bool{"context": true, "net/url": true}
+	var endpoints []templateEndpoint
+	for _, e := range d.Endpoints {
+		if e.Method != "GET" && e.Method != "POST" {
+			return nil, fmt.Errorf("%s: unsupported method %s", e.Name, e.Method)
+		}
+		r, exists := results[e.Result]
+		if !exists {
+			return nil, fmt.Errorf("%s: undefined result %s", e.Name, e.Result)
+		}
+		if err := checkFields(e.Name, e.Params); err != nil {
+			return nil, err
+		}
+		te := templateEndpoint{endpoint: e}
+		inPath := make(map[string]bool)
+		var path []string
+		last := 0
+		for _, m := range pathParam.FindAllStringSubmatchIndex(e.Path, -1) {
+			if m[0] > last {
+				path = append(path, fmt.Sprintf("%q", e.Path[last:m[0]]))
+			}
+			name := e.Path[m[2]:m[3]]
+			p, ok := findField(e.Params, name)
+			if !ok || p.Type != "string" {
+				return nil, fmt.Errorf("%s: path parameter %s must be a string param", e.Name, name)
+			}
+			inPath[name] = true
+			path = append(path, "url.PathEscape(params."+goName(name)+")")
+			last = m[1]
+		}
+		if last < len(e.Path) {
+			path = append(path, fmt.Sprintf("%q", e.Path[last:]))
+		}
+		te.PathExpr = strings.Join(path, " + ")
+		for _, scope := range e.Scopes {
+			te.ScopeNames = append(te.ScopeNames, fmt.Sprintf("%q", scope))
+		}
+		for _, p := range e.Params {
+			if p.Required && p.Type == "string" {
+				te.Checks = append(te.Checks, p)
+				imports["errors"] = true
+			}
+			if !inPath[p.Name] {
+				te.Values = append(te.Values, templateValue{field: p, Expr: formatExpr(p)})
+				if p.Type != "string" {
+					imports["strconv"] = true
+				}
+			}
+		}
+		if e.Method == "GET" {
+			names := make([]string, len(r.Fields))
+			for i, f := range r.Fields {
+				names[i] = f.Name
+			}
+			te.Fields = strings.Join(names, ",")
+		}
+		endpoints = append(endpoints, te)
+	}
+	var sorted []string
+	for i := range imports {
+		sorted = append(sorted, i)
+	}
+	sort.Strings(sorted)
+
+	var b bytes.Buffer
+	err := bindings.Execute(&b, map[string]interface{}{
+		"Source":    filepath.Base(name),
+		"Imports":   sorted,
+		"Endpoints": endpoints,
+		"Results":   d.Results,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code %v\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+type templateEndpoint struct {
+	endpoint
+	PathExpr   string
+	Fields     string
+	Checks     []field
+	Values     []templateValue
+	ScopeNames []string
+}
+
+type templateValue struct {
+	field
+	Expr string
+}
+
+func checkFields(name string, fields []field) error {
+	for _, f := range fields {
+		if !goTypes[f.Type] {
+			return fmt.Errorf("%s: unsupported type %s of %s", name, f.Type, f.Name)
+		}
+	}
+	return nil
+}
+
+func findField(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// formatExpr returns the expression formatting the param p as a string.
+func formatExpr(p field) string {
+	v := "params." + goName(p.Name)
+	switch p.Type {
+	case "int":
+		return "strconv.Itoa(" + v + ")"
+	case "int64":
+		return "strconv.FormatInt(" + v + ", 10)"
+	case "float64":
+		return "strconv.FormatFloat(" + v + ", 'f', -1, 64)"
+	case "bool":
+		return "strconv.FormatBool(" + v + ")"
+	default:
+		return v
+	}
+}
+
+var initialisms = map[string]string{"id": "ID", "uri": "URI", "url": "URL", "api": "API"}
+
+// goName converts a snake_case Graph API name to an exported Go name.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if s, ok := initialisms[part]; ok {
+			b.WriteString(s)
+		} else if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+func zero(typ string) string {
+	switch typ {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+var bindings = template.Must(template.New("bindings").Funcs(template.FuncMap{"goName": goName, "zero": zero, "join": strings.Join}).Parse(`// Code generated by flannelgen from {{.Source}}. DO NOT EDIT.
+
+package flannel
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range .Results}}
+// {{.Name}} {{.Doc}}
+type {{.Name}} struct {
+{{range .Fields}}
+	// {{goName .Name}} {{.Doc}}
+	{{goName .Name}} {{.Type}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{end}}}
+{{end}}
+{{- range .Endpoints}}
+// {{.Name}}Params are the parameters of {{.Name}}.
+type {{.Name}}Params struct {
+{{range .Params}}
+	// {{goName .Name}} {{.Doc}}{{if .Required}} It is required.{{end}}
+	{{goName .Name}} {{.Type}}
+{{end}}}
+
+// {{.Name}} {{.Doc}}
+{{- if .Scopes}}
+// It requires the {{join .Scopes ", "}} {{if eq (len .Scopes) 1}}scope{{else}}scopes{{end}}.
+{{- end}}
+func (c APIClient) {{.Name}}(ctx context.Context, accessToken string, params {{.Name}}Params) (result {{.Result}}, err error) {
+{{- range .Checks}}
+	if params.{{goName .Name}} == "" {
+		return result, errors.New("{{.Name}} is required")
+	}
+{{- end}}
+	values := url.Values{}
+{{- if .Fields}}
+	values.Set("fields", "{{.Fields}}")
+{{- end}}
+{{- range .Values}}
+{{- if .Required}}
+	values.Set("{{.Name}}", {{.Expr}})
+{{- else}}
+	if params.{{goName .Name}} != {{zero .Type}} {
+		values.Set("{{.Name}}", {{.Expr}})
+	}
+{{- end}}
+{{- end}}
+	_, m, err := c.{{if eq .Method "GET"}}get{{else}}post{{end}}(ctx, accessToken, GraphAPIEndpoint+{{.PathExpr}}, values)
+	if err != nil {
+		return result, err
+	}
+	return result, c.decode("{{.Name}}", m, &result)
+}
+{{end}}
+// generatedScopes are the permission scopes required by each generated endpoint binding.
+var generatedScopes = map[string][]string{
+{{- range .Endpoints}}
+{{- if .Scopes}}
+	"{{.Name}}": { {{- join .ScopeNames ", " -}} },
+{{- end}}
+{{- end}}
+}
+`))
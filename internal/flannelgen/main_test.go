@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedBindingsUpToDate(t *testing.T) {
+	def, err := ioutil.ReadFile("../../endpoints.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("endpoints.json", def)
+	if err != nil {
+		t.Fatalf("failed to generate bindings %v", err)
+	}
+	existing, err := ioutil.ReadFile("../../endpoints_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, existing) {
+		t.Error("endpoints_gen.go is out of date, run go generate")
+	}
+	src, err = generateSchema("endpoints.json", def)
+	if err != nil {
+		t.Fatalf("failed to generate schema %v", err)
+	}
+	existing, err = ioutil.ReadFile("../../schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, existing) {
+		t.Error("schema.json is out of date, run go generate")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, def := range []string{
+		`{"endpoints":[{"name":"A","method":"DELETE","path":"/","result":"R"}],"results":[{"name":"R"}]}`,
+		`{"endpoints":[{"name":"A","method":"GET","path":"/","result":"Missing"}]}`,
+		`{"endpoints":[{"name":"A","method":"GET","path":"/{id}","result":"R"}],"results":[{"name":"R"}]}`,
+		`{"results":[{"name":"R","fields":[{"name":"a","type":"complex128"}]}]}`,
+	} {
+		if _, err := generate("test.json", []byte(def)); err == nil {
+			t.Errorf("expected error generating %s", def)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	if got := goName("external_fundraiser_uri"); got != "ExternalFundraiserURI" {
+		t.Errorf("unexpected name %s", got)
+	}
+	if got := goName("charity_id"); !strings.HasSuffix(got, "ID") {
+		t.Errorf("unexpected name %s", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonTypes maps the types of fields to JSON Schema types.
+var jsonTypes = map[string]string{"string": "string", "int": "integer", "int64": "integer", "float64": "number", "bool": "boolean"}
+
+type schemaObject struct {
+	Type                 string                    `json:"type"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]schemaProperty `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+type schemaProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// generateSchema returns a JSON Schema document defining the params and results of each endpoint, and each payload,
+// so that services not written in Go can validate the payloads sent and received by flannel.
+func generateSchema(name string, def []byte) ([]byte, error) {
+	var d definition
+	if err := json.Unmarshal(def, &d); err != nil {
+		return nil, fmt.Errorf("error parsing %s %v", name, err)
+	}
+	defs := make(map[string]schemaObject)
+	add := func(name string, doc string, fields []field, closed bool) error {
+		if err := checkFields(name, fields); err != nil {
+			return err
+		}
+		if _, exists := defs[name]; exists {
+			return fmt.Errorf("%s is defined more than once", name)
+		}
+		o := schemaObject{Type: "object", Description: name + " " + doc, Properties: make(map[string]schemaProperty), AdditionalProperties: !closed}
+		for _, f := range fields {
+			o.Properties[f.Name] = schemaProperty{Type: jsonTypes[f.Type], Description: f.Name + " " + f.Doc}
+			if f.Required {
+				o.Required = append(o.Required, f.Name)
+			}
+		}
+		defs[name] = o
+		return nil
+	}
+	for _, e := range d.Endpoints {
+		if err := add(e.Name+"Params", fmt.Sprintf("are the parameters of %s %s.", e.Method, e.Path), e.Params, true); err != nil {
+			return nil, err
+		}
+	}
+	// results are open as Facebook adds fields over time
+	for _, r := range d.Results {
+		if err := add(r.Name, r.Doc, r.Fields, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range d.Payloads {
+		if err := add(p.Name, p.Doc, p.Fields, true); err != nil {
+			return nil, err
+		}
+	}
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         "https://github.com/homemade/flannel/schema.json",
+		"title":       "flannel",
+		"description": "Generated by flannelgen from " + name + ". DO NOT EDIT.",
+		"$defs":       defs,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
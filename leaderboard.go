@@ -0,0 +1,175 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FundraiserTotals are the amounts of a fundraiser, in the smallest unit of its currency.
+type FundraiserTotals struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	AmountRaised int64  `json:"amount_raised"`
+	GoalAmount   int64  `json:"goal_amount"`
+	Currency     string `json:"currency"`
+
+	ExternalEventName string `json:"external_event_name,omitempty"`
+	ExternalEventURI  string `json:"external_event_uri,omitempty"`
+}
+
+// LeaderboardSettings configures Leaderboard.
+type LeaderboardSettings struct {
+
+	// AccessToken is used to get each fundraiser.
+	AccessToken string
+
+	// Concurrency is the number of fundraisers fetched at the same time, defaults to 4.
+	Concurrency int
+
+	// Cache, if set, holds the totals of each fundraiser for CacheTTL, so that leaderboards shown on busy pages
+	// do not get every fundraiser each time.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// ReportingCurrency, if set with Rates, is the currency amounts raised are converted to before being ranked.
+	ReportingCurrency string
+	Rates             RateProvider
+}
+
+// A LeaderboardEntry is the position of a fundraiser in a Leaderboard.
+type LeaderboardEntry struct {
+
+	// Rank starts at 1, fundraisers which have raised the same amount have the same rank.
+	Rank int
+
+	// ReportingAmount is the amount raised in the ReportingCurrency, or as is if none is set.
+	ReportingAmount int64
+
+	FundraiserTotals
+}
+
+// A Leaderboard ranks fundraisers by the amount raised.
+type Leaderboard struct {
+	Entries []LeaderboardEntry
+
+	// Failed are the errors getting fundraisers, by ID, which are left out of the entries.
+	Failed map[string]error
+}
+
+// Leaderboard gets the totals of fundraisers concurrently, for example all the participants of an event, and ranks
+// them by the amount raised, converted to the ReportingCurrency if set. Fundraisers which cannot be got or converted
+// are reported in Failed rather than failing the leaderboard, an error is only returned if ctx is done.
+func (c APIClient) Leaderboard(ctx context.Context, fundraiserIDs []string, settings LeaderboardSettings) (Leaderboard, error) {
+	totals, failed, err := c.fundraiserTotals(ctx, fundraiserIDs, settings)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+	board := Leaderboard{Failed: failed}
+	conv := newConverter(settings.Rates, settings.ReportingCurrency)
+	for _, t := range totals {
+		entry := LeaderboardEntry{ReportingAmount: t.AmountRaised, FundraiserTotals: t}
+		if conv != nil {
+			if entry.ReportingAmount, err = conv.convert(ctx, t.AmountRaised, t.Currency); err != nil {
+				failed[t.ID] = err
+				continue
+			}
+		}
+		board.Entries = append(board.Entries, entry)
+	}
+	sort.SliceStable(board.Entries, func(i, j int) bool {
+		return board.Entries[i].ReportingAmount > board.Entries[j].ReportingAmount
+	})
+	for i := range board.Entries {
+		board.Entries[i].Rank = i + 1
+		if i > 0 && board.Entries[i].ReportingAmount == board.Entries[i-1].ReportingAmount {
+			board.Entries[i].Rank = board.Entries[i-1].Rank
+		}
+	}
+	return board, nil
+}
+
+// fundraiserTotals gets the totals of fundraisers concurrently, in the order of fundraiserIDs.
+func (c APIClient) fundraiserTotals(ctx context.Context, fundraiserIDs []string, settings LeaderboardSettings) ([]FundraiserTotals, map[string]error, error) {
+	if settings.Concurrency < 1 {
+		settings.Concurrency = 4
+	}
+	results := make([]*FundraiserTotals, len(fundraiserIDs))
+	errs := concurrently(ctx, len(fundraiserIDs), settings.Concurrency, func(i int) (err error) {
+		results[i], err = c.getFundraiserTotals(ctx, fundraiserIDs[i], settings)
+		return err
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	var totals []FundraiserTotals
+	failed := make(map[string]error)
+	for i, id := range fundraiserIDs {
+		if errs[i] != nil {
+			failed[id] = errs[i]
+			continue
+		}
+		totals = append(totals, *results[i])
+	}
+	return totals, failed, nil
+}
+
+func (c APIClient) getFundraiserTotals(ctx context.Context, fundraiserID string, settings LeaderboardSettings) (*FundraiserTotals, error) {
+	key := "flannel:totals:" + fundraiserID
+	if settings.Cache != nil {
+		if b, found := settings.Cache.Get(ctx, key); found {
+			var totals FundraiserTotals
+			if err := json.Unmarshal(b, &totals); err == nil {
+				return &totals, nil
+			}
+		}
+	}
+	_, result, err := c.GetFundraiser(ctx, settings.AccessToken, fundraiserID, "id", "name", "amount_raised", "goal_amount", "currency",
+		"external_event_name", "external_event_uri")
+	if err != nil {
+		return nil, err
+	}
+	totals := &FundraiserTotals{ID: fundraiserID}
+	totals.Name, _ = result["name"].(string)
+	totals.Currency, _ = result["currency"].(string)
+	totals.ExternalEventName, _ = result["external_event_name"].(string)
+	totals.ExternalEventURI, _ = result["external_event_uri"].(string)
+	if v, ok := result["amount_raised"].(float64); ok {
+		totals.AmountRaised = int64(v)
+	}
+	if v, ok := result["goal_amount"].(float64); ok {
+		totals.GoalAmount = int64(v)
+	}
+	if settings.Cache != nil {
+		if b, err := json.Marshal(totals); err == nil {
+			settings.Cache.Set(ctx, key, b, settings.CacheTTL)
+		}
+	}
+	return totals, nil
+}
+
+// concurrently calls fn for each index up to n, with up to concurrency calls at the same time,
+// returning the error of each call.
+func concurrently(ctx context.Context, n int, concurrency int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
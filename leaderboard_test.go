@@ -0,0 +1,49 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaderboard(t *testing.T) {
+	var requests int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		id := strings.TrimPrefix(r.URL.Path, "/v2.8/")
+		amounts := map[string]int{"1": 500, "2": 2500, "3": 500}
+		amount, exists := amounts[id]
+		if !exists {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Unsupported get request","code":100,"error_subcode":33}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"name":"Runner %[1]s","amount_raised":%d,"goal_amount":10000,"currency":"GBP"}`, id, amount)
+	}))
+
+	settings := LeaderboardSettings{AccessToken: "token", Cache: NewMemoryCache(10), CacheTTL: time.Minute}
+	for i := 0; i < 2; i++ {
+		board, err := c.Leaderboard(context.Background(), []string{"1", "2", "3", "4"}, settings)
+		if err != nil {
+			t.Fatalf("failed to get leaderboard %v", err)
+		}
+		var ranks []string
+		for _, entry := range board.Entries {
+			ranks = append(ranks, fmt.Sprintf("%d:%s:%d", entry.Rank, entry.ID, entry.AmountRaised))
+		}
+		if fmt.Sprint(ranks) != "[1:2:2500 2:1:500 2:3:500]" {
+			t.Errorf("unexpected leaderboard %v", ranks)
+		}
+		if len(board.Failed) != 1 || board.Failed["4"] == nil {
+			t.Errorf("expected fundraiser 4 to fail, got %v", board.Failed)
+		}
+	}
+	// totals are cached, failures are not
+	if n := atomic.LoadInt32(&requests); n != 5 {
+		t.Errorf("expected 5 requests, got %d", n)
+	}
+}
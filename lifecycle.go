@@ -0,0 +1,112 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// A Service is a background component, such as a FundraiserQueue, WebhookQueue, DonationPoller or CampaignCleanup,
+// sharing a lifecycle so that applications embedding them can shut down cleanly.
+//
+//	q.Start(ctx)
+//	...
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	flannel.Shutdown(ctx, q, poller)
+type Service interface {
+
+	// Start starts the service in the background, the ctx is used for the work it does.
+	Start(ctx context.Context) error
+
+	// Shutdown stops the service, waiting for work in progress to complete. If ctx is done first the work is
+	// cancelled, and the error of ctx returned once it has stopped. Work which is not completed is resumed by
+	// the next Start where the service is backed by a store.
+	Shutdown(ctx context.Context) error
+
+	// Close stops the service, waiting for work in progress to complete without a timeout.
+	Close() error
+}
+
+// Shutdown shuts down services concurrently, returning the first error.
+func Shutdown(ctx context.Context, services ...Service) error {
+	errs := make([]error, len(services))
+	var wg sync.WaitGroup
+	for i, s := range services {
+		wg.Add(1)
+		go func(i int, s Service) {
+			defer wg.Done()
+			errs[i] = s.Shutdown(ctx)
+		}(i, s)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errAlreadyStarted = errors.New("already started")
+
+// drain waits for wg, cancelling the work it waits for with cancel if ctx is done first.
+func drain(ctx context.Context, wg *sync.WaitGroup, cancel context.CancelFunc) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// loop runs a periodic task of a Service in the background.
+type loop struct {
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	stop    context.CancelFunc
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// start calls run in a goroutine with ctx, which is cancelled if shutdown times out, and stop, which is cancelled
+// when shutdown starts so that run returns once the task in progress has completed.
+func (l *loop) start(ctx context.Context, run func(ctx context.Context, stop context.Context)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started || l.closed {
+		return errAlreadyStarted
+	}
+	l.started = true
+	ctx, l.cancel = context.WithCancel(ctx)
+	stop, cancelStop := context.WithCancel(ctx)
+	l.stop = cancelStop
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		run(ctx, stop)
+	}()
+	return nil
+}
+
+func (l *loop) shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	if l.closed || !l.started {
+		l.closed = true
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.stop()
+	l.mu.Unlock()
+	defer l.cancel()
+	return drain(ctx, &l.wg, l.cancel)
+}
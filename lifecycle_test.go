@@ -0,0 +1,75 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	received := make(chan string, 2)
+	release := make(chan struct{})
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.FormValue("name")
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, `{"id":"1234"}`)
+	}))
+	store := NewMemoryJobStore()
+	completed := make(chan error, 2)
+	settings := FundraiserQueueSettings{OnComplete: func(job FundraiserJob, status int, result map[string]interface{}, err error) {
+		completed <- err
+	}}
+	ctx := context.Background()
+
+	// a job which does not complete in time is cancelled and left in the store
+	q := NewFundraiserQueue(c, store, settings)
+	poller := c.NewDonationPoller(DonationPollerSettings{})
+	for _, s := range []Service{q, poller} {
+		if err := s.Start(ctx); err != nil {
+			t.Fatalf("failed to start %v", err)
+		}
+	}
+	if _, err := q.Enqueue(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "slow"}}); err != nil {
+		t.Fatalf("failed to enqueue job %v", err)
+	}
+	<-received
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := Shutdown(timeout, q, poller); err != context.DeadlineExceeded {
+		t.Errorf("expected shutdown to time out, got %v", err)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 1 {
+		t.Errorf("expected cancelled job to remain in the store, got %d", len(pending))
+	}
+	select {
+	case err := <-completed:
+		t.Errorf("expected cancelled job not to complete, got %v", err)
+	default:
+	}
+
+	// the job is resumed and completes before the next shutdown
+	q = NewFundraiserQueue(c, store, settings)
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	<-received
+	close(release)
+	if err := q.Shutdown(ctx); err != nil {
+		t.Errorf("unexpected error shutting down %v", err)
+	}
+	if err := <-completed; err != nil {
+		t.Errorf("expected resumed job to complete, got %v", err)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 0 {
+		t.Errorf("expected completed job to be removed from the store, got %d", len(pending))
+	}
+	if err := poller.Start(ctx); err == nil {
+		t.Errorf("expected error restarting a shut down poller")
+	}
+}
@@ -0,0 +1,117 @@
+package flannel
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Facebook URLs used to build fundraiser links.
+const (
+	FundraiserURLPrefix = "https://www.facebook.com/donate/"
+	ShareDialogEndpoint = "https://www.facebook.com/dialog/share"
+)
+
+// UTMParams are the Google Analytics campaign parameters added to fundraiser links, empty parameters are omitted.
+type UTMParams struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// LinkSettings configures the links returned by NewFundraiserLinks.
+type LinkSettings struct {
+
+	// AppID is the Facebook app the share dialog is opened by, the share dialog link is omitted if not set.
+	AppID string
+
+	// RedirectURL is where users are sent once the share dialog is closed.
+	RedirectURL string
+
+	// Quote is text pre-filled in the share dialog.
+	Quote string
+
+	// Hashtag is a hashtag, including the #, added to the shared post.
+	Hashtag string
+
+	UTM UTMParams
+}
+
+// FundraiserLinks are the marketing links of a fundraiser.
+type FundraiserLinks struct {
+
+	// Share is the fundraiser URL, with any UTM parameters.
+	Share string
+
+	// DeepLink opens the fundraiser in the Facebook app on mobile devices.
+	DeepLink string
+
+	// ShareDialog opens the Facebook share dialog pre-filled with the fundraiser.
+	ShareDialog string
+}
+
+var fundraiserIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// NewFundraiserLinks returns the links of the fundraiser identified by its ID or its permalink, as returned by
+// CreateFundraiser and the Graph API.
+func NewFundraiserLinks(idOrPermalink string, settings LinkSettings) (FundraiserLinks, error) {
+	id, err := parseFundraiserID(idOrPermalink)
+	if err != nil {
+		return FundraiserLinks{}, err
+	}
+	share, _ := url.Parse(FundraiserURLPrefix + id + "/")
+	query := share.Query()
+	for name, value := range map[string]string{
+		"utm_source":   settings.UTM.Source,
+		"utm_medium":   settings.UTM.Medium,
+		"utm_campaign": settings.UTM.Campaign,
+		"utm_term":     settings.UTM.Term,
+		"utm_content":  settings.UTM.Content,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	share.RawQuery = query.Encode()
+
+	links := FundraiserLinks{
+		Share:    share.String(),
+		DeepLink: "fb://facewebmodal/f?href=" + url.QueryEscape(share.String()),
+	}
+	if settings.AppID != "" {
+		query := url.Values{"app_id": {settings.AppID}, "href": {links.Share}, "display": {"page"}}
+		if settings.RedirectURL != "" {
+			query.Set("redirect_uri", settings.RedirectURL)
+		}
+		if settings.Quote != "" {
+			query.Set("quote", settings.Quote)
+		}
+		if settings.Hashtag != "" {
+			query.Set("hashtag", settings.Hashtag)
+		}
+		links.ShareDialog = ShareDialogEndpoint + "?" + query.Encode()
+	}
+	return links, nil
+}
+
+// parseFundraiserID returns the ID of a fundraiser from its ID or permalink.
+func parseFundraiserID(idOrPermalink string) (string, error) {
+	s := strings.TrimSpace(idOrPermalink)
+	if fundraiserIDPattern.MatchString(s) {
+		return s, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+		(u.Host != "facebook.com" && !strings.HasSuffix(u.Host, ".facebook.com")) {
+		return "", fmt.Errorf("invalid fundraiser id or permalink %q", idOrPermalink)
+	}
+	// permalinks are of the form /donate/{id}/ or /fundraisers/{id}/
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 2 && (parts[0] == "donate" || parts[0] == "fundraisers") && fundraiserIDPattern.MatchString(parts[1]) {
+		return parts[1], nil
+	}
+	return "", fmt.Errorf("invalid fundraiser permalink %q", idOrPermalink)
+}
@@ -0,0 +1,34 @@
+package flannel
+
+import (
+	"testing"
+)
+
+func TestNewFundraiserLinks(t *testing.T) {
+	links, err := NewFundraiserLinks("https://www.facebook.com/donate/1234/?fundraiser_source=external_url", LinkSettings{
+		AppID:   "99",
+		Hashtag: "#run",
+		UTM:     UTMParams{Source: "facebook", Campaign: "marathon"},
+	})
+	if err != nil {
+		t.Fatalf("failed to build links %v", err)
+	}
+	if expected := "https://www.facebook.com/donate/1234/?utm_campaign=marathon&utm_source=facebook"; links.Share != expected {
+		t.Errorf("unexpected share link %s", links.Share)
+	}
+	if expected := "fb://facewebmodal/f?href=https%3A%2F%2Fwww.facebook.com%2Fdonate%2F1234%2F%3Futm_campaign%3Dmarathon%26utm_source%3Dfacebook"; links.DeepLink != expected {
+		t.Errorf("unexpected deep link %s", links.DeepLink)
+	}
+	if expected := "https://www.facebook.com/dialog/share?app_id=99&display=page&hashtag=%23run&href=https%3A%2F%2Fwww.facebook.com%2Fdonate%2F1234%2F%3Futm_campaign%3Dmarathon%26utm_source%3Dfacebook"; links.ShareDialog != expected {
+		t.Errorf("unexpected share dialog link %s", links.ShareDialog)
+	}
+
+	if links, err = NewFundraiserLinks("1234", LinkSettings{}); err != nil || links.Share != "https://www.facebook.com/donate/1234/" || links.ShareDialog != "" {
+		t.Errorf("unexpected links %+v %v", links, err)
+	}
+	for _, invalid := range []string{"abc", "https://example.com/donate/1234/", "https://www.facebook.com/groups/1234/"} {
+		if _, err := NewFundraiserLinks(invalid, LinkSettings{}); err == nil {
+			t.Errorf("expected %s to be invalid", invalid)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ListFundraisers returns an iterator over the Facebook Fundraisers created by the user identified by accessToken.
+// The fields to return can optionally be set with fields, otherwise Facebook returns its default fields.
+// It requires the manage_fundraisers scope.
+func (c APIClient) ListFundraisers(ctx context.Context, accessToken string, fields ...string) *ListIterator {
+	return c.list(ctx, accessToken, CreateFundraiserEndpoint, fields)
+}
+
+// ListDonations returns an iterator over the donations made to a Facebook Fundraiser.
+// The fields to return can optionally be set with fields, otherwise Facebook returns its default fields.
+// It requires the manage_fundraisers scope.
+func (c APIClient) ListDonations(ctx context.Context, accessToken string, fundraiserID string, fields ...string) *ListIterator {
+	return c.list(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(fundraiserID)+"/donations", fields)
+}
+
+func (c APIClient) list(ctx context.Context, accessToken string, endpoint string, fields []string) *ListIterator {
+	return &ListIterator{c: c, ctx: ctx, accessToken: accessToken, endpoint: endpoint, query: fieldsQuery(fields)}
+}
+
+// A ListIterator iterates over the items returned by a Facebook API list endpoint, requesting further pages as required.
+// Each page is read into memory before its items are decoded, so the concurrency limit of the APIClient applies to the
+// page request but not while the caller handles its items, which may make API calls of their own.
+//
+//	it := c.ListDonations(ctx, accessToken, fundraiserID)
+//	defer it.Close()
+//	for it.Next() {
+//		donation := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ListIterator struct {
+	c           APIClient
+	ctx         context.Context
+	accessToken string
+	endpoint    string
+	query       url.Values
+
+	// after is the cursor of the page requested, start is the cursor of the first page
+	after   string
+	start   string
+	fetched bool
+
+	release func()
+	dec     *json.Decoder
+	inData  bool
+	paging  listPaging
+
+	item map[string]interface{}
+	err  error
+	done bool
+}
+
+type listPaging struct {
+	Cursors struct {
+		Before string `json:"before"`
+		After  string `json:"after"`
+	} `json:"cursors"`
+	Next string `json:"next"`
+}
+
+// Next advances the iterator to the next item, which is then available through Item.
+// It returns false when there are no more items or an error occurs.
+func (it *ListIterator) Next() bool {
+	for it.err == nil && !it.done {
+		if it.dec == nil {
+			if it.fetched && it.paging.Next == "" {
+				it.done = true
+				break
+			}
+			if it.fetched {
+				it.after = it.paging.Cursors.After
+			} else {
+				it.after = it.start
+			}
+			if it.err = it.fetch(); it.err != nil {
+				break
+			}
+			continue
+		}
+		if it.inData {
+			if it.dec.More() {
+				item := make(map[string]interface{})
+				if err := it.dec.Decode(&item); err != nil {
+					it.fail(err)
+					break
+				}
+				it.item = item
+				return true
+			}
+			// consume the end of the data array
+			if _, err := it.dec.Token(); err != nil {
+				it.fail(err)
+				break
+			}
+			it.inData = false
+			continue
+		}
+		if !it.dec.More() {
+			// end of the page
+			it.closePage()
+			continue
+		}
+		tok, err := it.dec.Token()
+		if err != nil {
+			it.fail(err)
+			break
+		}
+		switch tok {
+		case "data":
+			if tok, err = it.dec.Token(); err != nil || tok != json.Delim('[') {
+				it.fail(fmt.Errorf("expected data array, got %v %v", tok, err))
+				break
+			}
+			it.inData = true
+		case "paging":
+			if err = it.dec.Decode(&it.paging); err != nil {
+				it.fail(err)
+			}
+		default:
+			var skip json.RawMessage
+			if err = it.dec.Decode(&skip); err != nil {
+				it.fail(err)
+			}
+		}
+	}
+	it.item = nil
+	return false
+}
+
+// StartAfter starts iterating after cursor, as returned by PageCursor, it must be called before Next.
+func (it *ListIterator) StartAfter(cursor string) *ListIterator {
+	it.start = cursor
+	return it
+}
+
+// PageCursor returns the cursor from which the page of the current item was requested, so that iteration can be
+// resumed from the start of the page with StartAfter.
+func (it *ListIterator) PageCursor() string {
+	return it.after
+}
+
+// A Checkpoint records the position of a ListIterator, so that iteration can be resumed from the start of the
+// current page by another ListIterator, for example one created by another worker once the checkpoint has been
+// persisted. It can be serialized as JSON.
+type Checkpoint struct {
+	Endpoint string `json:"endpoint"`
+
+	// ParamsHash identifies the query parameters of the list, so that a checkpoint is not resumed with other fields.
+	ParamsHash string `json:"params_hash"`
+
+	Cursor string `json:"cursor"`
+}
+
+// Checkpoint returns the checkpoint of the page of the current item.
+func (it *ListIterator) Checkpoint() Checkpoint {
+	return Checkpoint{Endpoint: it.endpoint, ParamsHash: it.paramsHash(), Cursor: it.PageCursor()}
+}
+
+type checkpointError struct {
+	msg string
+}
+
+func (e checkpointError) Error() string {
+	return e.msg
+}
+
+// IsCheckpointMismatch returns true if err is from resuming a checkpoint of a different list.
+func IsCheckpointMismatch(err error) bool {
+	var ce checkpointError
+	return errors.As(err, &ce)
+}
+
+// Resume starts iterating from checkpoint, it must be called before Next. An error is returned if the checkpoint
+// was not taken from a list of the same endpoint and parameters.
+func (it *ListIterator) Resume(checkpoint Checkpoint) error {
+	if it.fetched {
+		return errors.New("iteration has already started")
+	}
+	if checkpoint.Endpoint != it.endpoint || checkpoint.ParamsHash != it.paramsHash() {
+		return checkpointError{msg: fmt.Sprintf("checkpoint of %s does not match %s", checkpoint.Endpoint, it.endpoint)}
+	}
+	it.StartAfter(checkpoint.Cursor)
+	return nil
+}
+
+func (it *ListIterator) paramsHash() string {
+	sum := sha256.Sum256([]byte(it.query.Encode()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Item returns the current item.
+func (it *ListIterator) Item() map[string]interface{} {
+	return it.item
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+// Close releases the page currently being read, it must be called if iteration is stopped early.
+func (it *ListIterator) Close() error {
+	it.closePage()
+	it.done = true
+	return nil
+}
+
+// fetch requests the next page and positions the decoder at the start of the response object.
+func (it *ListIterator) fetch() error {
+	it.fetched = true
+	it.paging = listPaging{}
+	query := url.Values{}
+	for k, v := range it.query {
+		query[k] = v
+	}
+	if it.after != "" {
+		query.Set("after", it.after)
+	}
+	u := it.endpoint
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+
+	release, err := it.c.acquire(it.ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(it.ctx, "GET", u, nil)
+	if err != nil {
+		release()
+		return fmt.Errorf("error preparing request %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+it.accessToken)
+	if err := it.c.checkCompatibility(req, queryFields(query)); err != nil {
+		release()
+		return err
+	}
+	res, err := it.c.do(it.ctx, req)
+	if err != nil {
+		release()
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		// error responses are small so are read in full
+		defer release()
+		_, _, err = it.c.readResponse(it.endpoint, req, res, http.StatusOK)
+		return err
+	}
+	it.c.logRequest("facebook api", req, res.StatusCode, nil, nil)
+	if err := it.c.checkDeprecation(req, res, nil); err != nil {
+		drainAndClose(res.Body)
+		release()
+		return err
+	}
+	b, releaseBody, err := it.c.readBody(res.Body)
+	drainAndClose(res.Body)
+	release()
+	if err != nil {
+		releaseBody()
+		return err
+	}
+	it.release = releaseBody
+	it.dec = json.NewDecoder(bytes.NewReader(b))
+	if tok, err := it.dec.Token(); err != nil || tok != json.Delim('{') {
+		it.closePage()
+		return fmt.Errorf("error parsing response %v %v", tok, err)
+	}
+	return nil
+}
+
+func (it *ListIterator) fail(err error) {
+	it.closePage()
+	it.err = fmt.Errorf("error parsing response %v", err)
+}
+
+func (it *ListIterator) closePage() {
+	if it.release != nil {
+		it.release()
+		it.release = nil
+	}
+	it.dec = nil
+	it.inData = false
+}
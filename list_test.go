@@ -0,0 +1,97 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListDonations(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2.8/1234/donations" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("fields"); got != "id,amount" {
+			t.Errorf("unexpected fields %q", got)
+		}
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"data":[{"id":"1","amount":500},{"id":"2","amount":1000}],"paging":{"cursors":{"before":"a","after":"b"},"next":"https://graph.facebook.com/v2.8/1234/donations?after=b"}}`)
+		case "b":
+			fmt.Fprint(w, `{"paging":{"cursors":{"before":"b","after":"c"}},"data":[{"id":"3","amount":250}]}`)
+		default:
+			t.Errorf("unexpected page requested %s", r.URL.RawQuery)
+		}
+	}))
+
+	it := c.ListDonations(context.Background(), "token", "1234", "id", "amount")
+	defer it.Close()
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Item()["id"].(string))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("failed to list donations %v", err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("unexpected donations %v", ids)
+	}
+}
+
+func TestListFundraisersError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"Invalid OAuth access token.","type":"OAuthException","code":190}}`)
+	}))
+
+	it := c.ListFundraisers(context.Background(), "token")
+	if it.Next() {
+		t.Fatalf("expected no fundraisers")
+	}
+	if code, _ := ErrorCodes(it.Err()); code != 190 {
+		t.Errorf("expected facebook error code 190, got %v", it.Err())
+	}
+}
+
+func TestListCheckpoint(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"data":[{"id":"1"}],"paging":{"cursors":{"after":"b"},"next":"https://graph.facebook.com/v2.8/1234/donations?after=b"}}`)
+		case "b":
+			fmt.Fprint(w, `{"data":[{"id":"2"}],"paging":{"cursors":{"after":"c"}}}`)
+		}
+	}))
+
+	it := c.ListDonations(context.Background(), "token", "1234", "id")
+	for it.Next() && it.Item()["id"] != "2" {
+	}
+	b, err := json.Marshal(it.Checkpoint())
+	it.Close()
+	if err != nil {
+		t.Fatalf("failed to marshal checkpoint %v", err)
+	}
+
+	// another worker resumes from the persisted checkpoint
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		t.Fatalf("failed to unmarshal checkpoint %v", err)
+	}
+	if err := c.ListDonations(context.Background(), "token", "1234", "id", "amount").Resume(checkpoint); !IsCheckpointMismatch(err) {
+		t.Errorf("expected checkpoint of other fields to mismatch, got %v", err)
+	}
+	it = c.ListDonations(context.Background(), "token", "1234", "id")
+	defer it.Close()
+	if err := it.Resume(checkpoint); err != nil {
+		t.Fatalf("failed to resume %v", err)
+	}
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Item()["id"].(string))
+	}
+	if fmt.Sprint(ids) != "[2]" || it.Err() != nil {
+		t.Errorf("unexpected resumed donations %v %v", ids, it.Err())
+	}
+}
@@ -0,0 +1,154 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultGraphVersion is the Graph API version of GraphAPIEndpoint.
+const defaultGraphVersion = "v2.8"
+
+// LiveConfig is configuration of an APIClient which can be changed while it is in use, for example by a config
+// watcher, so that a Graph API version bump or app secret rotation does not require a restart.
+// Anything not set on the LiveConfig is as configured by the options of the APIClient.
+//
+//	live := flannel.NewLiveConfig()
+//	c, err := flannel.CreateAPIClient(flannel.WithLiveConfig(live))
+//	live.SetGraphVersion("v3.0")
+type LiveConfig struct {
+	mu           sync.RWMutex
+	graphVersion string
+	appSecrets   *appSecrets
+	rateLimiter  RateLimiter
+	debug        *bool
+
+	strictDeprecation *bool
+}
+
+// NewLiveConfig creates a new LiveConfig with nothing set.
+func NewLiveConfig() *LiveConfig {
+	return &LiveConfig{}
+}
+
+// WithLiveConfig uses config to change the configuration of the APIClient while it is in use.
+func WithLiveConfig(config *LiveConfig) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.live = config
+		return nil
+	}
+}
+
+// SetGraphVersion sets the Graph API version, such as "v3.0", of API calls to GraphAPIEndpoint.
+// An empty version restores the version of GraphAPIEndpoint.
+func (l *LiveConfig) SetGraphVersion(version string) error {
+	if _, ok := parseGraphVersion(version); version != "" && !ok {
+		return fmt.Errorf("invalid graph api version %q", version)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.graphVersion = version
+	return nil
+}
+
+// SetAppSecrets sets the app secrets used to add an appsecret_proof to each API call, as WithAppSecretProof.
+// An empty secret stops adding an appsecret_proof.
+func (l *LiveConfig) SetAppSecrets(secret string, previous ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if secret == "" {
+		l.appSecrets = &appSecrets{}
+		return
+	}
+	l.appSecrets = &appSecrets{secrets: append([]string{secret}, previous...)}
+}
+
+// SetRateLimiter replaces the rate limiter capping the rate of all API calls, as WithRateLimiter.
+// The rate of a TokenBucket can also be changed in place with SetRate.
+func (l *LiveConfig) SetRateLimiter(limiter RateLimiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rateLimiter = limiter
+}
+
+// SetDebug sets whether all API calls are logged, or only failed ones, as the debug flag of WithLogger.
+func (l *LiveConfig) SetDebug(debug bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debug = &debug
+}
+
+// SetStrictDeprecation sets whether API calls returning deprecation signals fail, as WithStrictDeprecation.
+func (l *LiveConfig) SetStrictDeprecation(strict bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strictDeprecation = &strict
+}
+
+// debug returns whether all API calls are logged.
+func (c APIClient) debug() bool {
+	if c.live != nil {
+		c.live.mu.RLock()
+		defer c.live.mu.RUnlock()
+		if c.live.debug != nil {
+			return *c.live.debug
+		}
+	}
+	return c.debugModeEnabled
+}
+
+// currentAppSecrets returns the app secrets used to add an appsecret_proof, if any.
+func (c APIClient) currentAppSecrets() *appSecrets {
+	if c.live != nil {
+		c.live.mu.RLock()
+		defer c.live.mu.RUnlock()
+		if s := c.live.appSecrets; s != nil {
+			if len(s.secrets) == 0 {
+				return nil
+			}
+			return s
+		}
+	}
+	return c.appSecrets
+}
+
+// currentRateLimiter returns the rate limiter capping the rate of all API calls, if any.
+func (c APIClient) currentRateLimiter() RateLimiter {
+	if c.live != nil {
+		c.live.mu.RLock()
+		defer c.live.mu.RUnlock()
+		if c.live.rateLimiter != nil {
+			return c.live.rateLimiter
+		}
+	}
+	return c.rateLimiter
+}
+
+// graphVersion returns the Graph API version req is sent with, which is changed from the version of
+// GraphAPIEndpoint to any set on the LiveConfig.
+func (c APIClient) graphVersion(req *http.Request) string {
+	version := requestedVersion(req)
+	if c.live == nil || req.URL.Host != graphHost || version != defaultGraphVersion {
+		return version
+	}
+	c.live.mu.RLock()
+	defer c.live.mu.RUnlock()
+	if c.live.graphVersion != "" {
+		return c.live.graphVersion
+	}
+	return version
+}
+
+// withGraphVersion returns req changed to the Graph API version set on the LiveConfig.
+func (c APIClient) withGraphVersion(ctx context.Context, req *http.Request) *http.Request {
+	version := c.graphVersion(req)
+	if version == requestedVersion(req) {
+		return req
+	}
+	req = req.Clone(ctx)
+	req.URL.Path = "/" + version + strings.TrimPrefix(req.URL.Path, "/"+defaultGraphVersion)
+	req.URL.RawPath = ""
+	return req
+}
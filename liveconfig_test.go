@@ -0,0 +1,72 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestLiveConfig(t *testing.T) {
+	var paths, proofs []string
+	live := NewLiveConfig()
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		proofs = append(proofs, r.URL.Query().Get("appsecret_proof"))
+		fmt.Fprint(w, `{"id":"1"}`)
+	}), WithAppSecretProof("old"), WithLiveConfig(live))
+	ctx := context.Background()
+	get := func() {
+		if _, _, err := c.GetFundraiser(ctx, "token", "1"); err != nil {
+			t.Fatalf("failed to get fundraiser %v", err)
+		}
+	}
+
+	get()
+	if err := live.SetGraphVersion("v3.0"); err != nil {
+		t.Fatalf("failed to set graph version %v", err)
+	}
+	live.SetAppSecrets("new")
+	limited := 0
+	live.SetRateLimiter(RateLimiterFunc(func(ctx context.Context) error {
+		limited++
+		return nil
+	}))
+	get()
+	live.SetAppSecrets("")
+	get()
+
+	if paths[0] != "/v2.8/1" || paths[1] != "/v3.0/1" {
+		t.Errorf("expected graph version to change, got %v", paths)
+	}
+	if proofs[0] != appSecretProof("old", "token") || proofs[1] != appSecretProof("new", "token") || proofs[2] != "" {
+		t.Errorf("expected app secret to change, got %v", proofs)
+	}
+	if limited != 2 {
+		t.Errorf("expected new rate limiter to be used, got %d", limited)
+	}
+	if err := live.SetGraphVersion("latest"); err == nil {
+		t.Errorf("expected invalid graph version to fail")
+	}
+}
+
+func TestLiveConfigDisableAppSecretsConcurrently(t *testing.T) {
+	live := NewLiveConfig()
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	}), WithAppSecretProof("secret"), WithLiveConfig(live))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			live.SetAppSecrets("")
+			live.SetAppSecrets("secret")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if _, _, err := c.GetFundraiser(context.Background(), "token", "1"); err != nil {
+			t.Fatalf("failed to get fundraiser %v", err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,174 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContextLogger is implemented by Loggers which log request-scoped values, such as trace IDs,
+// from the context of the API call. If the Logger passed to WithLogger implements ContextLogger
+// then LogCtx is called instead of Logf.
+type ContextLogger interface {
+	Logger
+	LogCtx(ctx context.Context, format string, args ...interface{})
+}
+
+// The ContextLoggerFunc type is an adapter to allow the use of ordinary functions as ContextLoggers.
+type ContextLoggerFunc func(ctx context.Context, format string, args ...interface{})
+
+// Logf calls f(context.Background(), format, args...).
+func (f ContextLoggerFunc) Logf(format string, args ...interface{}) {
+	f(context.Background(), format, args...)
+}
+
+// LogCtx calls f(ctx, format, args...).
+func (f ContextLoggerFunc) LogCtx(ctx context.Context, format string, args ...interface{}) {
+	f(ctx, format, args...)
+}
+
+// NewSlogLogger returns a Logger which writes to l, for use with WithLogger.
+//
+// API calls are logged with the attributes method, url, status and, when available, request_id, body and error.
+// Failed API calls are logged at the Error level and all other API calls at the Debug level,
+// so l must be enabled for Debug to see the API calls logged in debug mode.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// Logf logs the formatted message at the Info level.
+func (s slogLogger) Logf(format string, args ...interface{}) {
+	s.LogCtx(context.Background(), format, args...)
+}
+
+// LogCtx logs the formatted message at the Info level with ctx.
+func (s slogLogger) LogCtx(ctx context.Context, format string, args ...interface{}) {
+	s.l.InfoContext(ctx, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+func (s slogLogger) logRequest(ctx context.Context, msg string, method string, url string, status int, body string, requestID string, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("url", url),
+	}
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	if len(body) > 0 {
+		attrs = append(attrs, slog.String("body", body))
+	}
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", redactString(err.Error())))
+	}
+	s.l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// WithRequestIDHeader sends the request ID set with ContextWithRequestID to Facebook in the header name,
+// such as X-Request-ID, so that API calls can be matched in any captured traffic.
+func WithRequestIDHeader(name string) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.requestIDHeader = http.CanonicalHeaderKey(name)
+		return nil
+	}
+}
+
+// WithDebugLogSampling reduces the volume of debug mode logging, so that it can remain enabled in production.
+// Successful API calls are logged with probability rate (0 to 1), failed API calls are always logged.
+// Logged response bodies are truncated to maxBodySize bytes, zero logs bodies in full.
+func WithDebugLogSampling(rate float64, maxBodySize int) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("invalid sampling rate %v", rate)
+		}
+		if maxBodySize < 0 {
+			return fmt.Errorf("invalid max body size %d", maxBodySize)
+		}
+		c.logSampling = &logSampling{rate: rate, maxBodySize: maxBodySize}
+		return nil
+	}
+}
+
+type logSampling struct {
+	rate        float64
+	maxBodySize int
+}
+
+// logRequest logs a request made by the APIClient if it failed or debug mode is enabled, redacting credentials
+// from the URL, body and error, and personal data from the body depending on the redaction level.
+// The msg describes the request, such as "facebook api", and status is zero if no response was received.
+func (c APIClient) logRequest(msg string, req *http.Request, status int, body []byte, err error) {
+	if c.logger == nil || !(c.debug() || err != nil) {
+		return
+	}
+	if err == nil && c.logSampling != nil && rand.Float64() >= c.logSampling.rate {
+		return
+	}
+	u := redactString(req.URL.String())
+	var b string
+	if len(body) > 0 {
+		b = c.redactBody(body)
+		if c.logSampling != nil && c.logSampling.maxBodySize > 0 && len(b) > c.logSampling.maxBodySize {
+			// truncate at the start of a rune, so that multi-byte characters are not split
+			n := c.logSampling.maxBodySize
+			for n > 0 && !utf8.RuneStart(b[n]) {
+				n--
+			}
+			b = fmt.Sprintf("%s... (%d bytes truncated)", b[:n], len(b)-n)
+		}
+	}
+	requestID, _ := RequestIDFromContext(req.Context())
+	if s, ok := c.logger.(slogLogger); ok {
+		s.logRequest(req.Context(), msg, req.Method, u, status, b, requestID, err)
+		return
+	}
+	if requestID != "" {
+		msg = msg + " " + requestID
+	}
+	switch {
+	case status == 0:
+		c.logf(req.Context(), "%s %s request to %s failed %s\n", msg, req.Method, u, redactString(err.Error()))
+	case len(b) > 0:
+		c.logf(req.Context(), "%s %s request to %s returned %d %s\n", msg, req.Method, u, status, b)
+	default:
+		c.logf(req.Context(), "%s %s request to %s returned %d\n", msg, req.Method, u, status)
+	}
+}
+
+// logMultipart logs the names and sizes of the parts of the multipart body of req in debug mode, so that failed
+// requests can be diagnosed without logging the values of fields or the content of files.
+func (c APIClient) logMultipart(msg string, req *http.Request, parts []multipartPart) {
+	if c.logger == nil || !c.debug() || len(parts) == 0 {
+		return
+	}
+	described := make([]string, len(parts))
+	for i, p := range parts {
+		if p.contentType == "" {
+			described[i] = fmt.Sprintf("%s (%d bytes)", p.name, p.size)
+			continue
+		}
+		described[i] = fmt.Sprintf("%s %s (%d bytes)", p.name, p.contentType, p.size)
+	}
+	c.logf(req.Context(), "%s %s request to %s sent multipart %s\n", msg, req.Method, redactString(req.URL.String()), strings.Join(described, ", "))
+}
+
+// logf logs using LogCtx if the logger is a ContextLogger, otherwise Logf.
+func (c APIClient) logf(ctx context.Context, format string, args ...interface{}) {
+	if l, ok := c.logger.(ContextLogger); ok {
+		l.LogCtx(ctx, format, args...)
+		return
+	}
+	c.logger.Logf(format, args...)
+}
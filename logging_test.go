@@ -0,0 +1,135 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var out bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"invalid"}}`))
+	}), WithLogger(NewSlogLogger(l), false))
+
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); err == nil {
+		t.Fatal("expected get fundraiser to fail")
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single json log record, got %s", out.String())
+	}
+	if record["level"] != "ERROR" || record["msg"] != "facebook api" || record["method"] != "GET" ||
+		record["status"] != float64(http.StatusBadRequest) || record["body"] == nil || record["error"] == nil {
+		t.Errorf("unexpected log record %v", record)
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	var tenants []string
+	logger := ContextLoggerFunc(func(ctx context.Context, format string, args ...interface{}) {
+		tenant, _ := TenantFromContext(ctx)
+		tenants = append(tenants, tenant)
+	})
+	c := newTestClient(t, http.HandlerFunc(fundraiserCreated), WithLogger(logger, true))
+
+	ctx := ContextWithTenant(context.Background(), "tenant")
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if _, _, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if len(tenants) != 3 || tenants[0] != "tenant" || tenants[1] != "tenant" || tenants[2] != "tenant" {
+		t.Errorf("expected the context of each api call and its multipart body to be logged, got %v", tenants)
+	}
+}
+
+func TestLogMultipart(t *testing.T) {
+	var logged []string
+	logger := LoggerFunc(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	c := newTestClient(t, http.HandlerFunc(fundraiserCreated), WithLogger(logger, true))
+
+	params := CreateFundraiserParams{AccessToken: "token", Title: "Donor Name", ExternalID: "external"}
+	_, _, err := c.CreateFundraiserWithContext(context.Background(), params,
+		WithFundraiserCoverPhotoImage("photo.png", bytes.NewReader(testPhoto(2048))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if len(logged) != 2 {
+		t.Fatalf("expected multipart body and response to be logged, got %v", logged)
+	}
+	if !strings.Contains(logged[0], "name (10 bytes), ") || !strings.Contains(logged[0], "external_id (8 bytes), ") || !strings.Contains(logged[0], "cover_photo image/png (2048 bytes)") {
+		t.Errorf("expected names and sizes of parts to be logged, got %s", logged[0])
+	}
+	if strings.Contains(logged[0], "Donor Name") || strings.Contains(logged[0], "photo.png") {
+		t.Errorf("expected values not to be logged, got %s", logged[0])
+	}
+}
+
+func TestDebugLogSampling(t *testing.T) {
+	var logged []string
+	logger := LoggerFunc(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	fail := false
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte(`{"id":"1234","description":"` + "x" + strings.Repeat("é", 1000) + `"}`))
+	}), WithLogger(logger, true), WithDebugLogSampling(0, 100))
+
+	c.GetFundraiser(context.Background(), "token", "1234")
+	if len(logged) != 0 {
+		t.Errorf("expected successful api call not to be sampled, got %v", logged)
+	}
+
+	fail = true
+	c.GetFundraiser(context.Background(), "token", "1234")
+	if len(logged) != 1 {
+		t.Fatalf("expected failed api call to be logged, got %v", logged)
+	}
+	if len(logged[0]) > 300 || !strings.Contains(logged[0], "truncated") || !utf8.ValidString(logged[0]) {
+		t.Errorf("expected body to be truncated at the start of a rune, got %s", logged[0])
+	}
+
+	if _, err := CreateAPIClient(WithDebugLogSampling(2, 0)); err == nil {
+		t.Error("expected invalid sampling rate to fail")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var logged []string
+	logger := LoggerFunc(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	var header string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Request-ID")
+		fundraiserCreated(w, r)
+	}), WithLogger(logger, true), WithRequestIDHeader("x-request-id"))
+
+	ctx := ContextWithRequestID(context.Background(), "abc123")
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil {
+		t.Fatalf("failed to get fundraiser %v", err)
+	}
+	if header != "abc123" {
+		t.Errorf("expected request id header abc123, got %q", header)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "abc123") {
+		t.Errorf("expected request id to be logged, got %v", logged)
+	}
+}
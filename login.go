@@ -0,0 +1,227 @@
+package flannel
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Facebook Login endpoints.
+const (
+	LoginDialogEndpoint = "https://www.facebook.com/v2.8/dialog/oauth"
+	AccessTokenEndpoint = GraphAPIEndpoint + "/oauth/access_token"
+)
+
+// loginStateCookieName is the cookie holding the state of a login in progress.
+const loginStateCookieName = "flannel_login_state"
+
+// LoginSettings configures a LoginHandler.
+type LoginSettings struct {
+
+	// AppID and AppSecret identify the Facebook app.
+	AppID     string
+	AppSecret string
+
+	// RedirectURL is the URL of the callback handler, which must be a valid OAuth redirect URI of the app.
+	RedirectURL string
+
+	// Scopes are the permissions requested from the user, such as ScopeManageFundraisers.
+	Scopes []string
+
+	// RequireScopes checks that users granted all Scopes, failing login if any were declined, so that missing
+	// permissions are caught at login rather than by later API calls. Use MissingScopes to find those declined.
+	RequireScopes bool
+
+	// OnToken is called with the access token of each user completing login, typically to store it for use
+	// with CreateFundraiser. If it returns an error the login fails.
+	OnToken func(ctx context.Context, token LoginToken) error
+
+	// SuccessURL is where users are redirected once login completes, defaults to "/".
+	SuccessURL string
+
+	// OnError is called when login fails to respond to the user, by default a 400 or 502 error is returned.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// LoginToken is the user access token returned by Facebook Login.
+type LoginToken struct {
+	AccessToken string
+	TokenType   string
+
+	// Expiry is when the access token expires, zero if unknown.
+	Expiry time.Time
+
+	// UserID is the Facebook user ID of the user the access token belongs to, if known.
+	UserID string
+}
+
+// A LoginHandler implements the server-side Facebook Login flow.
+// The Redirect handler sends users to the Facebook Login dialog, and the Callback handler, served at
+// RedirectURL, validates the state and exchanges the code returned by Facebook for an access token.
+//
+//	login := c.NewLoginHandler(flannel.LoginSettings{...})
+//	http.Handle("/login", login.Redirect())
+//	http.Handle("/login/callback", login.Callback())
+type LoginHandler struct {
+	c        APIClient
+	settings LoginSettings
+}
+
+// NewLoginHandler creates a new LoginHandler which exchanges codes for access tokens using c.
+func (c APIClient) NewLoginHandler(settings LoginSettings) *LoginHandler {
+	if settings.SuccessURL == "" {
+		settings.SuccessURL = "/"
+	}
+	return &LoginHandler{c: c, settings: settings}
+}
+
+type loginError struct {
+	status int
+	denied bool
+	err    error
+}
+
+func (e loginError) Error() string {
+	return e.err.Error()
+}
+
+func (e loginError) Unwrap() error {
+	return e.err
+}
+
+// IsLoginDenied returns true if err is from a user declining Facebook Login, or any of the scopes required.
+func IsLoginDenied(err error) bool {
+	var le loginError
+	return errors.As(err, &le) && le.denied
+}
+
+// Redirect returns a handler redirecting users to the Facebook Login dialog, with a state stored in a cookie.
+func (h *LoginHandler) Redirect() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := newJobID()
+		if err != nil {
+			h.fail(w, r, loginError{status: http.StatusInternalServerError, err: err})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     loginStateCookieName,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(h.settings.RedirectURL, "https:"),
+			SameSite: http.SameSiteLaxMode,
+		})
+		query := url.Values{
+			"client_id":     {h.settings.AppID},
+			"redirect_uri":  {h.settings.RedirectURL},
+			"state":         {state},
+			"response_type": {"code"},
+		}
+		if len(h.settings.Scopes) > 0 {
+			query.Set("scope", strings.Join(h.settings.Scopes, ","))
+		}
+		http.Redirect(w, r, LoginDialogEndpoint+"?"+query.Encode(), http.StatusFound)
+	})
+}
+
+// Callback returns a handler completing login once Facebook redirects users back to RedirectURL.
+func (h *LoginHandler) Callback() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		cookie, err := r.Cookie(loginStateCookieName)
+		if err != nil || cookie.Value == "" || !hmac.Equal([]byte(cookie.Value), []byte(query.Get("state"))) {
+			h.fail(w, r, loginError{status: http.StatusBadRequest, err: errors.New("invalid login state")})
+			return
+		}
+		// the state can only be used once
+		http.SetCookie(w, &http.Cookie{Name: loginStateCookieName, Path: "/", MaxAge: -1})
+		if reason := query.Get("error"); reason != "" {
+			h.fail(w, r, loginError{status: http.StatusBadRequest, denied: reason == "access_denied",
+				err: fmt.Errorf("login failed %s %s", reason, query.Get("error_description"))})
+			return
+		}
+		token, err := h.Exchange(r.Context(), query.Get("code"))
+		if err != nil {
+			h.fail(w, r, loginError{status: http.StatusBadGateway, err: err})
+			return
+		}
+		if h.settings.RequireScopes {
+			if err := h.c.CheckScopes(r.Context(), token.AccessToken, h.settings.Scopes...); err != nil {
+				status := http.StatusBadGateway
+				if MissingScopes(err) != nil {
+					status = http.StatusForbidden
+				}
+				h.fail(w, r, loginError{status: status, denied: status == http.StatusForbidden, err: err})
+				return
+			}
+		}
+		if h.settings.OnToken != nil {
+			if err := h.settings.OnToken(r.Context(), token); err != nil {
+				h.fail(w, r, loginError{status: http.StatusInternalServerError, err: err})
+				return
+			}
+		}
+		http.Redirect(w, r, h.settings.SuccessURL, http.StatusFound)
+	})
+}
+
+// Exchange exchanges a code returned by the Facebook Login dialog for a user access token.
+func (h *LoginHandler) Exchange(ctx context.Context, code string) (LoginToken, error) {
+	if code == "" {
+		return LoginToken{}, errors.New("missing login code")
+	}
+	return h.c.accessToken(ctx, url.Values{
+		"client_id":     {h.settings.AppID},
+		"client_secret": {h.settings.AppSecret},
+		"redirect_uri":  {h.settings.RedirectURL},
+		"code":          {code},
+	})
+}
+
+// accessToken requests an access token from the AccessTokenEndpoint with query.
+// Login codes can only be exchanged once, so the request is subject to rate limiting and the circuit breaker,
+// but is never hedged, retried or cached.
+func (c APIClient) accessToken(ctx context.Context, query url.Values) (LoginToken, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return LoginToken{}, err
+	}
+	defer release()
+	req, err := http.NewRequestWithContext(ctx, "GET", AccessTokenEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return LoginToken{}, fmt.Errorf("error preparing request %v", err)
+	}
+	req = c.withGraphVersion(ctx, req)
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return LoginToken{}, err
+	}
+	_, result, err := c.readResponse(AccessTokenEndpoint, req, res, http.StatusOK)
+	if err != nil {
+		return LoginToken{}, err
+	}
+	token := LoginToken{}
+	token.AccessToken, _ = result["access_token"].(string)
+	token.TokenType, _ = result["token_type"].(string)
+	if expiresIn, ok := result["expires_in"].(float64); ok && expiresIn > 0 {
+		token.Expiry = c.now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	if token.AccessToken == "" {
+		return LoginToken{}, errors.New("missing access token")
+	}
+	return token, nil
+}
+
+func (h *LoginHandler) fail(w http.ResponseWriter, r *http.Request, err loginError) {
+	if h.settings.OnError != nil {
+		h.settings.OnError(w, r, err)
+		return
+	}
+	http.Error(w, http.StatusText(err.status), err.status)
+}
@@ -0,0 +1,103 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginHandler(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2.8/oauth/access_token" || r.FormValue("code") != "abc" || r.FormValue("client_secret") != "secret" {
+			t.Errorf("unexpected code exchange %s", r.URL)
+		}
+		fmt.Fprint(w, `{"access_token":"user-token","token_type":"bearer","expires_in":5183944}`)
+	}))
+	var token LoginToken
+	login := c.NewLoginHandler(LoginSettings{
+		AppID:       "app",
+		AppSecret:   "secret",
+		RedirectURL: "https://example.com/login/callback",
+		Scopes:      []string{"public_profile"},
+		OnToken: func(ctx context.Context, t LoginToken) error {
+			token = t
+			return nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	login.Redirect().ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+	location, _ := url.Parse(w.Header().Get("Location"))
+	state := location.Query().Get("state")
+	if w.Code != http.StatusFound || state == "" || location.Query().Get("redirect_uri") != "https://example.com/login/callback" {
+		t.Fatalf("unexpected redirect %d %s", w.Code, location)
+	}
+	cookie := w.Result().Cookies()[0]
+
+	callback := func(query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/login/callback?"+query, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		login.Callback().ServeHTTP(w, r)
+		return w
+	}
+	if w := callback("code=abc&state=forged", cookie); w.Code != http.StatusBadRequest {
+		t.Errorf("expected forged state to be rejected, got %d", w.Code)
+	}
+	if w := callback("code=abc&state="+state, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected missing state cookie to be rejected, got %d", w.Code)
+	}
+	if w := callback("code=abc&state="+state, cookie); w.Code != http.StatusFound || w.Header().Get("Location") != "/" {
+		t.Errorf("unexpected callback response %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if token.AccessToken != "user-token" || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	var denied error
+	login.settings.OnError = func(w http.ResponseWriter, r *http.Request, err error) { denied = err }
+	callback("error=access_denied&error_reason=user_denied&state="+state, cookie)
+	if !IsLoginDenied(denied) {
+		t.Errorf("expected login denied error, got %v", denied)
+	}
+}
+
+func TestLoginExchangeSentOnce(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// respond slowly, so that a hedged request would be sent
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("ETag", `"token"`)
+		fmt.Fprint(w, `{"access_token":"user-token"}`)
+	}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithHedgedReads(HedgingSettings{InitialDelay: time.Millisecond, MinDelay: time.Millisecond}),
+		WithResponseCache(NewMemoryCache(10)),
+	)
+	login := c.NewLoginHandler(LoginSettings{AppID: "app", AppSecret: "secret"})
+	if _, err := login.Exchange(context.Background(), "abc"); err == nil {
+		t.Error("expected failed code exchange to return an error")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected code exchange to be sent once, got %d requests", n)
+	}
+	for i := 0; i < 2; i++ {
+		if token, err := login.Exchange(context.Background(), "abc"); err != nil || token.AccessToken != "user-token" {
+			t.Errorf("unexpected token %+v %v", token, err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("expected code exchanges not to be cached, got %d requests", n)
+	}
+}
@@ -0,0 +1,67 @@
+package flannel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DebugTokenEndpoint is the Facebook API endpoint used to inspect access tokens.
+const DebugTokenEndpoint = GraphAPIEndpoint + "/debug_token"
+
+// GetCharity reads the details of a Facebook Charity.
+// The fields to return can optionally be set with fields, otherwise Facebook returns its default fields.
+// Results are cached per access token, as the fields visible may depend on the user.
+func (c APIClient) GetCharity(ctx context.Context, accessToken string, charityID string, fields ...string) (status int, result map[string]interface{}, err error) {
+	h := sha256.Sum256([]byte(accessToken))
+	key := "flannel:charity:" + charityID + ":" + strings.Join(fields, ",") + ":" + hex.EncodeToString(h[:8])
+	return c.cachedMetadata(ctx, key, func() (int, map[string]interface{}, error) {
+		return c.get(ctx, accessToken, GraphAPIEndpoint+"/"+url.PathEscape(charityID), fieldsQuery(fields))
+	})
+}
+
+// DebugToken inspects inputToken, returning metadata such as its app, user, scopes and expiry.
+// The appAccessToken is the access token of the app (or an app developer) making the call.
+// See https://developers.facebook.com/docs/graph-api/reference/debug_token/
+func (c APIClient) DebugToken(ctx context.Context, appAccessToken string, inputToken string) (status int, result map[string]interface{}, err error) {
+	h := sha256.Sum256([]byte(inputToken))
+	key := "flannel:debug_token:" + hex.EncodeToString(h[:])
+	return c.cachedMetadata(ctx, key, func() (int, map[string]interface{}, error) {
+		return c.get(ctx, appAccessToken, DebugTokenEndpoint, url.Values{"input_token": {inputToken}})
+	})
+}
+
+// WithMetadataCache caches the results of GetCharity and DebugToken for ttl, as charity
+// details and access token metadata rarely change. The cache can be shared between APIClients
+// using an implementation such as Redis, or use NewMemoryCache for an in-memory cache.
+func WithMetadataCache(cache Cache, ttl time.Duration) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.metadataCache = cache
+		c.metadataCacheTTL = ttl
+		return nil
+	}
+}
+
+// cachedMetadata returns the cached result for key, otherwise it calls fetch and caches a successful result.
+func (c APIClient) cachedMetadata(ctx context.Context, key string, fetch func() (int, map[string]interface{}, error)) (status int, result map[string]interface{}, err error) {
+	if c.metadataCache == nil {
+		return fetch()
+	}
+	if v, hit := c.metadataCache.Get(ctx, key); hit {
+		if err := json.Unmarshal(v, &result); err == nil {
+			return http.StatusOK, result, nil
+		}
+	}
+	status, result, err = fetch()
+	if err == nil {
+		if v, err := json.Marshal(result); err == nil {
+			c.metadataCache.Set(ctx, key, v, c.metadataCacheTTL)
+		}
+	}
+	return status, result, err
+}
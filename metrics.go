@@ -0,0 +1,78 @@
+package flannel
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Metrics is implemented to export metrics of the API calls made by an APIClient to a metrics backend.
+// Endpoints are reported without the Graph API version and with ids replaced by {id}, as for Stats.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+
+	// ObserveRequest is called for each HTTP request sent, status is zero if no response was received.
+	ObserveRequest(method string, endpoint string, status int, latency time.Duration)
+
+	// ObserveError is called for each Facebook error returned by an API call.
+	ObserveError(method string, endpoint string, code int, subcode int)
+
+	// ObserveRetry is called each time a failed HTTP request is retried.
+	ObserveRetry(method string, endpoint string)
+
+	// ObserveRateLimitWait is called with the time spent waiting on the rate limiters before each HTTP request,
+	// tenant is empty unless the API call was made with ContextWithTenant.
+	ObserveRateLimitWait(tenant string, wait time.Duration)
+
+	// ObserveAppUsage is called with the percentages of the app's rate limit used, as reported by Facebook in the
+	// X-App-Usage header. See https://developers.facebook.com/docs/graph-api/overview/rate-limiting/
+	ObserveAppUsage(callCount float64, totalCPUTime float64, totalTime float64)
+}
+
+// WithMetrics exports metrics of the API calls made by the APIClient using m.
+func WithMetrics(m Metrics) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.metrics = m
+		return nil
+	}
+}
+
+type appUsage struct {
+	CallCount    float64 `json:"call_count"`
+	TotalCPUTime float64 `json:"total_cputime"`
+	TotalTime    float64 `json:"total_time"`
+}
+
+// observeResponse reports the outcome of req, and the app usage reported by Facebook, to the metrics.
+func (c APIClient) observeResponse(req *http.Request, res *http.Response, latency time.Duration) {
+	if c.metrics == nil {
+		return
+	}
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+		if h := res.Header.Get("X-App-Usage"); h != "" {
+			var usage appUsage
+			if err := json.Unmarshal([]byte(h), &usage); err == nil {
+				c.metrics.ObserveAppUsage(usage.CallCount, usage.TotalCPUTime, usage.TotalTime)
+			}
+		}
+	}
+	c.metrics.ObserveRequest(req.Method, statsEndpoint(req), status, latency)
+}
+
+// observeError reports a Facebook error returned by req to the metrics and any ErrorSummary.
+func (c APIClient) observeError(req *http.Request, err error) {
+	if c.metrics == nil && c.errorSummary == nil {
+		return
+	}
+	if fe, ok := err.(facebookError); ok {
+		code, subcode := fe.ErrorCodes()
+		if c.metrics != nil {
+			c.metrics.ObserveError(req.Method, statsEndpoint(req), code, subcode)
+		}
+		if c.errorSummary != nil {
+			c.errorSummary.observe(req.Method, statsEndpoint(req), code, subcode)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	mu       sync.Mutex
+	requests []int
+	errors   []int
+	retries  int
+	waits    int
+	usage    float64
+}
+
+func (m *testMetrics) ObserveRequest(method string, endpoint string, status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, status)
+}
+
+func (m *testMetrics) ObserveError(method string, endpoint string, code int, subcode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = append(m.errors, code)
+}
+
+func (m *testMetrics) ObserveRetry(method string, endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *testMetrics) ObserveRateLimitWait(tenant string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits++
+}
+
+func (m *testMetrics) ObserveAppUsage(callCount float64, totalCPUTime float64, totalTime float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage = callCount
+}
+
+func TestMetrics(t *testing.T) {
+	calls := 0
+	m := &testMetrics{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-App-Usage", `{"call_count":28,"total_time":25,"total_cputime":25}`)
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"invalid","code":100}}`))
+	}), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}), WithMetrics(m))
+
+	c.GetFundraiser(context.Background(), "token", "1234")
+
+	if len(m.requests) != 2 || m.requests[0] != http.StatusServiceUnavailable || m.requests[1] != http.StatusBadRequest {
+		t.Errorf("unexpected requests %v", m.requests)
+	}
+	if len(m.errors) != 1 || m.errors[0] != 100 {
+		t.Errorf("unexpected errors %v", m.errors)
+	}
+	if m.retries != 1 || m.waits != 2 || m.usage != 28 {
+		t.Errorf("unexpected retries %d, rate limit waits %d or usage %v", m.retries, m.waits, m.usage)
+	}
+}
@@ -0,0 +1,201 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MirroredFundraiser is a fundraiser as copied to a MirrorStore.
+type MirroredFundraiser struct {
+	ID           string
+	ExternalID   string
+	Name         string
+	Description  string
+	GoalAmount   int64
+	AmountRaised int64
+	Currency     string
+	EndTime      time.Time
+
+	// MirroredAt is when the fundraiser was last read from Facebook.
+	MirroredAt time.Time
+}
+
+// MirroredDonation is a donation as copied to a MirrorStore.
+type MirroredDonation struct {
+	ID           string
+	FundraiserID string
+	Amount       int64
+	Currency     string
+	CreatedTime  time.Time
+}
+
+// A MirrorStore is a local copy of fundraisers and donations, kept up to date by an APIClient configured with
+// WithMirrorStore, so that applications can query them without making API calls. Saves replace any fundraiser
+// or donation with the same id. Implementations must be safe for concurrent use.
+type MirrorStore interface {
+	SaveFundraiser(ctx context.Context, f MirroredFundraiser) error
+	SaveDonation(ctx context.Context, d MirroredDonation) error
+
+	// Fundraiser returns the fundraiser with id, and false if it is not found.
+	Fundraiser(ctx context.Context, id string) (MirroredFundraiser, bool, error)
+
+	// Fundraisers returns all fundraisers, ordered by id.
+	Fundraisers(ctx context.Context) ([]MirroredFundraiser, error)
+
+	// Donations returns the donations to the fundraiser with fundraiserID, oldest first.
+	Donations(ctx context.Context, fundraiserID string) ([]MirroredDonation, error)
+}
+
+// A MemoryMirrorStore is an in-memory MirrorStore, its contents are lost when the process exits.
+type MemoryMirrorStore struct {
+	mu          sync.Mutex
+	fundraisers map[string]MirroredFundraiser
+	donations   map[string]MirroredDonation
+}
+
+// NewMemoryMirrorStore creates a new MemoryMirrorStore.
+func NewMemoryMirrorStore() *MemoryMirrorStore {
+	return &MemoryMirrorStore{fundraisers: make(map[string]MirroredFundraiser), donations: make(map[string]MirroredDonation)}
+}
+
+// SaveFundraiser saves f.
+func (s *MemoryMirrorStore) SaveFundraiser(ctx context.Context, f MirroredFundraiser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fundraisers[f.ID] = f
+	return nil
+}
+
+// SaveDonation saves d.
+func (s *MemoryMirrorStore) SaveDonation(ctx context.Context, d MirroredDonation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.donations[d.ID] = d
+	return nil
+}
+
+// Fundraiser returns the fundraiser with id.
+func (s *MemoryMirrorStore) Fundraiser(ctx context.Context, id string) (MirroredFundraiser, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, exists := s.fundraisers[id]
+	return f, exists, nil
+}
+
+// Fundraisers returns all fundraisers, ordered by id.
+func (s *MemoryMirrorStore) Fundraisers(ctx context.Context) ([]MirroredFundraiser, error) {
+	s.mu.Lock()
+	fundraisers := make([]MirroredFundraiser, 0, len(s.fundraisers))
+	for _, f := range s.fundraisers {
+		fundraisers = append(fundraisers, f)
+	}
+	s.mu.Unlock()
+	sort.Slice(fundraisers, func(i, j int) bool { return fundraisers[i].ID < fundraisers[j].ID })
+	return fundraisers, nil
+}
+
+// Donations returns the donations to the fundraiser with fundraiserID, oldest first.
+func (s *MemoryMirrorStore) Donations(ctx context.Context, fundraiserID string) ([]MirroredDonation, error) {
+	s.mu.Lock()
+	var donations []MirroredDonation
+	for _, d := range s.donations {
+		if d.FundraiserID == fundraiserID {
+			donations = append(donations, d)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(donations, func(i, j int) bool {
+		if !donations[i].CreatedTime.Equal(donations[j].CreatedTime) {
+			return donations[i].CreatedTime.Before(donations[j].CreatedTime)
+		}
+		return donations[i].ID < donations[j].ID
+	})
+	return donations, nil
+}
+
+// WithMirrorStore keeps store up to date with the fundraisers changed by ApplySync, and those read by RefreshMirror
+// and the events passed to MirrorSink.
+func WithMirrorStore(store MirrorStore) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.mirror = store
+		return nil
+	}
+}
+
+var errNoMirrorStore = errors.New("mirror store is not configured")
+
+// mirrorFields are the fields of fundraisers read to mirror them.
+var mirrorFields = []string{"id", "external_id", "name", "description", "goal_amount", "amount_raised", "currency", "end_time"}
+
+// RefreshMirror copies all the fundraisers created by the user identified by accessToken to the MirrorStore,
+// returning the number copied. Donations are mirrored as they are received by MirrorSink.
+// It requires the manage_fundraisers scope.
+func (c APIClient) RefreshMirror(ctx context.Context, accessToken string) (int, error) {
+	if c.mirror == nil {
+		return 0, errNoMirrorStore
+	}
+	ctx = backgroundContext(ctx)
+	it := c.ListFundraisers(ctx, accessToken, mirrorFields...)
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		if err := c.mirror.SaveFundraiser(ctx, c.mirroredFundraiser(it.Item())); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, it.Err()
+}
+
+// MirrorSink returns an EventSink saving the donations of DonationCreated events to the MirrorStore, and
+// reading the fundraisers of FundraiserUpdated events using accessToken to save them. It can be used with
+// PublishTo for webhooks, or as the OnEvent of a DonationPoller.
+func (c APIClient) MirrorSink(accessToken string) EventSink {
+	return EventSinkFunc(func(ctx context.Context, event WebhookEvent) error {
+		if c.mirror == nil {
+			return errNoMirrorStore
+		}
+		switch {
+		case event.Donation != nil:
+			d := MirroredDonation{
+				ID:           event.Donation.ID,
+				FundraiserID: event.Donation.FundraiserID,
+				Amount:       event.Donation.Amount,
+				Currency:     event.Donation.Currency,
+			}
+			d.CreatedTime, _ = parseGraphTime(event.Donation.CreatedTime)
+			return c.mirror.SaveDonation(ctx, d)
+		case event.Fundraiser != nil:
+			return c.mirrorFundraiser(ctx, accessToken, event.Fundraiser.ID)
+		}
+		return nil
+	})
+}
+
+// mirrorFundraiser reads the fundraiser with fundraiserID and saves it to the MirrorStore.
+func (c APIClient) mirrorFundraiser(ctx context.Context, accessToken string, fundraiserID string) error {
+	_, result, err := c.GetFundraiser(ctx, accessToken, fundraiserID, mirrorFields...)
+	if err != nil {
+		return err
+	}
+	return c.mirror.SaveFundraiser(ctx, c.mirroredFundraiser(result))
+}
+
+// mirroredFundraiser converts a fundraiser read from Facebook.
+func (c APIClient) mirroredFundraiser(item map[string]interface{}) MirroredFundraiser {
+	f := MirroredFundraiser{MirroredAt: c.now()}
+	f.ID, _ = item["id"].(string)
+	f.ExternalID, _ = item["external_id"].(string)
+	f.Name, _ = item["name"].(string)
+	f.Description, _ = item["description"].(string)
+	goal, _ := item["goal_amount"].(float64)
+	f.GoalAmount = int64(goal)
+	raised, _ := item["amount_raised"].(float64)
+	f.AmountRaised = int64(raised)
+	f.Currency, _ = item["currency"].(string)
+	f.EndTime, _ = syncEndTime(item)
+	return f
+}
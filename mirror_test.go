@@ -0,0 +1,53 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMirrorStore(t *testing.T) {
+	raised := 100
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/me/fundraisers") {
+			fmt.Fprintf(w, `{"data":[{"id":"2","external_id":"ext-2","name":"Run"},{"id":"1","external_id":"ext-1","amount_raised":%d,"currency":"GBP","end_time":"2026-01-02T15:04:05+0000"}]}`, raised)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"1","external_id":"ext-1","amount_raised":%d,"currency":"GBP"}`, raised)
+	}))
+	ctx := context.Background()
+	if _, err := c.RefreshMirror(ctx, "token"); err != errNoMirrorStore {
+		t.Errorf("expected error without a mirror store, got %v", err)
+	}
+	store := NewMemoryMirrorStore()
+	WithMirrorStore(store)(&c)
+
+	if n, err := c.RefreshMirror(ctx, "token"); err != nil || n != 2 {
+		t.Fatalf("expected fundraisers to be mirrored, got %d %v", n, err)
+	}
+	fundraisers, _ := store.Fundraisers(ctx)
+	if len(fundraisers) != 2 || fundraisers[0].ID != "1" || fundraisers[0].AmountRaised != 100 || fundraisers[0].EndTime.Year() != 2026 || fundraisers[1].Name != "Run" {
+		t.Errorf("unexpected fundraisers %+v", fundraisers)
+	}
+
+	raised = 600
+	sink := c.MirrorSink("token")
+	for _, event := range []WebhookEvent{
+		{Type: DonationCreated, Donation: &WebhookDonation{ID: "d2", FundraiserID: "1", Amount: 300, CreatedTime: "2026-01-02T16:00:00+0000"}},
+		{Type: DonationCreated, Donation: &WebhookDonation{ID: "d1", FundraiserID: "1", Amount: 200, CreatedTime: "2026-01-02T15:30:00+0000"}},
+		{Type: FundraiserUpdated, Fundraiser: &WebhookFundraiser{ID: "1", ChangedFields: []string{"amount_raised"}}},
+	} {
+		if err := sink.Publish(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f, exists, _ := store.Fundraiser(ctx, "1"); !exists || f.AmountRaised != 600 {
+		t.Errorf("expected updated fundraiser to be mirrored, got %+v", f)
+	}
+	donations, _ := store.Donations(ctx, "1")
+	if len(donations) != 2 || donations[0].ID != "d1" || donations[1].Amount != 300 {
+		t.Errorf("unexpected donations %+v", donations)
+	}
+}
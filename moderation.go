@@ -0,0 +1,110 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// FundraiserContent is the user supplied content of a fundraiser, checked before it is created or updated.
+type FundraiserContent struct {
+
+	// ExternalID is set when creating a fundraiser, FundraiserID when updating one.
+	ExternalID   string
+	FundraiserID string
+
+	Title       string
+	Description string
+
+	// CoverPhoto opens the cover photo after any transforms are applied, it is nil if there is no cover photo.
+	CoverPhoto func() (io.ReadCloser, error)
+}
+
+// A ContentChecker checks fundraiser content, typically with a moderation service, before it is sent to Facebook.
+// Returning an error blocks the fundraiser from being created or updated.
+type ContentChecker interface {
+	CheckContent(ctx context.Context, content FundraiserContent) error
+}
+
+// The ContentCheckerFunc type is an adapter to allow the use of ordinary functions as ContentCheckers.
+// If f is a function with the appropriate signature, ContentCheckerFunc(f) is a ContentChecker that calls f.
+type ContentCheckerFunc func(ctx context.Context, content FundraiserContent) error
+
+// CheckContent calls f(ctx, content).
+func (f ContentCheckerFunc) CheckContent(ctx context.Context, content FundraiserContent) error {
+	return f(ctx, content)
+}
+
+// WithContentChecker checks the content of fundraisers with checker before they are created or updated.
+// Cover photos which cannot be read more than once, such as those added with WithFundraiserCoverPhotoImage
+// from a reader which is not an io.Seeker, are held in memory so that they can be both checked and sent.
+func WithContentChecker(checker ContentChecker) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.contentChecker = checker
+		return nil
+	}
+}
+
+type contentRejectedError struct {
+	err error
+}
+
+func (e contentRejectedError) Error() string {
+	return fmt.Sprintf("fundraiser content rejected %v", e.err)
+}
+
+func (e contentRejectedError) Unwrap() error {
+	return e.err
+}
+
+// IsContentRejected returns true if err was returned because a ContentChecker rejected the content of a fundraiser.
+func IsContentRejected(err error) bool {
+	var e contentRejectedError
+	return errors.As(err, &e)
+}
+
+// checkContent checks content with any ContentChecker.
+func (c APIClient) checkContent(ctx context.Context, content FundraiserContent) error {
+	if c.contentChecker == nil {
+		return nil
+	}
+	if err := c.contentChecker.CheckContent(ctx, content); err != nil {
+		return contentRejectedError{err}
+	}
+	return nil
+}
+
+// checkContent checks the content of the form with any ContentChecker of its client.
+func (f *fundraiserForm) checkContent(params CreateFundraiserParams) error {
+	if f.client.contentChecker == nil {
+		return nil
+	}
+	content := FundraiserContent{ExternalID: params.ExternalID, Title: params.Title, Description: params.Description}
+	for i := range f.files {
+		file := &f.files[i]
+		if file.fieldName != "cover_photo" {
+			continue
+		}
+		if !file.replayable {
+			// read the cover photo into memory, so that it can be read by both the checker and the request
+			r, err := file.open()
+			if err != nil {
+				return file.error(err)
+			}
+			b, err := ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return file.error(err)
+			}
+			file.open = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(b)), nil
+			}
+			file.replayable = true
+		}
+		content.CoverPhoto = file.open
+	}
+	return f.client.checkContent(f.ctx, content)
+}
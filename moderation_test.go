@@ -0,0 +1,52 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestContentChecker(t *testing.T) {
+	photo := testPhoto(1024)
+	var uploaded []byte
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if file, _, err := r.FormFile("cover_photo"); err == nil {
+			uploaded, _ = ioutil.ReadAll(file)
+		}
+		fundraiserCreated(w, r)
+	}), WithContentChecker(ContentCheckerFunc(func(ctx context.Context, content FundraiserContent) error {
+		if content.Title == "Blocked" {
+			return errors.New("policy violation")
+		}
+		if content.CoverPhoto != nil {
+			r, err := content.CoverPhoto()
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			if checked, _ := ioutil.ReadAll(r); !bytes.Equal(checked, photo) {
+				t.Errorf("unexpected cover photo of %d bytes checked", len(checked))
+			}
+		}
+		return nil
+	})))
+
+	// a reader which cannot be rewound is read by both the checker and the request
+	reader := struct{ *bytes.Reader }{bytes.NewReader(photo)}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Test"}, WithFundraiserCoverPhotoImage("photo.jpg", reader)); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if !bytes.Equal(uploaded, photo) {
+		t.Errorf("unexpected cover photo of %d bytes uploaded", len(uploaded))
+	}
+
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{Title: "Blocked"}); !IsContentRejected(err) {
+		t.Errorf("expected content to be rejected, got %v", err)
+	}
+	if _, _, err := c.UpdateFundraiser(context.Background(), "token", "1", map[string]string{"name": "Blocked"}); !IsContentRejected(err) {
+		t.Errorf("expected update to be rejected, got %v", err)
+	}
+}
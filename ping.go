@@ -0,0 +1,53 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PingResult describes the response to a Ping.
+type PingResult struct {
+
+	// Latency is the time taken for the Facebook API to respond.
+	Latency time.Duration
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// APIVersion is the Graph API version which handled the request, as reported by Facebook.
+	APIVersion string
+}
+
+// Ping makes a cheap call to the Facebook API to check connectivity, for use in readiness probes and smoke tests.
+// When accessToken is set the call must succeed, confirming the token is valid, otherwise
+// any response from Facebook (including an authentication error) confirms the API is reachable.
+// Ping is subject to rate limiting and the circuit breaker, but is never retried or hedged.
+func (c APIClient) Ping(ctx context.Context, accessToken string) (PingResult, error) {
+	endpoint := GraphAPIEndpoint + "/me"
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?fields=id", nil)
+	if err != nil {
+		return PingResult{}, fmt.Errorf("error preparing request %v", err)
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	start := time.Now()
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return PingResult{}, err
+	}
+	result := PingResult{
+		Latency:    time.Since(start),
+		Status:     res.StatusCode,
+		APIVersion: res.Header.Get("facebook-api-version"),
+	}
+	if accessToken == "" && res.StatusCode < http.StatusInternalServerError {
+		c.readResponse(endpoint, req, res, res.StatusCode)
+		return result, nil
+	}
+	_, _, err = c.readResponse(endpoint, req, res, http.StatusOK)
+	return result, err
+}
@@ -0,0 +1,33 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("facebook-api-version", "v2.8")
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"An active access token must be used","code":2500}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1234"}`))
+	}))
+
+	for _, token := range []string{"", "token"} {
+		result, err := c.Ping(context.Background(), token)
+		if err != nil {
+			t.Fatalf("failed to ping with token %q %v", token, err)
+		}
+		if result.APIVersion != "v2.8" || result.Latency <= 0 {
+			t.Errorf("unexpected ping result %+v", result)
+		}
+	}
+
+	if _, err := c.Ping(context.Background(), "invalid"); err == nil {
+		t.Error("expected ping with an invalid token to fail")
+	}
+}
@@ -0,0 +1,295 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DonationPollerSettings configures a DonationPoller.
+type DonationPollerSettings struct {
+
+	// AccessToken is used to list the donations of fundraisers tracked without an access token.
+	AccessToken string
+
+	// OnEvent is called with a DonationCreated event for each new donation, as for webhooks. If it returns an error
+	// the donation is passed to OnEvent again by the next poll.
+	OnEvent func(ctx context.Context, event WebhookEvent) error
+
+	// OnError is called with errors polling, which are otherwise ignored by Run.
+	OnError func(err error)
+
+	// Interval is the time between polls, defaults to 1 minute.
+	Interval time.Duration
+
+	// MaxInterval limits the interval, which doubles each time Facebook throttles polling and recovers once polls
+	// succeed again, defaults to 10 times Interval.
+	MaxInterval time.Duration
+
+	// EmitExisting passes the donations made before a fundraiser was tracked to OnEvent, by default the first poll
+	// of each fundraiser only records its existing donations.
+	EmitExisting bool
+}
+
+// A DonationPoller periodically lists the donations of tracked fundraisers, emitting the same DonationCreated events
+// as a WebhookHandler for each new donation, for apps which cannot receive webhooks.
+// Donations are assumed to be listed newest first, so each poll only reads until a donation already seen.
+type DonationPoller struct {
+	c        APIClient
+	settings DonationPollerSettings
+
+	mu       sync.Mutex
+	tracked  map[string]*polledFundraiser
+	interval time.Duration
+	loop     loop
+}
+
+type polledFundraiser struct {
+	accessToken string
+	polled      bool
+
+	// seen holds the created time of each donation already seen, donations created before pruned have all been seen
+	seen   map[string]time.Time
+	pruned time.Time
+
+	// partial is set when a donation failed, so that the next poll reads all donations
+	partial bool
+}
+
+// NewDonationPoller creates a new DonationPoller which lists donations using c.
+func (c APIClient) NewDonationPoller(settings DonationPollerSettings) *DonationPoller {
+	if settings.Interval <= 0 {
+		settings.Interval = time.Minute
+	}
+	if settings.MaxInterval < settings.Interval {
+		settings.MaxInterval = 10 * settings.Interval
+	}
+	return &DonationPoller{c: c, settings: settings, tracked: make(map[string]*polledFundraiser), interval: settings.Interval}
+}
+
+// Track starts polling the donations of a fundraiser, using accessToken if set.
+func (p *DonationPoller) Track(fundraiserID string, accessToken string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if accessToken == "" {
+		accessToken = p.settings.AccessToken
+	}
+	if f, exists := p.tracked[fundraiserID]; exists {
+		f.accessToken = accessToken
+		return
+	}
+	p.tracked[fundraiserID] = &polledFundraiser{accessToken: accessToken, seen: make(map[string]time.Time)}
+}
+
+// Untrack stops polling the donations of a fundraiser.
+func (p *DonationPoller) Untrack(fundraiserID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tracked, fundraiserID)
+}
+
+// Interval returns the current time between polls.
+func (p *DonationPoller) Interval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interval
+}
+
+// Run polls until ctx is done, which is returned.
+func (p *DonationPoller) Run(ctx context.Context) error {
+	return p.run(ctx, ctx)
+}
+
+// Start polls in the background until Shutdown or Close.
+func (p *DonationPoller) Start(ctx context.Context) error {
+	return p.loop.start(ctx, func(ctx context.Context, stop context.Context) { p.run(ctx, stop) })
+}
+
+// Shutdown stops polling once any poll in progress has completed, cancelling it if ctx is done first.
+func (p *DonationPoller) Shutdown(ctx context.Context) error {
+	return p.loop.shutdown(ctx)
+}
+
+// Close stops polling once any poll in progress has completed.
+func (p *DonationPoller) Close() error {
+	return p.Shutdown(context.Background())
+}
+
+// run polls with ctx until stop is done.
+func (p *DonationPoller) run(ctx context.Context, stop context.Context) error {
+	for {
+		if err := p.Poll(ctx); err != nil && ctx.Err() == nil && p.settings.OnError != nil {
+			p.settings.OnError(err)
+		}
+		if err := sleep(stop, p.c.clock, p.Interval()); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll lists the new donations of each tracked fundraiser once, passing them to OnEvent oldest first.
+// The first error is returned once all fundraisers have been polled. Poll must not be called concurrently.
+func (p *DonationPoller) Poll(ctx context.Context) error {
+	ctx = backgroundContext(ctx)
+	p.mu.Lock()
+	tracked := make(map[string]*polledFundraiser, len(p.tracked))
+	for id, f := range p.tracked {
+		tracked[id] = f
+	}
+	p.mu.Unlock()
+
+	var first error
+	throttled := false
+	for id, f := range tracked {
+		if err := p.poll(ctx, id, f); err != nil {
+			if first == nil {
+				first = err
+			}
+			throttled = throttled || isThrottled(err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if throttled {
+		p.interval *= 2
+	} else {
+		p.interval -= p.interval / 4
+	}
+	if p.interval > p.settings.MaxInterval {
+		p.interval = p.settings.MaxInterval
+	}
+	if p.interval < p.settings.Interval {
+		p.interval = p.settings.Interval
+	}
+	return first
+}
+
+// polledDonationRetention is how long before the newest donation seen that donations are remembered, older donations
+// are pruned and treated as seen.
+const polledDonationRetention = 7 * 24 * time.Hour
+
+func (p *DonationPoller) poll(ctx context.Context, fundraiserID string, f *polledFundraiser) error {
+	p.mu.Lock()
+	accessToken := f.accessToken
+	polled := f.polled
+	all := !f.polled || f.partial
+	p.mu.Unlock()
+
+	it := p.c.ListDonations(ctx, accessToken, fundraiserID, "id", "amount", "currency", "created_time")
+	defer it.Close()
+	var donations []WebhookDonation
+	// values holds each donation as listed, for WebhookEvent Value
+	var values []json.RawMessage
+	for it.Next() {
+		item := it.Item()
+		id, _ := item["id"].(string)
+		donation := WebhookDonation{ID: id, FundraiserID: fundraiserID}
+		donation.CreatedTime, _ = item["created_time"].(string)
+		if p.seen(f, donation) {
+			if all {
+				continue
+			}
+			break
+		}
+		amount, _ := item["amount"].(float64)
+		donation.Amount = int64(amount)
+		donation.Currency, _ = item["currency"].(string)
+		value, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		donations = append(donations, donation)
+		values = append(values, value)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if !polled && !p.settings.EmitExisting {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for _, donation := range donations {
+			f.see(donation)
+		}
+		f.polled = true
+		return nil
+	}
+	p.mu.Lock()
+	f.polled = true
+	f.partial = false
+	p.mu.Unlock()
+	var first error
+	for i := len(donations) - 1; i >= 0; i-- {
+		donation := donations[i]
+		var err error
+		if p.settings.OnEvent != nil {
+			err = p.settings.OnEvent(ctx, WebhookEvent{
+				Type:     DonationCreated,
+				Object:   "fundraiser",
+				EntryID:  fundraiserID,
+				Time:     p.c.now(),
+				Field:    "donations",
+				Donation: &donation,
+				Value:    values[i],
+			})
+		}
+		p.mu.Lock()
+		if err != nil {
+			f.partial = true
+		} else {
+			f.see(donation)
+		}
+		p.mu.Unlock()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// seen returns true if donation has already been seen.
+func (p *DonationPoller) seen(f *polledFundraiser, donation WebhookDonation) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, seen := f.seen[donation.ID]; seen {
+		return true
+	}
+	created, ok := parseGraphTime(donation.CreatedTime)
+	return ok && created.Before(f.pruned)
+}
+
+// see records that donation has been seen, pruning donations created long before it.
+func (f *polledFundraiser) see(donation WebhookDonation) {
+	created, _ := parseGraphTime(donation.CreatedTime)
+	f.seen[donation.ID] = created
+	// prune at most hourly, rather than for every donation
+	if pruned := created.Add(-polledDonationRetention); pruned.Sub(f.pruned) > time.Hour {
+		f.pruned = pruned
+		for id, created := range f.seen {
+			if !created.IsZero() && created.Before(pruned) {
+				delete(f.seen, id)
+			}
+		}
+	}
+}
+
+// isThrottled returns true if err is from Facebook throttling API calls, or an open circuit breaker.
+func isThrottled(err error) bool {
+	if IsCircuitOpen(err) {
+		return true
+	}
+	var fe facebookError
+	if !errors.As(err, &fe) {
+		return false
+	}
+	switch code, _ := fe.ErrorCodes(); code {
+	case 4, 17, 32, 613:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDonationPoller(t *testing.T) {
+	var mu sync.Mutex
+	donations := []string{"1"}
+	throttled := false
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if throttled {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Application request limit reached","code":4}}`)
+			return
+		}
+		// newest first
+		var data []string
+		for i := len(donations) - 1; i >= 0; i-- {
+			data = append(data, fmt.Sprintf(`{"id":%q,"amount":500,"currency":"GBP"}`, donations[i]))
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+	}))
+
+	var events []string
+	fail := false
+	p := c.NewDonationPoller(DonationPollerSettings{
+		AccessToken: "token",
+		Interval:    time.Second,
+		OnEvent: func(ctx context.Context, event WebhookEvent) error {
+			if fail {
+				return errors.New("unavailable")
+			}
+			var value struct {
+				ID     string `json:"id"`
+				Amount int64  `json:"amount"`
+			}
+			if err := event.DecodeValue(&value); err != nil || value.ID != event.Donation.ID || value.Amount != 500 {
+				t.Errorf("unexpected event value %s %v", event.Value, err)
+			}
+			events = append(events, event.EntryID+"/"+event.Donation.ID)
+			return nil
+		},
+	})
+	p.Track("1234", "")
+	ctx := context.Background()
+
+	// existing donations are only recorded
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+	mu.Lock()
+	donations = append(donations, "2", "3")
+	mu.Unlock()
+	fail = true
+	if err := p.Poll(ctx); err == nil {
+		t.Errorf("expected failed event to be returned")
+	}
+	fail = false
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+	if fmt.Sprint(events) != "[1234/2 1234/3]" {
+		t.Errorf("unexpected events %v", events)
+	}
+
+	mu.Lock()
+	throttled = true
+	mu.Unlock()
+	if err := p.Poll(ctx); err == nil {
+		t.Errorf("expected throttling error")
+	}
+	if p.Interval() != 2*time.Second {
+		t.Errorf("expected interval to double when throttled, got %v", p.Interval())
+	}
+}
+
+func TestDonationPollerPrunesSeen(t *testing.T) {
+	var mu sync.Mutex
+	var donations []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var data []string
+		for i := len(donations) - 1; i >= 0; i-- {
+			data = append(data, donations[i])
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+	}))
+	var events []string
+	p := c.NewDonationPoller(DonationPollerSettings{
+		AccessToken: "token",
+		OnEvent: func(ctx context.Context, event WebhookEvent) error {
+			events = append(events, event.Donation.ID)
+			return nil
+		},
+	})
+	p.Track("1234", "")
+	ctx := context.Background()
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+
+	mu.Lock()
+	donations = append(donations,
+		`{"id":"old","amount":500,"currency":"GBP","created_time":"2017-01-01T12:00:00+0000"}`,
+		`{"id":"new","amount":500,"currency":"GBP","created_time":"2017-02-01T12:00:00+0000"}`,
+	)
+	mu.Unlock()
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+	f := p.tracked["1234"]
+	if _, seen := f.seen["old"]; seen || len(f.seen) != 1 {
+		t.Errorf("expected old donation to be pruned, got %v", f.seen)
+	}
+	// donations created before those pruned are not emitted again, even when all donations are read
+	f.partial = true
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("failed to poll %v", err)
+	}
+	if fmt.Sprint(events) != "[old new]" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
@@ -0,0 +1,63 @@
+package flannel
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// copyBuffers pools the buffers used to copy files into request bodies.
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 32*1024)
+		return &b
+	},
+}
+
+// copyBuffer copies src to dst using a pooled buffer.
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	b := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(b)
+	return io.CopyBuffer(dst, src, *b)
+}
+
+// responseBuffers pools the buffers responses are read into.
+var responseBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledResponseBuffer stops unusually large response buffers being retained by the pool.
+const maxPooledResponseBuffer = 256 * 1024
+
+func getResponseBuffer() *bytes.Buffer {
+	b := responseBuffers.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putResponseBuffer(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledResponseBuffer {
+		responseBuffers.Put(b)
+	}
+}
+
+// gzipReaders pools gzip readers, which allocate large decompression state.
+var gzipReaders sync.Pool
+
+func getGzipReader(r io.Reader) (*gzip.Reader, error) {
+	if zr, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		if err := zr.Reset(r); err != nil {
+			gzipReaders.Put(zr)
+			return nil, err
+		}
+		return zr, nil
+	}
+	return gzip.NewReader(r)
+}
+
+func putGzipReader(zr *gzip.Reader) {
+	gzipReaders.Put(zr)
+}
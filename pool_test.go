@@ -0,0 +1,59 @@
+package flannel
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func BenchmarkCreateFundraiserWithCoverPhoto(b *testing.B) {
+	photo := bytes.NewReader(testPhoto(1024 * 1024))
+	c := newTestClient(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		fundraiserCreated(w, r)
+	}))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		photo.Seek(0, io.SeekStart)
+		if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.jpg", photo)); err != nil {
+			b.Fatalf("failed to create fundraiser %v", err)
+		}
+	}
+}
+
+// BenchmarkCopyBuffer and BenchmarkCopy compare copying files into request bodies with and without pooled buffers.
+func BenchmarkCopyBuffer(b *testing.B) {
+	src := bytes.NewReader(bytes.Repeat([]byte{0xff}, 64*1024))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		src.Seek(0, io.SeekStart)
+		copyBuffer(ioutil.Discard, NewMeteredReader(src, MeteredReaderSettings{MaxSize: FundraiserCoverPhotoImageMaxSize}))
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	src := bytes.NewReader(bytes.Repeat([]byte{0xff}, 64*1024))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		src.Seek(0, io.SeekStart)
+		io.Copy(struct{ io.Writer }{ioutil.Discard}, NewMeteredReader(src, MeteredReaderSettings{MaxSize: FundraiserCoverPhotoImageMaxSize}))
+	}
+}
+
+// BenchmarkReadBody measures reading responses into pooled buffers.
+func BenchmarkReadBody(b *testing.B) {
+	body := bytes.NewReader(bytes.Repeat([]byte(`{"id":"1234"}`), 1000))
+	c, _ := CreateAPIClient()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body.Seek(0, io.SeekStart)
+		_, release, err := c.readBody(body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		release()
+	}
+}
@@ -0,0 +1,73 @@
+package flannel
+
+import (
+	"math"
+	"time"
+)
+
+// GoalProgress is the progress of a fundraiser towards its goal, with amounts in the smallest unit of its currency.
+type GoalProgress struct {
+	Goal   int64
+	Raised int64
+
+	// Percent is the percentage of the goal raised, which exceeds 100 once the goal is beaten.
+	Percent float64
+
+	// Remaining is the amount left to raise, zero once the goal is reached.
+	Remaining int64
+
+	Reached bool
+
+	// Velocity is the amount raised per day by recent donations, set by Project.
+	Velocity float64
+
+	// ProjectedCompletion is when the goal will be reached if donations continue at Velocity, set by Project
+	// unless the goal is reached or there were no recent donations.
+	ProjectedCompletion time.Time
+}
+
+// NewGoalProgress returns the progress of raised towards goal.
+func NewGoalProgress(goal int64, raised int64) GoalProgress {
+	p := GoalProgress{Goal: goal, Raised: raised, Reached: raised >= goal}
+	if goal > 0 {
+		p.Percent = float64(raised) * 100 / float64(goal)
+	}
+	if !p.Reached {
+		p.Remaining = goal - raised
+	}
+	return p
+}
+
+// Progress returns the progress of the fundraiser towards its goal.
+func (f FundraiserDetails) Progress() GoalProgress {
+	return NewGoalProgress(f.GoalAmount, f.AmountRaised)
+}
+
+// Progress returns the progress of the fundraiser towards its goal.
+func (f FundraiserTotals) Progress() GoalProgress {
+	return NewGoalProgress(f.GoalAmount, f.AmountRaised)
+}
+
+// Project sets the Velocity of the donations made in the window before now, and the ProjectedCompletion of the
+// goal at that velocity. Donations with a CreatedTime which cannot be parsed are ignored.
+func (p GoalProgress) Project(donations []WebhookDonation, window time.Duration, now time.Time) GoalProgress {
+	if window <= 0 {
+		return p
+	}
+	start := now.Add(-window)
+	var recent int64
+	for _, donation := range donations {
+		created, ok := parseGraphTime(donation.CreatedTime)
+		if ok && !created.Before(start) && !created.After(now) {
+			recent += donation.Amount
+		}
+	}
+	days := window.Hours() / 24
+	p.Velocity = float64(recent) / days
+	p.ProjectedCompletion = time.Time{}
+	if !p.Reached && p.Velocity > 0 {
+		remaining := math.Ceil(float64(p.Remaining) / p.Velocity * 24 * float64(time.Hour))
+		p.ProjectedCompletion = now.Add(time.Duration(remaining))
+	}
+	return p
+}
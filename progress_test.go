@@ -0,0 +1,33 @@
+package flannel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalProgress(t *testing.T) {
+	p := FundraiserDetails{GoalAmount: 10000, AmountRaised: 2500}.Progress()
+	if p.Percent != 25 || p.Remaining != 7500 || p.Reached {
+		t.Errorf("unexpected progress %+v", p)
+	}
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	p = p.Project([]WebhookDonation{
+		{Amount: 1000, CreatedTime: "2026-09-30T12:00:00+0000"},
+		{Amount: 500, CreatedTime: "2026-09-29T18:00:00+0000"},
+		// before the window
+		{Amount: 5000, CreatedTime: "2026-09-20T12:00:00+0000"},
+		{Amount: 5000, CreatedTime: "invalid"},
+	}, 2*24*time.Hour, now)
+	if p.Velocity != 750 {
+		t.Errorf("expected velocity of 750 a day, got %v", p.Velocity)
+	}
+	if expected := now.AddDate(0, 0, 10); !p.ProjectedCompletion.Equal(expected) {
+		t.Errorf("expected projected completion %v, got %v", expected, p.ProjectedCompletion)
+	}
+
+	p = NewGoalProgress(10000, 12500).Project(nil, time.Hour, now)
+	if p.Percent != 125 || p.Remaining != 0 || !p.Reached || !p.ProjectedCompletion.IsZero() {
+		t.Errorf("unexpected progress %+v", p)
+	}
+}
@@ -0,0 +1,456 @@
+package flannel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A FundraiserJob is a request to create a Facebook Fundraiser, processed asynchronously by a FundraiserQueue.
+type FundraiserJob struct {
+
+	// ID identifies the job, it is set by Enqueue.
+	ID string
+
+	// Params are the required parameters of the fundraiser, including the AccessToken of the user creating it.
+	Params CreateFundraiserParams
+
+	// Fields are optional fields of the fundraiser, as set with WithFundraiserField.
+	Fields map[string]string
+
+	// CoverPhotoURL is an optional cover photo, as set with WithFundraiserCoverPhotoURL.
+	CoverPhotoURL string
+
+	// EnqueuedAt is when the job was enqueued.
+	EnqueuedAt time.Time
+
+	// Attempts is the number of attempts made to create the fundraiser.
+	Attempts int
+
+	// NextAttempt is when the job should next be attempted, it is set by Schedule to the time the fundraiser
+	// should be created.
+	NextAttempt time.Time
+
+	// LastError is the error returned by the last attempt.
+	LastError string
+}
+
+// options returns the options creating the fundraiser of the job.
+func (j FundraiserJob) options() ([]func(*multipart.Writer) error, error) {
+	names := make([]string, 0, len(j.Fields))
+	for name := range j.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var options []func(*multipart.Writer) error
+	for _, name := range names {
+		options = append(options, WithFundraiserField(name, j.Fields[name]))
+	}
+	if j.CoverPhotoURL != "" {
+		u, err := url.Parse(j.CoverPhotoURL)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithFundraiserCoverPhotoURL(path.Base(u.Path), *u))
+	}
+	return options, nil
+}
+
+// JobStore is the interface implemented by stores persisting the jobs of a FundraiserQueue,
+// so that jobs survive restarts. Jobs include user access tokens, so stores must be secured accordingly.
+type JobStore interface {
+
+	// Save inserts or updates job.
+	Save(ctx context.Context, job FundraiserJob) error
+
+	// Delete removes the job with id.
+	Delete(ctx context.Context, id string) error
+
+	// Pending returns all saved jobs.
+	Pending(ctx context.Context) ([]FundraiserJob, error)
+}
+
+// A MemoryJobStore is an in-memory JobStore, jobs are lost when the process exits.
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]FundraiserJob
+}
+
+// NewMemoryJobStore creates a new MemoryJobStore.
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]FundraiserJob)}
+}
+
+// Save inserts or updates job.
+func (s *MemoryJobStore) Save(ctx context.Context, job FundraiserJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Delete removes the job with id.
+func (s *MemoryJobStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// Pending returns all saved jobs, oldest first.
+func (s *MemoryJobStore) Pending(ctx context.Context) ([]FundraiserJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]FundraiserJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].EnqueuedAt.Before(jobs[j].EnqueuedAt) })
+	return jobs, nil
+}
+
+// FundraiserQueueSettings configures a FundraiserQueue.
+type FundraiserQueueSettings struct {
+
+	// Workers is the number of jobs processed at the same time, defaults to 1.
+	Workers int
+
+	// MaxAttempts is the maximum number of attempts made for each job, defaults to 5.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry of a job, which doubles for each subsequent retry, defaults to 30 seconds.
+	Backoff time.Duration
+
+	// OnComplete is called once a job has completed, either because the fundraiser was created
+	// or because the job failed with a permanent error or ran out of attempts.
+	OnComplete func(job FundraiserJob, status int, result map[string]interface{}, err error)
+}
+
+// A FundraiserQueue creates Facebook Fundraisers asynchronously, so that callers do not block on Facebook latency.
+// Jobs are persisted to a JobStore before being processed by a pool of workers, which retry jobs failing
+// with transient errors. API calls are subject to the rate limiting and retry policy of the APIClient.
+type FundraiserQueue struct {
+	c        APIClient
+	store    JobStore
+	settings FundraiserQueueSettings
+
+	mu      sync.Mutex
+	ready   []FundraiserJob
+	active  map[string]bool
+	timers  map[string]func() bool
+	started bool
+	closed  bool
+	notify  chan struct{}
+	quit    chan struct{}
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var errQueueClosed = errors.New("queue is closed")
+
+// NewFundraiserQueue creates a new FundraiserQueue which creates fundraisers using c.
+func NewFundraiserQueue(c APIClient, store JobStore, settings FundraiserQueueSettings) *FundraiserQueue {
+	if settings.Workers < 1 {
+		settings.Workers = 1
+	}
+	if settings.MaxAttempts < 1 {
+		settings.MaxAttempts = 5
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = 30 * time.Second
+	}
+	return &FundraiserQueue{
+		c:        c,
+		store:    store,
+		settings: settings,
+		active:   make(map[string]bool),
+		timers:   make(map[string]func() bool),
+		notify:   make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start resumes any pending jobs from the store and starts the workers.
+// The ctx is used for all API calls made by the workers.
+func (q *FundraiserQueue) Start(ctx context.Context) error {
+	q.mu.Lock()
+	if q.started || q.closed {
+		q.mu.Unlock()
+		return errors.New("queue already started")
+	}
+	q.started = true
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.mu.Unlock()
+
+	jobs, err := q.store.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		q.add(job)
+	}
+	for i := 0; i < q.settings.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	return nil
+}
+
+// Enqueue persists a job to create a fundraiser, returning its ID once saved.
+func (q *FundraiserQueue) Enqueue(ctx context.Context, job FundraiserJob) (string, error) {
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return "", errQueueClosed
+	}
+	if job.ID == "" {
+		id, err := newJobID()
+		if err != nil {
+			return "", err
+		}
+		job.ID = id
+	}
+	job.EnqueuedAt = q.c.now()
+	if err := q.store.Save(ctx, job); err != nil {
+		return "", err
+	}
+	q.add(job)
+	return job.ID, nil
+}
+
+// Schedule persists a job to create a fundraiser at a given time, for example the launch of a campaign, returning
+// its ID once saved. Scheduled jobs are saved to the store, so are resumed by Start if the process restarts before
+// the fundraiser is created, and are retried as other jobs.
+func (q *FundraiserQueue) Schedule(ctx context.Context, job FundraiserJob, at time.Time) (string, error) {
+	job.NextAttempt = at
+	return q.Enqueue(ctx, job)
+}
+
+// Cancel removes a job which has not started to be processed, for example one scheduled for later, returning
+// false if the job is unknown or being processed.
+func (q *FundraiserQueue) Cancel(ctx context.Context, id string) (bool, error) {
+	q.mu.Lock()
+	found := false
+	// a timer which cannot be stopped has fired, and its job is about to become ready
+	if stop, exists := q.timers[id]; exists && stop() {
+		delete(q.timers, id)
+		found = true
+	}
+	for i, job := range q.ready {
+		if job.ID == id {
+			q.ready = append(q.ready[:i:i], q.ready[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if found {
+		delete(q.active, id)
+	}
+	q.mu.Unlock()
+	if !found {
+		return false, nil
+	}
+	if err := q.store.Delete(ctx, id); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Close stops the workers once any jobs being processed have completed.
+// Jobs not yet completed remain in the store and are resumed by the next Start.
+func (q *FundraiserQueue) Close() error {
+	return q.Shutdown(context.Background())
+}
+
+// Shutdown stops the workers once any jobs being processed have completed, cancelling them if ctx is done first,
+// in which case the error of ctx is returned. Jobs not yet completed, including those cancelled, remain in the
+// store and are resumed by the next Start.
+func (q *FundraiserQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	for id, stop := range q.timers {
+		stop()
+		delete(q.timers, id)
+	}
+	close(q.quit)
+	cancel := q.cancel
+	q.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	defer cancel()
+	return drain(ctx, &q.wg, cancel)
+}
+
+// add schedules a job unless it is already known to the queue, or the queue
+// has not started in which case it is resumed from the store by Start.
+func (q *FundraiserQueue) add(job FundraiserJob) {
+	q.mu.Lock()
+	if !q.started || q.active[job.ID] {
+		q.mu.Unlock()
+		return
+	}
+	q.active[job.ID] = true
+	q.mu.Unlock()
+	q.schedule(job)
+}
+
+// schedule makes job ready for a worker at its NextAttempt.
+func (q *FundraiserQueue) schedule(job FundraiserJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	if delay := job.NextAttempt.Sub(clockOrSystem(q.c.clock).Now()); delay > 0 {
+		q.timers[job.ID] = clockOrSystem(q.c.clock).AfterFunc(delay, func() {
+			q.mu.Lock()
+			if _, exists := q.timers[job.ID]; !exists {
+				// the job was cancelled or the queue closed
+				q.mu.Unlock()
+				return
+			}
+			delete(q.timers, job.ID)
+			q.mu.Unlock()
+			job.NextAttempt = time.Time{}
+			q.schedule(job)
+		})
+		return
+	}
+	q.ready = append(q.ready, job)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the next ready job, blocking until one is available or the queue is closed.
+func (q *FundraiserQueue) next() (FundraiserJob, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return FundraiserJob{}, false
+		}
+		if len(q.ready) > 0 {
+			job := q.ready[0]
+			q.ready = q.ready[1:]
+			more := len(q.ready) > 0
+			q.mu.Unlock()
+			if more {
+				// wake another worker
+				select {
+				case q.notify <- struct{}{}:
+				default:
+				}
+			}
+			return job, true
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.notify:
+		case <-q.quit:
+		}
+	}
+}
+
+func (q *FundraiserQueue) work(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+		q.process(ctx, job)
+	}
+}
+
+func (q *FundraiserQueue) process(ctx context.Context, job FundraiserJob) {
+	job.Attempts++
+	var status int
+	var result map[string]interface{}
+	// a job whose options are invalid fails permanently, as retrying it cannot succeed
+	options, err := job.options()
+	retry := false
+	if err == nil {
+		status, result, err = q.c.CreateFundraiserWithContext(ctx, job.Params, options...)
+		retry = err != nil && isTransient(err)
+	}
+	if retry && job.Attempts < q.settings.MaxAttempts && ctx.Err() == nil {
+		job.LastError = err.Error()
+		job.NextAttempt = clockOrSystem(q.c.clock).Now().Add(q.settings.Backoff << uint(job.Attempts-1))
+		if saveErr := q.store.Save(ctx, job); saveErr == nil {
+			q.schedule(job)
+			return
+		}
+	}
+	if err != nil && ctx.Err() != nil {
+		// leave the job in the store to be resumed
+		q.mu.Lock()
+		delete(q.active, job.ID)
+		q.mu.Unlock()
+		return
+	}
+	if err != nil {
+		job.LastError = err.Error()
+	}
+	// the job is complete, so it is removed from the store even if ctx is cancelled after the API call
+	q.store.Delete(context.WithoutCancel(ctx), job.ID)
+	q.mu.Lock()
+	delete(q.active, job.ID)
+	q.mu.Unlock()
+	if q.settings.OnComplete != nil {
+		q.settings.OnComplete(job, status, result, err)
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isTransient returns true if err may succeed when the API call is retried later.
+// Only network errors, server errors and throttling are transient, other errors are permanent.
+// Facebook errors are transient when flagged as such, or when they represent throttling.
+// See https://developers.facebook.com/docs/graph-api/using-graph-api/error-handling/
+func isTransient(err error) bool {
+	if IsCircuitOpen(err) {
+		return true
+	}
+	var fe facebookError
+	if errors.As(err, &fe) {
+		if fe.Status >= 500 {
+			return true
+		}
+		if transient, ok := fe.ErrorMap["is_transient"].(bool); ok && transient {
+			return true
+		}
+		switch code, _ := fe.ErrorCodes(); code {
+		case 1, 2, 4, 17, 32, 341, 613:
+			return true
+		}
+		return false
+	}
+	if failure, status := CoverPhotoDownloadFailure(err); failure == DownloadFailureStatus {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
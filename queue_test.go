@@ -0,0 +1,290 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFundraiserQueue(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.FormValue("name")
+		mu.Lock()
+		attempts[name]++
+		n := attempts[name]
+		mu.Unlock()
+		switch {
+		case name == "throttled" && n == 1:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Application request limit reached","code":4}}`)
+		case name == "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid parameter","code":100}}`)
+		default:
+			fmt.Fprintf(w, `{"id":"%s-%s"}`, name, r.FormValue("external_event_name"))
+		}
+	}))
+
+	store := NewMemoryJobStore()
+	done := make(chan FundraiserJob, 3)
+	results := make(map[string]error)
+	q := NewFundraiserQueue(c, store, FundraiserQueueSettings{
+		Workers: 2,
+		Backoff: time.Millisecond,
+		OnComplete: func(job FundraiserJob, status int, result map[string]interface{}, err error) {
+			mu.Lock()
+			results[job.Params.Title] = err
+			mu.Unlock()
+			done <- job
+		},
+	})
+	ctx := context.Background()
+
+	// jobs enqueued before the queue is started are resumed from the store
+	if _, err := q.Enqueue(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "first"}, Fields: map[string]string{"external_event_name": "Marathon"}}); err != nil {
+		t.Fatalf("failed to enqueue job %v", err)
+	}
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	for _, title := range []string{"throttled", "invalid"} {
+		if _, err := q.Enqueue(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: title}}); err != nil {
+			t.Fatalf("failed to enqueue job %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for jobs to complete")
+		}
+	}
+	q.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if results["first"] != nil || results["throttled"] != nil {
+		t.Errorf("expected jobs to succeed, got %v", results)
+	}
+	if attempts["throttled"] != 2 {
+		t.Errorf("expected throttled job to be retried, got %d attempts", attempts["throttled"])
+	}
+	if results["invalid"] == nil || attempts["invalid"] != 1 {
+		t.Errorf("expected invalid job to fail without retry, got %d attempts %v", attempts["invalid"], results["invalid"])
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 0 {
+		t.Errorf("expected completed jobs to be removed from the store, got %d", len(pending))
+	}
+	if _, err := q.Enqueue(ctx, FundraiserJob{}); err == nil {
+		t.Errorf("expected error enqueuing to a closed queue")
+	}
+}
+
+func TestFundraiserQueueSchedule(t *testing.T) {
+	created := make(chan time.Time, 2)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created <- time.Now()
+		fmt.Fprint(w, `{"id":"1234"}`)
+	}))
+	store := NewMemoryJobStore()
+	q := NewFundraiserQueue(c, store, FundraiserQueueSettings{})
+	ctx := context.Background()
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	defer q.Close()
+
+	launch := time.Now().Add(100 * time.Millisecond)
+	if _, err := q.Schedule(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "launch"}}, launch); err != nil {
+		t.Fatalf("failed to schedule job %v", err)
+	}
+	cancelled, err := q.Schedule(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "cancelled"}}, launch)
+	if err != nil {
+		t.Fatalf("failed to schedule job %v", err)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 2 {
+		t.Errorf("expected scheduled jobs to be saved, got %d", len(pending))
+	}
+	if ok, err := q.Cancel(ctx, cancelled); !ok || err != nil {
+		t.Errorf("failed to cancel job %v", err)
+	}
+
+	select {
+	case at := <-created:
+		if at.Before(launch) {
+			t.Errorf("fundraiser created %v before launch", launch.Sub(at))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for scheduled job")
+	}
+	select {
+	case <-created:
+		t.Errorf("expected cancelled job not to be created")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestFundraiserQueueCancelFiredTimer(t *testing.T) {
+	created := make(chan struct{}, 1)
+	clock := newTestClock(time.Now())
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created <- struct{}{}
+		fmt.Fprint(w, `{"id":"1234"}`)
+	}), WithClock(clock))
+	store := NewMemoryJobStore()
+	q := NewFundraiserQueue(c, store, FundraiserQueueSettings{})
+	ctx := context.Background()
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	defer q.Close()
+	id, err := q.Schedule(ctx, FundraiserJob{Params: CreateFundraiserParams{Title: "launch"}}, clock.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to schedule job %v", err)
+	}
+
+	// fire the timer whilst its callback is blocked, so that it can no longer be stopped
+	q.mu.Lock()
+	clock.advance(time.Minute)
+	q.mu.Unlock()
+	cancelled, err := q.Cancel(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-created:
+		if cancelled {
+			t.Errorf("expected a job reported as cancelled not to be created")
+		}
+	case <-time.After(time.Second):
+		if !cancelled {
+			t.Errorf("expected a job which was not cancelled to be created")
+		}
+	}
+}
+
+// cancelledDeleteStore reports an error when a job is deleted with a context which is already cancelled.
+type cancelledDeleteStore struct {
+	*MemoryJobStore
+}
+
+func (s cancelledDeleteStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryJobStore.Delete(ctx, id)
+}
+
+// cancellingTransport reads each response and then calls cancel, as if the context of the API call was
+// cancelled just after the response was received.
+type cancellingTransport struct {
+	http.RoundTripper
+	cancel context.CancelFunc
+}
+
+func (t cancellingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.cancel()
+	return res, nil
+}
+
+func TestFundraiserQueueDeleteAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestClient(t, http.HandlerFunc(fundraiserCreated), func(c *APIClient) error {
+		c.httpClient.Transport = cancellingTransport{c.httpClient.Transport, cancel}
+		return nil
+	})
+	store := cancelledDeleteStore{NewMemoryJobStore()}
+	done := make(chan error, 1)
+	q := NewFundraiserQueue(c, store, FundraiserQueueSettings{
+		OnComplete: func(job FundraiserJob, status int, result map[string]interface{}, err error) {
+			done <- err
+		},
+	})
+	if _, err := q.Enqueue(ctx, FundraiserJob{}); err != nil {
+		t.Fatalf("failed to enqueue job %v", err)
+	}
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected job to succeed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for job to complete")
+	}
+	q.Close()
+	if pending, _ := store.Pending(context.Background()); len(pending) != 0 {
+		t.Errorf("expected completed job to be removed from the store, got %d", len(pending))
+	}
+}
+
+func TestFundraiserQueueBadCoverPhotoURL(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		fundraiserCreated(w, r)
+	}))
+	store := NewMemoryJobStore()
+	done := make(chan FundraiserJob, 2)
+	errs := make(chan error, 2)
+	q := NewFundraiserQueue(c, store, FundraiserQueueSettings{
+		Backoff: time.Millisecond,
+		OnComplete: func(job FundraiserJob, status int, result map[string]interface{}, err error) {
+			done <- job
+			errs <- err
+		},
+	})
+	ctx := context.Background()
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	defer q.Close()
+	for _, u := range []string{"http://[::1/photo.jpg", "https://photos.example.com/missing.jpg"} {
+		if _, err := q.Enqueue(ctx, FundraiserJob{CoverPhotoURL: u}); err != nil {
+			t.Fatalf("failed to enqueue job %v", err)
+		}
+		select {
+		case job := <-done:
+			if err := <-errs; err == nil || job.Attempts != 1 || job.LastError == "" {
+				t.Errorf("expected job with cover photo %s to fail after 1 attempt, got %d attempts %v", u, job.Attempts, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for job with cover photo %s to complete", u)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("expected only the missing cover photo to be requested, got %d requests", requests)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 0 {
+		t.Errorf("expected failed jobs to be removed from the store, got %d", len(pending))
+	}
+}
@@ -0,0 +1,173 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter is the interface implemented by rate limiters used by the APIClient to cap the rate of API calls.
+type RateLimiter interface {
+	// Wait blocks until the next API call is permitted or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// The RateLimiterFunc type is an adapter to allow the use of ordinary functions as RateLimiters.
+type RateLimiterFunc func(ctx context.Context) error
+
+// Wait calls f(ctx).
+func (f RateLimiterFunc) Wait(ctx context.Context) error {
+	return f(ctx)
+}
+
+// A TokenBucket is a RateLimiter permitting on average Rate API calls per second,
+// with bursts of up to Burst API calls. Background API calls, as set with ContextWithPriority,
+// only take tokens when no interactive API calls are waiting.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  Clock
+
+	// interactive is the number of interactive API calls waiting
+	interactive int
+}
+
+// NewTokenBucket creates a new TokenBucket which starts full.
+// The rate is the number of API calls permitted per second and burst is the bucket capacity.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// SetClock sets the Clock used to refill the bucket, which defaults to SystemClock. It must be set before the bucket
+// is used.
+func (b *TokenBucket) SetClock(clock Clock) {
+	b.clock = clock
+	b.last = clockOrSystem(clock).Now()
+}
+
+// SetRate changes the rate and burst of the bucket while it is in use.
+func (b *TokenBucket) SetRate(rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = rate
+	b.burst = float64(burst)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	background := PriorityFromContext(ctx) != PriorityInteractive
+	if !background {
+		b.mu.Lock()
+		b.interactive++
+		b.mu.Unlock()
+		defer func() {
+			b.mu.Lock()
+			b.interactive--
+			b.mu.Unlock()
+		}()
+	}
+	for {
+		delay := b.take(background)
+		if delay <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, b.clock, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// take removes a token from the bucket if one is available, and the API call is not in the background whilst
+// interactive API calls wait, otherwise it returns how long to wait until one will be.
+func (b *TokenBucket) take(background bool) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(clockOrSystem(b.clock).Now())
+	if background && b.interactive > 0 {
+		if b.rate <= 0 {
+			return time.Second
+		}
+		return time.Duration(float64(time.Second) / b.rate)
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// refill adds the tokens accrued since the bucket was last refilled, b.mu must be held.
+func (b *TokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		b.last = now
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// WithRateLimiter caps the rate of all API calls made by the APIClient.
+func WithRateLimiter(limiter RateLimiter) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.rateLimiter = limiter
+		return nil
+	}
+}
+
+// WithTenantRateLimiter caps the rate of API calls made on behalf of tenant.
+// The tenant of an API call is set on its context with ContextWithTenant.
+// Tenant rate limiters are applied in addition to any limiter set with WithRateLimiter.
+func WithTenantRateLimiter(tenant string, limiter RateLimiter) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if c.tenantRateLimiters == nil {
+			c.tenantRateLimiters = make(map[string]RateLimiter)
+		}
+		c.tenantRateLimiters[tenant] = limiter
+		return nil
+	}
+}
+
+func (c APIClient) waitRateLimiters(ctx context.Context) error {
+	if c.metrics != nil {
+		start := time.Now()
+		defer func() {
+			tenant, _ := TenantFromContext(ctx)
+			c.metrics.ObserveRateLimitWait(tenant, time.Since(start))
+		}()
+	}
+	if tenant, ok := TenantFromContext(ctx); ok {
+		if limiter, exists := c.tenantRateLimiters[tenant]; exists {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("error waiting for tenant %s rate limiter %w", tenant, err)
+			}
+		}
+	}
+	if limiter := c.currentRateLimiter(); limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("error waiting for rate limiter %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(50, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("unexpected error waiting for token %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("expected third call to wait for a token, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	b = NewTokenBucket(0.001, 1)
+	b.Wait(ctx)
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled error, got %v", err)
+	}
+}
+
+func TestTenantRateLimiter(t *testing.T) {
+	var calls []string
+	limiter := func(name string) RateLimiter {
+		return RateLimiterFunc(func(ctx context.Context) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+	c := newTestClient(t, fundraiserCreated,
+		WithRateLimiter(limiter("client")),
+		WithTenantRateLimiter("charity-a", limiter("charity-a")),
+	)
+
+	ctx := ContextWithTenant(context.Background(), "charity-a")
+	if _, _, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	ctx = ContextWithTenant(context.Background(), "charity-b")
+	if _, _, err := c.CreateFundraiserWithContext(ctx, CreateFundraiserParams{}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+
+	want := []string{"charity-a", "client", "client"}
+	if len(calls) != len(want) {
+		t.Fatalf("expected limiters %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("expected limiters %v, got %v", want, calls)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// rateLimiterState is the persisted state of a TokenBucket or FairScheduler.
+type rateLimiterState struct {
+	Tokens  float64            `json:"tokens"`
+	Updated time.Time          `json:"updated"`
+	Tenants map[string]float64 `json:"tenants,omitempty"`
+}
+
+// persistentRateLimiter is implemented by rate limiters whose state can be persisted.
+type persistentRateLimiter interface {
+	state() rateLimiterState
+	restore(state rateLimiterState)
+}
+
+func (b *TokenBucket) state() rateLimiterState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := clockOrSystem(b.clock).Now()
+	b.refill(now)
+	return rateLimiterState{Tokens: b.tokens, Updated: now}
+}
+
+// restore sets the tokens of the bucket, which are refilled for the time since they were saved.
+func (b *TokenBucket) restore(state rateLimiterState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := clockOrSystem(b.clock).Now()
+	b.tokens, b.last = state.Tokens, state.Updated
+	if b.last.After(now) {
+		b.last = now
+	}
+	b.refill(now)
+}
+
+func (s *FairScheduler) state() rateLimiterState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockOrSystem(s.settings.Clock).Now()
+	s.global.refill(now)
+	state := rateLimiterState{Tokens: s.global.tokens, Updated: now}
+	if s.settings.TenantRate > 0 {
+		state.Tenants = make(map[string]float64, len(s.tenants))
+		for name, t := range s.tenants {
+			t.budget.refill(now)
+			state.Tenants[name] = t.budget.tokens
+		}
+	}
+	return state
+}
+
+// restore sets the tokens of the rate and tenant budgets, which are refilled for the time since they were saved.
+func (s *FairScheduler) restore(state rateLimiterState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockOrSystem(s.settings.Clock).Now()
+	s.global.restore(state.Tokens, state.Updated, now)
+	for name, tokens := range state.Tenants {
+		s.tenant(name).budget.restore(tokens, state.Updated, now)
+	}
+}
+
+func (b *bucket) restore(tokens float64, updated time.Time, now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	b.tokens, b.last = tokens, updated
+	if b.last.After(now) {
+		b.last = now
+	}
+	b.refill(now)
+}
+
+// SaveRateLimiter saves the state of limiter, a TokenBucket or FairScheduler, to cache with key, so that it can be
+// restored with RestoreRateLimiter after a restart rather than starting with full buckets, which would trip
+// Facebook throttling when many instances are deployed at once.
+func SaveRateLimiter(ctx context.Context, cache Cache, key string, limiter RateLimiter) error {
+	p, ok := limiter.(persistentRateLimiter)
+	if !ok {
+		return fmt.Errorf("rate limiter %T cannot be saved", limiter)
+	}
+	v, err := json.Marshal(p.state())
+	if err != nil {
+		return err
+	}
+	cache.Set(ctx, rateLimiterKey(key), v, 0)
+	return nil
+}
+
+// RestoreRateLimiter restores the state of limiter, a TokenBucket or FairScheduler, saved to cache with key by
+// SaveRateLimiter, returning false if there is none. Tokens are refilled for the time since the state was saved.
+func RestoreRateLimiter(ctx context.Context, cache Cache, key string, limiter RateLimiter) (bool, error) {
+	p, ok := limiter.(persistentRateLimiter)
+	if !ok {
+		return false, fmt.Errorf("rate limiter %T cannot be restored", limiter)
+	}
+	v, found := cache.Get(ctx, rateLimiterKey(key))
+	if !found {
+		return false, nil
+	}
+	var state rateLimiterState
+	if err := json.Unmarshal(v, &state); err != nil {
+		return false, fmt.Errorf("error decoding rate limiter state %v", err)
+	}
+	p.restore(state)
+	return true, nil
+}
+
+func rateLimiterKey(key string) string {
+	return "flannel:ratelimiter:" + key
+}
+
+// A RateLimiterPersistence is a Service restoring the state of a rate limiter as it starts, and saving it
+// periodically and as it shuts down.
+//
+//	p := flannel.NewRateLimiterPersistence(bucket, cache, "app", 10*time.Second)
+//	p.Start(ctx)
+//	defer p.Close()
+type RateLimiterPersistence struct {
+	limiter  RateLimiter
+	cache    Cache
+	key      string
+	interval time.Duration
+	loop     loop
+}
+
+// NewRateLimiterPersistence creates a new RateLimiterPersistence saving the state of limiter, a TokenBucket or
+// FairScheduler, to cache with key every interval, which defaults to 10 seconds.
+func NewRateLimiterPersistence(limiter RateLimiter, cache Cache, key string, interval time.Duration) *RateLimiterPersistence {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &RateLimiterPersistence{limiter: limiter, cache: cache, key: key, interval: interval}
+}
+
+// Start restores the state of the rate limiter, then saves it every interval until Shutdown or Close.
+func (p *RateLimiterPersistence) Start(ctx context.Context) error {
+	if _, err := RestoreRateLimiter(ctx, p.cache, p.key, p.limiter); err != nil {
+		return err
+	}
+	return p.loop.start(ctx, func(ctx context.Context, stop context.Context) {
+		for sleep(stop, nil, p.interval) == nil {
+			SaveRateLimiter(ctx, p.cache, p.key, p.limiter)
+		}
+	})
+}
+
+// Shutdown stops saving periodically, then saves the state of the rate limiter a final time.
+func (p *RateLimiterPersistence) Shutdown(ctx context.Context) error {
+	if err := p.loop.shutdown(ctx); err != nil {
+		return err
+	}
+	return SaveRateLimiter(ctx, p.cache, p.key, p.limiter)
+}
+
+// Close stops saving periodically, then saves the state of the rate limiter a final time.
+func (p *RateLimiterPersistence) Close() error {
+	return p.Shutdown(context.Background())
+}
@@ -0,0 +1,60 @@
+package flannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPersistRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(10)
+	drained := func(limiter RateLimiter, ctx context.Context) bool {
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		return limiter.Wait(timeout) != nil
+	}
+
+	bucket := NewTokenBucket(1, 5)
+	p := NewRateLimiterPersistence(bucket, cache, "app", time.Hour)
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("failed to start %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		bucket.Wait(ctx)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("failed to save rate limiter %v", err)
+	}
+	restarted := NewTokenBucket(1, 5)
+	if found, err := RestoreRateLimiter(ctx, cache, "app", restarted); !found || err != nil {
+		t.Fatalf("failed to restore rate limiter %v %v", found, err)
+	}
+	if !drained(restarted, ctx) {
+		t.Errorf("expected restored bucket to be drained")
+	}
+
+	tenant := ContextWithTenant(ctx, "bulk")
+	scheduler := NewFairScheduler(FairSchedulerSettings{Rate: 100, Burst: 10, TenantRate: 0.1, TenantBurst: 1})
+	scheduler.Wait(tenant)
+	if err := SaveRateLimiter(ctx, cache, "fair", scheduler); err != nil {
+		t.Fatalf("failed to save rate limiter %v", err)
+	}
+	scheduler = NewFairScheduler(FairSchedulerSettings{Rate: 100, Burst: 10, TenantRate: 0.1, TenantBurst: 1})
+	if found, err := RestoreRateLimiter(ctx, cache, "fair", scheduler); !found || err != nil {
+		t.Fatalf("failed to restore rate limiter %v %v", found, err)
+	}
+	if !drained(scheduler, tenant) {
+		t.Errorf("expected restored tenant budget to be used")
+	}
+	if drained(scheduler, ContextWithTenant(ctx, "other")) {
+		t.Errorf("expected other tenant budget to be available")
+	}
+
+	if found, err := RestoreRateLimiter(ctx, cache, "missing", NewTokenBucket(1, 1)); found || err != nil {
+		t.Errorf("expected no saved state, got %v %v", found, err)
+	}
+	if err := SaveRateLimiter(ctx, cache, "func", RateLimiterFunc(func(ctx context.Context) error { return nil })); err == nil {
+		t.Errorf("expected error saving a rate limiter without state")
+	}
+}
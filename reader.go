@@ -0,0 +1,86 @@
+package flannel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrMaxSizeExceeded is returned, wrapped, when reading more than the maximum size permitted,
+// such as a cover photo larger than FundraiserCoverPhotoImageMaxSize. Test for it with errors.Is.
+var ErrMaxSizeExceeded = errors.New("max size exceeded")
+
+// MeteredReaderSettings configures a MeteredReader.
+type MeteredReaderSettings struct {
+
+	// MaxSize is the maximum number of bytes which can be read, zero is unlimited.
+	MaxSize int64
+
+	// BytesPerSecond throttles reads to the bandwidth, zero is unlimited.
+	BytesPerSecond int64
+
+	// Progress is called after each read with the total number of bytes read.
+	Progress func(bytesRead int64)
+}
+
+// A MeteredReader wraps a Reader, restricting the amount of data read, throttling the rate it is read
+// and reporting the progress of reading it.
+type MeteredReader struct {
+	r        io.Reader
+	settings MeteredReaderSettings
+	start    time.Time
+	read     int64
+}
+
+// NewMeteredReader creates a new MeteredReader reading from r.
+func NewMeteredReader(r io.Reader, settings MeteredReaderSettings) *MeteredReader {
+	return &MeteredReader{r: r, settings: settings}
+}
+
+// Read reads from the underlying Reader, returning an error wrapping ErrMaxSizeExceeded once more than MaxSize
+// bytes have been read, and blocking as required to keep within BytesPerSecond.
+func (m *MeteredReader) Read(p []byte) (n int, err error) {
+	if m.settings.BytesPerSecond > 0 {
+		if m.start.IsZero() {
+			m.start = time.Now()
+		}
+		// read at most 100ms of bandwidth at a time so that throttling is smooth
+		if chunk := m.settings.BytesPerSecond / 10; chunk > 0 && int64(len(p)) > chunk {
+			p = p[:chunk]
+		}
+	}
+	n, err = m.r.Read(p)
+	m.read += int64(n)
+	if m.settings.Progress != nil && n > 0 {
+		m.settings.Progress(m.read)
+	}
+	if m.settings.MaxSize > 0 && m.read > m.settings.MaxSize {
+		// if we have exceeded the max size then override the error
+		return n, fmt.Errorf("%w reading more than %d bytes", ErrMaxSizeExceeded, m.settings.MaxSize)
+	}
+	if m.settings.BytesPerSecond > 0 {
+		due := m.start.Add(time.Duration(float64(m.read) / float64(m.settings.BytesPerSecond) * float64(time.Second)))
+		if wait := time.Until(due); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return n, err
+}
+
+// BytesRead returns the number of bytes read.
+func (m *MeteredReader) BytesRead() int64 {
+	return m.read
+}
+
+// WithCoverPhotoBandwidthLimit throttles the upload of cover photos to bytesPerSecond, so that large uploads
+// do not saturate the network.
+func WithCoverPhotoBandwidthLimit(bytesPerSecond int64) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if bytesPerSecond < 0 {
+			return fmt.Errorf("invalid bandwidth limit %d", bytesPerSecond)
+		}
+		c.coverPhotoBandwidth = bytesPerSecond
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package flannel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMeteredReader(t *testing.T) {
+	var progress int64
+	r := NewMeteredReader(bytes.NewReader(make([]byte, 100)), MeteredReaderSettings{
+		MaxSize:  50,
+		Progress: func(n int64) { progress = n },
+	})
+	_, err := ioutil.ReadAll(r)
+	if !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Errorf("expected max size exceeded, got %v", err)
+	}
+	if progress != r.BytesRead() || progress <= 50 {
+		t.Errorf("expected progress to report bytes read, got %d of %d", progress, r.BytesRead())
+	}
+
+	start := time.Now()
+	r = NewMeteredReader(bytes.NewReader(make([]byte, 3000)), MeteredReaderSettings{BytesPerSecond: 10000})
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatalf("failed to read %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("expected reading 3000 bytes at 10000 bytes per second to be throttled, took %v", elapsed)
+	}
+}
+
+func TestCreateFundraiserCoverPhotoMaxSizeExceeded(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		fundraiserCreated(w, r)
+	}), WithCoverPhotoMaxSize(1024))
+	// hide the size of the photo so that it is only detected when read
+	photo := struct{ io.Reader }{bytes.NewReader(testPhoto(2048))}
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.png", photo))
+	if !errors.Is(err, ErrMaxSizeExceeded) || !IsErrorWithFundraiserCoverPhoto(err) {
+		t.Errorf("expected max size exceeded cover photo error, got %v", err)
+	}
+}
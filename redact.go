@@ -0,0 +1,95 @@
+package flannel
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// RedactionLevel sets what is redacted from log output and error strings.
+type RedactionLevel int
+
+// Redaction levels.
+const (
+	// RedactSecrets redacts access tokens, appsecret_proof and other credentials, it is the default.
+	RedactSecrets RedactionLevel = iota
+
+	// RedactPersonalData additionally redacts personal data, such as the names and emails of donors, from API responses.
+	RedactPersonalData
+)
+
+// WithRedaction sets what is redacted from log output and error strings, defaults to RedactSecrets.
+func WithRedaction(level RedactionLevel) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.redaction = level
+		return nil
+	}
+}
+
+const redacted = "REDACTED"
+
+// secretKeys are the query parameters and JSON keys holding credentials.
+var secretKeys = map[string]bool{
+	"access_token":      true,
+	"input_token":       true,
+	"appsecret_proof":   true,
+	"client_secret":     true,
+	"fb_exchange_token": true,
+}
+
+// personalDataKeys are the JSON keys holding personal data, redacted at RedactPersonalData.
+var personalDataKeys = map[string]bool{
+	"donor":      true,
+	"name":       true,
+	"first_name": true,
+	"last_name":  true,
+	"email":      true,
+	"phone":      true,
+	"address":    true,
+}
+
+var secretPattern = regexp.MustCompile(`(?i)(access_token|input_token|appsecret_proof|client_secret|fb_exchange_token|code)=[^&\s"']+|(bearer|oauth) [^\s"']+`)
+
+var jsonSecretPattern = regexp.MustCompile(`"(access_token|input_token|appsecret_proof|client_secret|fb_exchange_token)"\s*:\s*"[^"]*"`)
+
+// redactString redacts credentials from s, such as tokens in URLs, JSON and Authorization headers.
+func redactString(s string) string {
+	s = jsonSecretPattern.ReplaceAllString(s, `"$1":"`+redacted+`"`)
+	return secretPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if i := strings.IndexAny(m, "= "); i >= 0 {
+			return m[:i+1] + redacted
+		}
+		return redacted
+	})
+}
+
+// redactBody redacts the values of sensitive keys from a JSON body, falling back to redactString if body is not JSON.
+func (c APIClient) redactBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redactString(string(body))
+	}
+	b, err := json.Marshal(c.redactValue(v))
+	if err != nil {
+		return redactString(string(body))
+	}
+	return redactString(string(b))
+}
+
+func (c APIClient) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if secretKeys[k] || (c.redaction >= RedactPersonalData && personalDataKeys[k]) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = c.redactValue(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = c.redactValue(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,52 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"access_token":"secret-token","donor":{"name":"Jane Donor","email":"jane@example.com"},"error":{"message":"invalid","code":190}}`))
+	})
+
+	for level, unexpected := range map[RedactionLevel][]string{
+		RedactSecrets:      {"secret-token", "input-secret"},
+		RedactPersonalData: {"secret-token", "input-secret", "Jane Donor", "jane@example.com"},
+	} {
+		var logged []string
+		logger := LoggerFunc(func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		})
+		c := newTestClient(t, handler, WithLogger(logger, true), WithRedaction(level))
+		_, _, err := c.DebugToken(context.Background(), "app-token", "input-secret")
+		if err == nil {
+			t.Fatal("expected debug token to fail")
+		}
+		output := strings.Join(logged, "") + err.Error()
+		for _, s := range unexpected {
+			if strings.Contains(output, s) {
+				t.Errorf("expected %s to be redacted at level %d, got %s", s, level, output)
+			}
+		}
+		if !strings.Contains(output, "190") {
+			t.Errorf("expected error code to be logged, got %s", output)
+		}
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	for s, expected := range map[string]string{
+		"https://graph.facebook.com/debug_token?input_token=abc&x=1": "https://graph.facebook.com/debug_token?input_token=REDACTED&x=1",
+		"Authorization: Bearer abc":                                  "Authorization: Bearer REDACTED",
+		"appsecret_proof=abc":                                        "appsecret_proof=REDACTED",
+	} {
+		if actual := redactString(s); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
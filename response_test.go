@@ -0,0 +1,101 @@
+package flannel
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"description":"` + strings.Repeat("x", 1024) + `"}`))
+	}), WithMaxResponseSize(512))
+
+	_, _, err := c.GetFundraiser(context.Background(), "token", "1234")
+	if !IsErrorResponseTooLarge(err) {
+		t.Errorf("expected response too large error, got %v", err)
+	}
+
+	if err := WithMaxResponseSize(4096)(&c); err != nil {
+		t.Fatalf("failed to set max response size %v", err)
+	}
+	if _, _, err = c.GetFundraiser(context.Background(), "token", "1234"); err != nil {
+		t.Errorf("expected response within max size to be read, got %v", err)
+	}
+}
+
+func TestResponsesReleaseConnections(t *testing.T) {
+	responses := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Invalid parameter","code":100}}`))
+		},
+		func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		func(w http.ResponseWriter) {
+			w.Write([]byte(`not json`))
+		},
+		func(w http.ResponseWriter) {
+			// inject a read error with a corrupt gzip body
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write([]byte(`not gzip` + strings.Repeat(" ", 16*1024)))
+		},
+		func(w http.ResponseWriter) {
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusForbidden)
+		},
+		func(w http.ResponseWriter) {
+			w.Write([]byte(`{"id":"1234"}`))
+		},
+	}
+	var mu sync.Mutex
+	next := 0
+	states := make(map[net.Conn]http.ConnState)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		respond := responses[next%len(responses)]
+		next++
+		mu.Unlock()
+		respond(w)
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		states[conn] = state
+		mu.Unlock()
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	c := newTestServerClient(t, srv)
+
+	calls := 3 * len(responses)
+	for i := 0; i < calls; i++ {
+		if i%2 == 0 {
+			c.GetFundraiser(context.Background(), "token", "1234")
+		} else {
+			c.CreateFundraiser(CreateFundraiserParams{})
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	active := 0
+	for _, state := range states {
+		if state == http.StateActive {
+			active++
+		}
+	}
+	if active > 0 {
+		t.Errorf("expected all connections to be released, %d of %d remain active", active, len(states))
+	}
+	// a second connection may be dialed whilst an idle connection is being returned to the pool
+	if len(states) > 2 {
+		t.Errorf("expected connections to be re-used, got %d connections for %d calls", len(states), calls)
+	}
+}
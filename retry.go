@@ -0,0 +1,139 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how failed API calls are retried.
+// Transport errors, 429 and 5xx responses are retried.
+type RetryPolicy struct {
+
+	// MaxAttempts is the maximum number of attempts at an API call including the first, values below 2 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, which doubles for each subsequent retry, defaults to 200 milliseconds.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries, defaults to 5 seconds.
+	MaxBackoff time.Duration
+
+	// Timeout, if set, limits the time taken by each attempt, including reading the response.
+	Timeout time.Duration
+}
+
+// WithRetryPolicy retries failed API calls with exponential backoff.
+// API calls with request bodies which cannot be replayed, such as cover photo images read from a
+// non-seekable io.Reader, are not retried.
+func WithRetryPolicy(policy RetryPolicy) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.retryPolicy = policy.withDefaults()
+		return nil
+	}
+}
+
+// EndpointClass classifies API calls, so that they can be retried with different policies.
+type EndpointClass int
+
+// Endpoint classes.
+const (
+	// EndpointReads are GET requests.
+	EndpointReads EndpointClass = iota
+
+	// EndpointWrites are POST requests without files.
+	EndpointWrites
+
+	// EndpointUploads are POST requests uploading files, such as fundraisers created with a cover photo.
+	EndpointUploads
+)
+
+// WithEndpointRetryPolicy retries failed API calls of class with policy, instead of the policy set with
+// WithRetryPolicy. For example uploads of large cover photos may be given fewer attempts and a longer
+// timeout than reads.
+func WithEndpointRetryPolicy(class EndpointClass, policy RetryPolicy) func(*APIClient) error {
+	return func(c *APIClient) error {
+		if class < EndpointReads || class > EndpointUploads {
+			return fmt.Errorf("unknown endpoint class %d", class)
+		}
+		if c.endpointRetryPolicies == nil {
+			c.endpointRetryPolicies = make(map[EndpointClass]RetryPolicy)
+		}
+		c.endpointRetryPolicies[class] = policy.withDefaults()
+		return nil
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 200 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	return p
+}
+
+// retryPolicyFor returns the retry policy of the class of req.
+func (c APIClient) retryPolicyFor(req *http.Request) RetryPolicy {
+	class := EndpointWrites
+	switch {
+	case req.Method == "GET" || req.Method == "HEAD":
+		class = EndpointReads
+	case strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/"):
+		class = EndpointUploads
+	}
+	if policy, exists := c.endpointRetryPolicies[class]; exists {
+		return policy
+	}
+	return c.retryPolicy
+}
+
+// attemptWithTimeout makes an attempt at req, limited to timeout if set. The timeout also applies to reading
+// the response body, so it is cancelled once the body is closed.
+func (c APIClient) attemptWithTimeout(ctx context.Context, timeout time.Duration, req *http.Request) (*http.Response, error) {
+	if timeout <= 0 {
+		return c.attempt(ctx, req)
+	}
+	actx, cancel := context.WithTimeout(ctx, timeout)
+	res, err := c.attempt(actx, req.WithContext(actx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// retryable returns true if the attempt at an API call should be retried.
+func (p RetryPolicy) retryable(ctx context.Context, attempt int, res *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil || IsCircuitOpen(err) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before retrying after attempt, with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (p RetryPolicy) wait(ctx context.Context, clock Clock, attempt int) error {
+	if err := sleep(ctx, clock, p.backoff(attempt)); err != nil {
+		return fmt.Errorf("error waiting to retry request %w", err)
+	}
+	return nil
+}
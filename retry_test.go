@@ -0,0 +1,99 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryRewindsCoverPhoto(t *testing.T) {
+	photo := testPhoto(5000)
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		file, _, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Fatalf("attempt %d missing cover photo %v", n, err)
+		}
+		content, _ := ioutil.ReadAll(file)
+		if !bytes.Equal(content, photo) {
+			t.Errorf("attempt %d sent %d bytes of cover photo, expected %d", n, len(content), len(photo))
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fundraiserCreated(w, r)
+	}), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	status, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(photo)))
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("failed to create fundraiser %d %v", status, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryNotAttemptedForOneShotReader(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	oneShot := struct{ io.Reader }{bytes.NewReader(testPhoto(0))}
+	status, _, err := c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.jpg", oneShot))
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("expected service unavailable error, got %d %v", status, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single attempt, got %d", calls)
+	}
+
+	status, _, err = c.CreateFundraiser(CreateFundraiserParams{})
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("expected service unavailable error, got %d %v", status, err)
+	}
+	if calls != 4 {
+		t.Errorf("expected 3 more attempts without a cover photo, got %d", calls-1)
+	}
+}
+
+func TestEndpointRetryPolicy(t *testing.T) {
+	var reads, uploads int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if atomic.AddInt32(&reads, 1) == 1 {
+				// the first read times out
+				time.Sleep(200 * time.Millisecond)
+			}
+			w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		ioutil.ReadAll(r.Body)
+		atomic.AddInt32(&uploads, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithEndpointRetryPolicy(EndpointReads, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, Timeout: 50 * time.Millisecond}),
+		WithEndpointRetryPolicy(EndpointUploads, RetryPolicy{MaxAttempts: 1}),
+	)
+
+	if _, _, err := c.GetFundraiser(context.Background(), "token", "1"); err != nil {
+		t.Fatalf("expected read to be retried after timing out, got %v", err)
+	}
+	if reads != 2 {
+		t.Errorf("expected 2 reads, got %d", reads)
+	}
+	c.CreateFundraiser(CreateFundraiserParams{}, WithFundraiserCoverPhotoImage("photo.jpg", bytes.NewReader(testPhoto(0))))
+	if uploads != 1 {
+		t.Errorf("expected a single upload attempt, got %d", uploads)
+	}
+}
@@ -0,0 +1,182 @@
+package flannel
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// EmojiPolicy is how a Sanitizer treats emoji.
+type EmojiPolicy int
+
+// Emoji policies.
+const (
+	EmojiAllow EmojiPolicy = iota
+	EmojiStrip
+	EmojiReject
+)
+
+// SanitizeSettings configures a Sanitizer.
+type SanitizeSettings struct {
+	Emoji EmojiPolicy
+
+	// Normalize normalizes text, defaults to Unicode NFC with norm.NFC.String from golang.org/x/text/unicode/norm.
+	Normalize func(string) string
+}
+
+// A Sanitizer cleans up user supplied fundraiser titles and descriptions, which Facebook otherwise rejects with
+// unhelpful errors. Control and invisible formatting characters are removed, whitespace is normalized, with
+// descriptions keeping single blank lines between paragraphs, and text is Unicode normalized.
+type Sanitizer struct {
+	settings SanitizeSettings
+}
+
+// NewSanitizer creates a new Sanitizer.
+func NewSanitizer(settings SanitizeSettings) *Sanitizer {
+	if settings.Normalize == nil {
+		settings.Normalize = norm.NFC.String
+	}
+	return &Sanitizer{settings: settings}
+}
+
+// WithContentSanitizer sanitizes the title and description of fundraisers created, and the name and description
+// fields of fundraisers updated, failing the API call if they are invalid once sanitized.
+func WithContentSanitizer(settings SanitizeSettings) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.sanitizer = NewSanitizer(settings)
+		return nil
+	}
+}
+
+// Title returns the sanitized title, or an error if it is empty, too long or contains rejected emoji.
+func (s *Sanitizer) Title(title string) (string, error) {
+	title, err := s.sanitize("title", title, false)
+	if err != nil {
+		return "", err
+	}
+	if title == "" {
+		return "", fmt.Errorf("title is empty")
+	}
+	if n := utf8.RuneCountInString(title); n > FundraiserTitleMaxLength {
+		return "", fmt.Errorf("title is %d characters long, the maximum is %d", n, FundraiserTitleMaxLength)
+	}
+	return title, nil
+}
+
+// Description returns the sanitized description, or an error if it is too long or contains rejected emoji.
+func (s *Sanitizer) Description(description string) (string, error) {
+	description, err := s.sanitize("description", description, true)
+	if err != nil {
+		return "", err
+	}
+	if n := utf8.RuneCountInString(description); n > FundraiserDescriptionMaxLength {
+		return "", fmt.Errorf("description is %d characters long, the maximum is %d", n, FundraiserDescriptionMaxLength)
+	}
+	return description, nil
+}
+
+// Apply sanitizes the Title and Description of params.
+func (s *Sanitizer) Apply(params *CreateFundraiserParams) error {
+	title, err := s.Title(params.Title)
+	if err != nil {
+		return err
+	}
+	description, err := s.Description(params.Description)
+	if err != nil {
+		return err
+	}
+	params.Title, params.Description = title, description
+	return nil
+}
+
+// applyFields sanitizes the name and description of fields, as passed to UpdateFundraiser.
+func (s *Sanitizer) applyFields(fields map[string]string) (map[string]string, error) {
+	sanitized := make(map[string]string, len(fields))
+	for k, v := range fields {
+		var err error
+		switch k {
+		case "name":
+			v, err = s.Title(v)
+		case "description":
+			v, err = s.Description(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sanitized[k] = v
+	}
+	return sanitized, nil
+}
+
+func (s *Sanitizer) sanitize(name string, text string, multiline bool) (string, error) {
+	if s.settings.Normalize != nil {
+		text = s.settings.Normalize(text)
+	}
+	text = strings.ToValidUTF8(text, "")
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	var b strings.Builder
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case r == '\n' && multiline:
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case isEmoji(r) || r == zeroWidthJoiner && i > 0 && i < len(runes)-1 && isEmoji(runes[i-1]) && isEmoji(runes[i+1]):
+			switch s.settings.Emoji {
+			case EmojiReject:
+				return "", fmt.Errorf("%s contains emoji", name)
+			case EmojiAllow:
+				b.WriteRune(r)
+			}
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r) && !isJoinerOrMark(r):
+			// removed, other than joiners and directional marks, and the zero width joiner of emoji sequences
+			// which is handled as emoji
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	// collapse runs of spaces, and of blank lines
+	var lines []string
+	blank := 0
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank++
+			continue
+		}
+		if blank > 0 && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		blank = 0
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// zeroWidthJoiner joins emoji into sequences, and also shapes the text of some scripts.
+const zeroWidthJoiner = 0x200D
+
+// isEmoji returns true if r is an emoji, or a character used to compose emoji sequences other than the zero
+// width joiner, which is only part of an emoji sequence between two emoji.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // emoticons, symbols, pictographs, flags
+		r >= 0x2600 && r <= 0x27BF,   // miscellaneous symbols and dingbats
+		r >= 0xE0020 && r <= 0xE007F, // tags
+		r == 0xFE0F, r == 0x20E3,     // variation selector and keycap
+		r == 0x2B50, r == 0x2B55, r == 0x231A, r == 0x231B:
+		return true
+	}
+	return false
+}
+
+// isJoinerOrMark returns true if r is a zero width non-joiner or joiner, or a left-to-right or right-to-left mark,
+// invisible formatting characters which are kept as they change how some scripts are written.
+func isJoinerOrMark(r rune) bool {
+	return r == 0x200C || r == zeroWidthJoiner || r == 0x200E || r == 0x200F
+}
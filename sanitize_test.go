@@ -0,0 +1,65 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSanitizer(t *testing.T) {
+	s := NewSanitizer(SanitizeSettings{Emoji: EmojiStrip})
+	title, err := s.Title("  Run\tfor\u200b the\x00 kids 🏃\u200d♀\ufe0f  ")
+	if err != nil {
+		t.Fatalf("failed to sanitize title %v", err)
+	}
+	if title != "Run for the kids" {
+		t.Errorf("unexpected title %q", title)
+	}
+	description, err := s.Description("Hello\r\n\r\n\r\n  world  \rbye\u202e")
+	if err != nil {
+		t.Fatalf("failed to sanitize description %v", err)
+	}
+	if description != "Hello\n\nworld\nbye" {
+		t.Errorf("unexpected description %q", description)
+	}
+
+	if title, _ := NewSanitizer(SanitizeSettings{}).Title("Go 🏃\u200d♀\ufe0f"); title != "Go 🏃\u200d♀\ufe0f" {
+		t.Errorf("expected emoji to be kept, got %q", title)
+	}
+	if _, err := NewSanitizer(SanitizeSettings{Emoji: EmojiReject}).Title("Go 🏃"); err == nil {
+		t.Error("expected emoji to be rejected")
+	}
+	if _, err := s.Title("\x00 \u200b"); err == nil {
+		t.Error("expected empty title to be rejected")
+	}
+	if _, err := s.Title(strings.Repeat("a", FundraiserTitleMaxLength+1)); err == nil {
+		t.Error("expected long title to be rejected")
+	}
+	// joiners and directional marks outside emoji sequences are kept
+	if title, _ := s.Title("क्\u200dष \u200cفا \u200eA\u200f 🏃\u200d"); title != "क्\u200dष \u200cفا \u200eA\u200f \u200d" {
+		t.Errorf("expected joiners and marks to be kept, got %q", title)
+	}
+	if title, _ := NewSanitizer(SanitizeSettings{}).Title("Cafe\u0301"); title != "Caf\u00e9" {
+		t.Errorf("expected title to be NFC normalized by default, got %q", title)
+	}
+	upper := NewSanitizer(SanitizeSettings{Normalize: strings.ToUpper})
+	if title, _ := upper.Title("run"); title != "RUN" {
+		t.Errorf("expected title to be normalized, got %q", title)
+	}
+}
+
+func TestUpdateFundraiserSanitized(t *testing.T) {
+	var name string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		name = r.PostForm.Get("name")
+		w.Write([]byte(`{"success":true}`))
+	}), WithContentSanitizer(SanitizeSettings{}))
+	if _, _, err := c.UpdateFundraiser(context.Background(), "token", "1", map[string]string{"name": " Bake\x07  sale "}); err != nil {
+		t.Fatalf("failed to update fundraiser %v", err)
+	}
+	if name != "Bake sale" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
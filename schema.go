@@ -0,0 +1,12 @@
+package flannel
+
+import _ "embed"
+
+//go:embed schema.json
+var schema []byte
+
+// JSONSchema returns a JSON Schema document defining the params and results of the typed endpoint bindings,
+// and the payloads sent by API calls such as CreateFundraiser, so that services not written in Go can validate them.
+func JSONSchema() []byte {
+	return append([]byte(nil), schema...)
+}
@@ -0,0 +1,26 @@
+package flannel
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var s struct {
+		Defs map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+			Required   []string               `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSONSchema(), &s); err != nil {
+		t.Fatalf("invalid JSON Schema %v", err)
+	}
+	for _, name := range []string{"FundraiserDetails", "SetFundraiserGoalParams", "CreateFundraiserRequest"} {
+		if len(s.Defs[name].Properties) == 0 {
+			t.Errorf("expected schema of %s", name)
+		}
+	}
+	if len(s.Defs["CreateFundraiserRequest"].Required) != 8 {
+		t.Errorf("unexpected required fields %v", s.Defs["CreateFundraiserRequest"].Required)
+	}
+}
@@ -0,0 +1,97 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Permission scopes requested with Facebook Login.
+const (
+	ScopePublicProfile     = "public_profile"
+	ScopeManageFundraisers = "manage_fundraisers"
+)
+
+// PermissionsEndpoint is the Facebook API endpoint listing the permission scopes granted by a user.
+const PermissionsEndpoint = GraphAPIEndpoint + "/me/permissions"
+
+// methodScopes are the permission scopes required by each APIClient method making API calls with a user access token.
+var methodScopes = map[string][]string{
+	"CreateFundraiser":            {ScopeManageFundraisers},
+	"CreateFundraiserWithContext": {ScopeManageFundraisers},
+	"CreateFundraisers":           {ScopeManageFundraisers},
+	"GetFundraiser":               {ScopeManageFundraisers},
+	"UpdateFundraiser":            {ScopeManageFundraisers},
+	"ListFundraisers":             {ScopeManageFundraisers},
+	"ListDonations":               {ScopeManageFundraisers},
+	"ExportFundraisers":           {ScopeManageFundraisers},
+	"ExportDonations":             {ScopeManageFundraisers},
+	"PlanSync":                    {ScopeManageFundraisers},
+	"ApplySync":                   {ScopeManageFundraisers},
+	"CheckExternalIDs":            {ScopeManageFundraisers},
+	"RefreshMirror":               {ScopeManageFundraisers},
+	"ChangedSince":                {ScopeManageFundraisers},
+}
+
+// RequiredScopes returns the permission scopes required by the APIClient method named method, such as
+// "CreateFundraiser", or nil if it requires none.
+func RequiredScopes(method string) []string {
+	s, exists := methodScopes[method]
+	if !exists {
+		s = generatedScopes[method]
+	}
+	return append([]string(nil), s...)
+}
+
+type scopeError struct {
+	missing []string
+}
+
+func (e scopeError) Error() string {
+	return fmt.Sprintf("missing permission scopes %s", strings.Join(e.missing, ","))
+}
+
+// MissingScopes returns the permission scopes missing from an access token if err was returned by CheckScopes,
+// or by a LoginHandler with RequireScopes set.
+func MissingScopes(err error) []string {
+	var e scopeError
+	if errors.As(err, &e) {
+		return e.missing
+	}
+	return nil
+}
+
+// CheckScopes checks that the user identified by accessToken has granted all of scopes, returning an error
+// for which MissingScopes returns the scopes not granted.
+func (c APIClient) CheckScopes(ctx context.Context, accessToken string, scopes ...string) error {
+	_, result, err := c.get(ctx, accessToken, PermissionsEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	var permissions struct {
+		Data []struct {
+			Permission string `json:"permission"`
+			Status     string `json:"status"`
+		} `json:"data"`
+	}
+	if err := decodeResult(result, &permissions); err != nil {
+		return err
+	}
+	granted := make(map[string]bool)
+	for _, p := range permissions.Data {
+		granted[p.Permission] = p.Status == "granted"
+	}
+	var missing []string
+	for _, scope := range scopes {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return scopeError{missing: uniqueStrings(missing)}
+	}
+	return nil
+}
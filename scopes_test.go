@@ -0,0 +1,53 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckScopes(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2.8/oauth/access_token":
+			fmt.Fprint(w, `{"access_token":"user-token","token_type":"bearer","expires_in":5183944}`)
+		case "/v2.8/me/permissions":
+			fmt.Fprint(w, `{"data":[{"permission":"public_profile","status":"granted"},{"permission":"manage_fundraisers","status":"declined"}]}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	ctx := context.Background()
+	if err := c.CheckScopes(ctx, "token", ScopePublicProfile); err != nil {
+		t.Errorf("expected granted scope to pass, got %v", err)
+	}
+	err := c.CheckScopes(ctx, "token", RequiredScopes("CreateFundraiser")...)
+	if fmt.Sprint(MissingScopes(err)) != "[manage_fundraisers]" {
+		t.Errorf("expected missing manage_fundraisers, got %v", err)
+	}
+	if fmt.Sprint(RequiredScopes("SetFundraiserGoal")) != "[manage_fundraisers]" || RequiredScopes("GetCharity") != nil {
+		t.Error("unexpected required scopes")
+	}
+
+	var loginErr error
+	login := c.NewLoginHandler(LoginSettings{
+		Scopes:        []string{ScopePublicProfile, ScopeManageFundraisers},
+		RequireScopes: true,
+		OnToken: func(ctx context.Context, token LoginToken) error {
+			t.Error("expected login to fail")
+			return nil
+		},
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) { loginErr = err },
+	})
+	w := httptest.NewRecorder()
+	login.Redirect().ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+	cookie := w.Result().Cookies()[0]
+	r := httptest.NewRequest("GET", "/login/callback?code=abc&state="+cookie.Value, nil)
+	r.AddCookie(cookie)
+	login.Callback().ServeHTTP(httptest.NewRecorder(), r)
+	if !IsLoginDenied(loginErr) || fmt.Sprint(MissingScopes(loginErr)) != "[manage_fundraisers]" {
+		t.Errorf("expected login to be denied for missing scopes, got %v", loginErr)
+	}
+}
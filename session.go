@@ -0,0 +1,202 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SessionSettings configures Sessions.
+type SessionSettings struct {
+
+	// AppID and AppSecret identify the Facebook app, and are used to refresh access tokens.
+	AppID     string
+	AppSecret string
+
+	// Tokens stores the access token of each user, defaults to a MemoryTokenStore.
+	// Use an EncryptedTokenStore so that access tokens are encrypted at rest.
+	Tokens TokenStore
+
+	// RefreshBefore is how long before they expire that access tokens are refreshed, defaults to 7 days.
+	// Set it to a negative duration to never refresh access tokens.
+	RefreshBefore time.Duration
+
+	// OnError is called with errors refreshing access tokens, which are otherwise ignored until the token expires.
+	OnError func(userID string, err error)
+}
+
+// Session binds a user of the platform to their Facebook identity and access token.
+type Session struct {
+	UserID         string
+	FacebookUserID string
+	Token          LoginToken
+}
+
+// Sessions make API calls on behalf of users of the platform, rather than with raw access tokens.
+// Access tokens are bound to users with Bind, typically from LoginSettings.OnToken, and are refreshed
+// as they near expiry, by exchanging them for new long-lived access tokens.
+//
+//	sessions := c.NewSessions(flannel.SessionSettings{...})
+//	_, err := sessions.Bind(ctx, userID, token)
+//	_, result, err := sessions.CreateFundraiser(ctx, userID, params)
+type Sessions struct {
+	c        APIClient
+	settings SessionSettings
+
+	mu sync.Mutex
+}
+
+// NewSessions creates new Sessions which make API calls using c.
+func (c APIClient) NewSessions(settings SessionSettings) *Sessions {
+	if settings.Tokens == nil {
+		settings.Tokens = NewMemoryTokenStore()
+	}
+	if settings.RefreshBefore == 0 {
+		settings.RefreshBefore = 7 * 24 * time.Hour
+	}
+	return &Sessions{c: c, settings: settings}
+}
+
+type sessionError struct {
+	userID  string
+	expired bool
+}
+
+func (e sessionError) Error() string {
+	if e.expired {
+		return fmt.Sprintf("session of user %s has expired", e.userID)
+	}
+	return fmt.Sprintf("no session for user %s", e.userID)
+}
+
+// IsLoginRequired returns true if err was returned because a user has no session, or the access token of their
+// session has expired, so they must complete Facebook Login again.
+func IsLoginRequired(err error) bool {
+	var e sessionError
+	return errors.As(err, &e)
+}
+
+// Bind binds token to the user of the platform with userID, looking up the Facebook user the token belongs to.
+func (s *Sessions) Bind(ctx context.Context, userID string, token LoginToken) (Session, error) {
+	if token.UserID == "" {
+		_, result, err := s.c.get(ctx, token.AccessToken, GraphAPIEndpoint+"/me", fieldsQuery([]string{"id"}))
+		if err != nil {
+			return Session{}, err
+		}
+		if token.UserID, _ = result["id"].(string); token.UserID == "" {
+			return Session{}, errors.New("missing facebook user id")
+		}
+	}
+	if err := s.settings.Tokens.Put(ctx, userID, token); err != nil {
+		return Session{}, err
+	}
+	return Session{UserID: userID, FacebookUserID: token.UserID, Token: token}, nil
+}
+
+// Get returns the session of the user with userID, refreshing its access token if it is due to expire.
+func (s *Sessions) Get(ctx context.Context, userID string) (Session, error) {
+	token, exists, err := s.settings.Tokens.Get(ctx, userID)
+	if err != nil {
+		return Session{}, err
+	}
+	if !exists {
+		return Session{}, sessionError{userID: userID}
+	}
+	if s.refreshDue(token) {
+		if token, err = s.refresh(ctx, userID); err != nil {
+			return Session{}, err
+		}
+	}
+	if !token.Expiry.IsZero() && !s.c.now().Before(token.Expiry) {
+		return Session{}, sessionError{userID: userID, expired: true}
+	}
+	return Session{UserID: userID, FacebookUserID: token.UserID, Token: token}, nil
+}
+
+// Remove removes the session of the user with userID, typically as they log out.
+func (s *Sessions) Remove(ctx context.Context, userID string) error {
+	return s.settings.Tokens.Delete(ctx, userID)
+}
+
+// AccessToken returns the access token of the user with userID.
+func (s *Sessions) AccessToken(ctx context.Context, userID string) (string, error) {
+	session, err := s.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return session.Token.AccessToken, nil
+}
+
+// CreateFundraiser creates a new Facebook Fundraiser on behalf of the user with userID, the AccessToken of params is ignored.
+func (s *Sessions) CreateFundraiser(ctx context.Context, userID string, params CreateFundraiserParams, options ...func(*multipart.Writer) error) (status int, result map[string]interface{}, err error) {
+	if params.AccessToken, err = s.AccessToken(ctx, userID); err != nil {
+		return 0, nil, err
+	}
+	return s.c.CreateFundraiserWithContext(ctx, params, options...)
+}
+
+// GetFundraiser reads an existing Facebook Fundraiser on behalf of the user with userID.
+func (s *Sessions) GetFundraiser(ctx context.Context, userID string, fundraiserID string, fields ...string) (status int, result map[string]interface{}, err error) {
+	accessToken, err := s.AccessToken(ctx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.c.GetFundraiser(ctx, accessToken, fundraiserID, fields...)
+}
+
+// UpdateFundraiser updates the fields of an existing Facebook Fundraiser on behalf of the user with userID.
+func (s *Sessions) UpdateFundraiser(ctx context.Context, userID string, fundraiserID string, fields map[string]string) (status int, result map[string]interface{}, err error) {
+	accessToken, err := s.AccessToken(ctx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.c.UpdateFundraiser(ctx, accessToken, fundraiserID, fields)
+}
+
+// refreshDue returns true if token expires within RefreshBefore.
+func (s *Sessions) refreshDue(token LoginToken) bool {
+	if token.Expiry.IsZero() || s.settings.RefreshBefore < 0 {
+		return false
+	}
+	now := s.c.now()
+	return now.Before(token.Expiry) && !now.Add(s.settings.RefreshBefore).Before(token.Expiry)
+}
+
+// refresh exchanges the access token of the user with userID for a new long-lived access token. If the exchange
+// fails the existing token is returned, so that it is used until it expires.
+func (s *Sessions) refresh(ctx context.Context, userID string) (LoginToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the token may have been refreshed whilst waiting
+	token, exists, err := s.settings.Tokens.Get(ctx, userID)
+	if err != nil {
+		return LoginToken{}, err
+	}
+	if !exists {
+		return LoginToken{}, sessionError{userID: userID}
+	}
+	if !s.refreshDue(token) {
+		return token, nil
+	}
+	refreshed, err := s.c.accessToken(ctx, url.Values{
+		"grant_type":        {"fb_exchange_token"},
+		"client_id":         {s.settings.AppID},
+		"client_secret":     {s.settings.AppSecret},
+		"fb_exchange_token": {token.AccessToken},
+	})
+	if err != nil {
+		if s.settings.OnError != nil {
+			s.settings.OnError(userID, err)
+		}
+		return token, nil
+	}
+	refreshed.UserID = token.UserID
+	if err := s.settings.Tokens.Put(ctx, userID, refreshed); err != nil {
+		return LoginToken{}, err
+	}
+	return refreshed, nil
+}
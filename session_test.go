@@ -0,0 +1,56 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	var created string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2.8/me":
+			fmt.Fprint(w, `{"id":"fb-1"}`)
+		case "/v2.8/oauth/access_token":
+			if r.FormValue("grant_type") != "fb_exchange_token" || r.FormValue("fb_exchange_token") != "short" {
+				t.Errorf("unexpected token exchange %s", r.URL)
+			}
+			fmt.Fprint(w, `{"access_token":"long","token_type":"bearer","expires_in":5183944}`)
+		default:
+			created = r.Header.Get("Authorization")
+			fundraiserCreated(w, r)
+		}
+	}))
+	ctx := context.Background()
+	sessions := c.NewSessions(SessionSettings{AppID: "app", AppSecret: "secret"})
+
+	session, err := sessions.Bind(ctx, "user-1", LoginToken{AccessToken: "short", Expiry: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("failed to bind session %v", err)
+	}
+	if session.FacebookUserID != "fb-1" {
+		t.Errorf("unexpected facebook user %q", session.FacebookUserID)
+	}
+
+	// the token expires within a week, so is refreshed
+	if _, _, err := sessions.CreateFundraiser(ctx, "user-1", CreateFundraiserParams{Title: "Test"}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if created != "Bearer long" {
+		t.Errorf("expected refreshed token to be used, got %q", created)
+	}
+	if session, _ := sessions.Get(ctx, "user-1"); session.FacebookUserID != "fb-1" || time.Until(session.Token.Expiry) < 24*time.Hour {
+		t.Errorf("unexpected refreshed session %+v", session)
+	}
+
+	if _, err := sessions.Get(ctx, "user-2"); !IsLoginRequired(err) {
+		t.Errorf("expected missing session to require login, got %v", err)
+	}
+	sessions.Bind(ctx, "user-3", LoginToken{AccessToken: "expired", UserID: "fb-3", Expiry: time.Now().Add(-time.Hour)})
+	if _, err := sessions.AccessToken(ctx, "user-3"); !IsLoginRequired(err) {
+		t.Errorf("expected expired session to require login, got %v", err)
+	}
+}
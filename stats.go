@@ -0,0 +1,132 @@
+package flannel
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the latency histogram buckets reported by Stats.
+// The final bucket of each histogram counts calls slower than the largest bound.
+var LatencyBuckets = []time.Duration{
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats is a snapshot of the API calls made by an APIClient.
+type Stats struct {
+	Endpoints []EndpointStats
+}
+
+// EndpointStats reports the outcomes and latencies of the HTTP requests made to an endpoint.
+// Each attempt is counted, so a retried API call is counted more than once.
+type EndpointStats struct {
+
+	// Method and Endpoint identify the API call, ids in the path of the endpoint are replaced with {id}.
+	Method   string
+	Endpoint string
+
+	// Requests is the number of requests sent.
+	Requests int64
+
+	// Succeeded, ClientErrors and ServerErrors count responses with 2xx/3xx, 4xx and 5xx status codes.
+	Succeeded    int64
+	ClientErrors int64
+	ServerErrors int64
+
+	// TransportErrors counts requests which failed without a response.
+	TransportErrors int64
+
+	// LatencyBuckets counts requests by the time taken to receive the response headers, using the bounds of
+	// LatencyBuckets with an additional final bucket. TotalLatency is the sum of all latencies.
+	LatencyBuckets []int64
+	TotalLatency   time.Duration
+}
+
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+type statsCollector struct {
+	mu        sync.Mutex
+	endpoints map[endpointKey]*EndpointStats
+}
+
+// record updates the stats of the endpoint requested by req.
+func (s *statsCollector) record(req *http.Request, res *http.Response, err error, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	key := endpointKey{method: req.Method, endpoint: statsEndpoint(req)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.endpoints == nil {
+		s.endpoints = make(map[endpointKey]*EndpointStats)
+	}
+	e, ok := s.endpoints[key]
+	if !ok {
+		e = &EndpointStats{Method: key.method, Endpoint: key.endpoint, LatencyBuckets: make([]int64, len(LatencyBuckets)+1)}
+		s.endpoints[key] = e
+	}
+	e.Requests++
+	switch {
+	case err != nil:
+		e.TransportErrors++
+	case res.StatusCode >= http.StatusInternalServerError:
+		e.ServerErrors++
+	case res.StatusCode >= http.StatusBadRequest:
+		e.ClientErrors++
+	default:
+		e.Succeeded++
+	}
+	e.LatencyBuckets[sort.Search(len(LatencyBuckets), func(i int) bool { return latency <= LatencyBuckets[i] })]++
+	e.TotalLatency += latency
+}
+
+// statsEndpoint returns the path of req without the Graph API version, replacing numeric ids with {id}.
+func statsEndpoint(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(segments) > 0 && strings.HasPrefix(segments[0], "v") && strings.Contains(segments[0], ".") {
+		segments = segments[1:]
+	}
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789_") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// Stats returns a snapshot of the per-endpoint outcomes and latencies of the API calls made by the APIClient,
+// for export to any metrics backend. Endpoints are sorted by endpoint then method.
+func (c APIClient) Stats() Stats {
+	if c.stats == nil {
+		return Stats{}
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	stats := Stats{Endpoints: make([]EndpointStats, 0, len(c.stats.endpoints))}
+	for _, e := range c.stats.endpoints {
+		snapshot := *e
+		snapshot.LatencyBuckets = append([]int64(nil), e.LatencyBuckets...)
+		stats.Endpoints = append(stats.Endpoints, snapshot)
+	}
+	sort.Slice(stats.Endpoints, func(i, j int) bool {
+		a, b := stats.Endpoints[i], stats.Endpoints[j]
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Method < b.Method
+	})
+	return stats
+}
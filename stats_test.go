@@ -0,0 +1,56 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2.8/404" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1234"}`))
+	}))
+	ctx := context.Background()
+	c.GetFundraiser(ctx, "token", "1234")
+	c.GetFundraiser(ctx, "token", "5678")
+	c.GetFundraiser(ctx, "token", "404")
+	c.GetCharity(ctx, "token", "1234")
+
+	stats := c.Stats()
+	if len(stats.Endpoints) != 1 {
+		t.Fatalf("expected stats for 1 endpoint, got %+v", stats.Endpoints)
+	}
+	e := stats.Endpoints[0]
+	if e.Method != "GET" || e.Endpoint != "/{id}" {
+		t.Errorf("expected stats for GET /{id}, got %s %s", e.Method, e.Endpoint)
+	}
+	if e.Requests != 4 || e.Succeeded != 3 || e.ClientErrors != 1 {
+		t.Errorf("unexpected outcomes %+v", e)
+	}
+	var counted int64
+	for _, n := range e.LatencyBuckets {
+		counted += n
+	}
+	if counted != 4 || e.TotalLatency <= 0 {
+		t.Errorf("unexpected latencies %+v", e)
+	}
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	for u, expected := range map[string]string{
+		"https://graph.facebook.com/v2.8/me/fundraisers":     "/me/fundraisers",
+		"https://graph.facebook.com/v2.8/1234/donations":     "/{id}/donations",
+		"https://graph.facebook.com/v2.8/1234_5678":          "/{id}",
+		"https://graph.facebook.com/v2.8/debug_token?a=1234": "/debug_token",
+	} {
+		req, _ := http.NewRequest("GET", u, nil)
+		if actual := statsEndpoint(req); actual != expected {
+			t.Errorf("expected %s to be %s, got %s", u, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package flannel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WithStrictDecoding checks the results of the typed endpoint bindings, such as GetFundraiserDetails, for fields
+// which are not decoded into their result types, so that fields added or renamed by Facebook are noticed before
+// they silently become zero values. If onUnknown is set it is called with the method and the paths of the unknown
+// fields, such as "charity.category", otherwise the API calls fail with an error for which UnknownFields returns them.
+func WithStrictDecoding(onUnknown func(method string, fields []string)) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.strictDecoding = &strictDecoding{onUnknown: onUnknown}
+		return nil
+	}
+}
+
+type strictDecoding struct {
+	onUnknown func(method string, fields []string)
+}
+
+type unknownFieldsError struct {
+	method string
+	fields []string
+}
+
+func (e unknownFieldsError) Error() string {
+	return fmt.Sprintf("error decoding result of %s unknown fields %s", e.method, strings.Join(e.fields, ","))
+}
+
+// UnknownFields returns the paths of the fields which could not be decoded if err was returned by an API call
+// when configured with WithStrictDecoding.
+func UnknownFields(err error) []string {
+	var e unknownFieldsError
+	if errors.As(err, &e) {
+		return e.fields
+	}
+	return nil
+}
+
+// decode decodes the result of an API call made by method into v, checking for unknown fields when configured
+// with WithStrictDecoding.
+func (c APIClient) decode(method string, result map[string]interface{}, v interface{}) error {
+	if c.strictDecoding != nil {
+		var fields []string
+		unknownFields(result, reflect.TypeOf(v), "", &fields)
+		if len(fields) > 0 {
+			sort.Strings(fields)
+			fields = uniqueStrings(fields)
+			if c.strictDecoding.onUnknown == nil {
+				return unknownFieldsError{method: method, fields: fields}
+			}
+			c.strictDecoding.onUnknown(method, fields)
+		}
+	}
+	return decodeResult(result, v)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields appends the paths of the fields of value which are not decoded into type t to fields.
+func unknownFields(value interface{}, t reflect.Type, path string, fields *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		known := make(map[string]reflect.Type)
+		structFields(t, known)
+		for name, field := range v {
+			if ft, exists := known[name]; exists {
+				unknownFields(field, ft, path+name+".", fields)
+			} else {
+				*fields = append(*fields, path+name)
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for _, item := range v {
+			unknownFields(item, t.Elem(), path, fields)
+		}
+	}
+}
+
+// structFields adds the JSON names and types of the fields of struct type t to known.
+func structFields(t reflect.Type, known map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structFields(ft, known)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		known[name] = f.Type
+	}
+}
@@ -0,0 +1,57 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","name":"Marathon","goal_amount":10000,"fundraiser_owner":{"id":"1"},"raised":2500}`)
+	})
+	ctx := context.Background()
+	params := GetFundraiserDetailsParams{FundraiserID: "1234"}
+
+	c := newTestClient(t, handler, WithStrictDecoding(nil))
+	_, err := c.GetFundraiserDetails(ctx, "token", params)
+	if got := UnknownFields(err); !reflect.DeepEqual(got, []string{"fundraiser_owner", "raised"}) {
+		t.Errorf("expected unknown fields error, got %v", err)
+	}
+
+	var reported []string
+	c = newTestClient(t, handler, WithStrictDecoding(func(method string, fields []string) {
+		reported = append(reported, method)
+		reported = append(reported, fields...)
+	}))
+	result, err := c.GetFundraiserDetails(ctx, "token", params)
+	if err != nil || result.Name != "Marathon" {
+		t.Errorf("expected result to be decoded, got %v %v", result, err)
+	}
+	if !reflect.DeepEqual(reported, []string{"GetFundraiserDetails", "fundraiser_owner", "raised"}) {
+		t.Errorf("unexpected unknown fields reported %v", reported)
+	}
+
+	// nested objects and lists are checked, except types decoding themselves
+	type donation struct {
+		ID      string    `json:"id"`
+		Created time.Time `json:"created_time"`
+	}
+	var v struct {
+		Data    []donation `json:"data"`
+		Ignored string     `json:"-"`
+	}
+	var fields []string
+	unknownFields(map[string]interface{}{
+		"data":   []interface{}{map[string]interface{}{"id": "1", "amount": 500, "created_time": "2020-01-01T00:00:00Z"}},
+		"paging": map[string]interface{}{},
+	}, reflect.TypeOf(&v), "", &fields)
+	sort.Strings(fields)
+	if !reflect.DeepEqual(fields, []string{"data.amount", "paging"}) {
+		t.Errorf("unexpected unknown fields %v", fields)
+	}
+}
@@ -0,0 +1,101 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// graphVersionSunsets are the dates Graph API versions stop being available, which is two years after the release
+// of the next version. See https://developers.facebook.com/docs/graph-api/changelog/versions
+var graphVersionSunsets = map[string]string{
+	"v2.8":  "2019-04-18",
+	"v2.9":  "2019-07-18",
+	"v2.10": "2019-11-07",
+	"v2.11": "2020-01-30",
+	"v2.12": "2020-05-01",
+	"v3.0":  "2020-07-26",
+	"v3.1":  "2020-10-23",
+	"v3.2":  "2021-04-30",
+	"v3.3":  "2021-07-29",
+	"v4.0":  "2021-11-12",
+	"v5.0":  "2022-02-03",
+	"v6.0":  "2022-05-05",
+	"v7.0":  "2022-08-04",
+	"v8.0":  "2022-11-10",
+	"v9.0":  "2023-02-23",
+	"v10.0": "2023-06-08",
+	"v11.0": "2023-09-14",
+	"v12.0": "2024-02-08",
+	"v13.0": "2024-05-25",
+}
+
+// GraphVersionSunset returns the date the Graph API version, such as "v2.8", stops being available, if known.
+// Versions which have not yet been superseded have no sunset date.
+func GraphVersionSunset(version string) (time.Time, bool) {
+	date, exists := graphVersionSunsets[version]
+	if !exists {
+		return time.Time{}, false
+	}
+	sunset, err := time.Parse("2006-01-02", date)
+	return sunset, err == nil
+}
+
+// A SunsetWarning warns that the Graph API version of API calls is close to, or past, its sunset date.
+type SunsetWarning struct {
+	Version string
+	Sunset  time.Time
+
+	// Remaining is the time until the sunset, which is negative once past.
+	Remaining time.Duration
+}
+
+// WithSunsetWarning warns when API calls request a Graph API version within days of its sunset date, or past it,
+// so that a version is not left to expire unnoticed. Warnings are passed to onWarning, or logged if it is nil,
+// at most once a day for each version.
+func WithSunsetWarning(days int, onWarning func(ctx context.Context, w SunsetWarning)) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.sunsetWarnings = &sunsetWarnings{within: time.Duration(days) * 24 * time.Hour, onWarning: onWarning, warned: make(map[string]time.Time)}
+		return nil
+	}
+}
+
+type sunsetWarnings struct {
+	within    time.Duration
+	onWarning func(ctx context.Context, w SunsetWarning)
+
+	mu     sync.Mutex
+	warned map[string]time.Time
+}
+
+// checkSunset warns if req requests a Graph API version close to its sunset date.
+func (c APIClient) checkSunset(ctx context.Context, req *http.Request) {
+	if c.sunsetWarnings == nil {
+		return
+	}
+	version := c.graphVersion(req)
+	sunset, known := GraphVersionSunset(version)
+	if !known {
+		return
+	}
+	now := c.now()
+	remaining := sunset.Sub(now)
+	if remaining > c.sunsetWarnings.within {
+		return
+	}
+	s := c.sunsetWarnings
+	s.mu.Lock()
+	if last, warned := s.warned[version]; warned && now.Sub(last) < 24*time.Hour {
+		s.mu.Unlock()
+		return
+	}
+	s.warned[version] = now
+	s.mu.Unlock()
+	w := SunsetWarning{Version: version, Sunset: sunset, Remaining: remaining}
+	if s.onWarning != nil {
+		s.onWarning(ctx, w)
+	} else if c.logger != nil {
+		c.logf(ctx, "facebook api version %s has a sunset date of %s\n", version, sunset.Format("2006-01-02"))
+	}
+}
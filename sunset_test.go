@@ -0,0 +1,42 @@
+package flannel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGraphVersionSunset(t *testing.T) {
+	if sunset, known := GraphVersionSunset("v2.8"); !known || !sunset.Equal(time.Date(2019, 4, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected sunset of v2.8 %v %t", sunset, known)
+	}
+	if _, known := GraphVersionSunset("v99.0"); known {
+		t.Error("expected no sunset for unreleased version")
+	}
+}
+
+func TestSunsetWarning(t *testing.T) {
+	clock := newTestClock(time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC))
+	var warnings []SunsetWarning
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"1234"}`))
+	}), WithClock(clock), WithSunsetWarning(30, func(ctx context.Context, w SunsetWarning) { warnings = append(warnings, w) }))
+	ctx := context.Background()
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warning 48 days before sunset, got %v %v", warnings, err)
+	}
+	clock.advance(30 * 24 * time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Version != "v2.8" || warnings[0].Remaining != 18*24*time.Hour {
+		t.Fatalf("expected one warning 18 days before sunset, got %v", warnings)
+	}
+	clock.advance(24 * time.Hour)
+	if _, _, err := c.GetFundraiser(ctx, "token", "1234"); err != nil || len(warnings) != 2 {
+		t.Errorf("expected warning to be repeated the next day, got %v %v", warnings, err)
+	}
+}
@@ -0,0 +1,299 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A FundraiserSpec is the desired state of a Facebook Fundraiser, identified by the ExternalID of its Params.
+type FundraiserSpec struct {
+
+	// Params are the required parameters of the fundraiser, the AccessToken is set by PlanSync.
+	// The charity and currency of a fundraiser cannot be changed, so only apply when it is created.
+	Params CreateFundraiserParams
+
+	// Fields are optional fields of the fundraiser, as set with WithFundraiserField.
+	Fields map[string]string
+
+	// CoverPhotoURL is an optional cover photo, only set when the fundraiser is created.
+	CoverPhotoURL string
+}
+
+// SyncActionType is the type of a SyncAction.
+type SyncActionType string
+
+// Sync action types.
+const (
+	SyncCreate SyncActionType = "create"
+	SyncUpdate SyncActionType = "update"
+	SyncEnd    SyncActionType = "end"
+)
+
+// A SyncChange is the current and desired value of a fundraiser field.
+type SyncChange struct {
+	From string
+	To   string
+}
+
+// A SyncAction is a change made to a single fundraiser by ApplySync.
+type SyncAction struct {
+	Type       SyncActionType
+	ExternalID string
+
+	// FundraiserID is the ID of the fundraiser, set by ApplySync once a fundraiser is created.
+	FundraiserID string
+
+	// Changes are the fields changed by the action, by field name.
+	Changes map[string]SyncChange
+
+	// Err is the error applying the action, set by ApplySync.
+	Err error
+
+	spec FundraiserSpec
+}
+
+// A SyncPlan is the set of actions converging the fundraisers of a user to their specs, as returned by PlanSync.
+// Writing the plan, without applying it, shows what a sync would change.
+type SyncPlan struct {
+	Actions []SyncAction
+
+	accessToken string
+}
+
+// syncFields are the fields compared with the specs.
+var syncFields = []string{"name", "description", "goal_amount", "end_time"}
+
+// PlanSync compares the fundraisers created by the user identified by accessToken with specs, returning the plan
+// to create fundraisers which are missing, update fields which have drifted, and end fundraisers whose external id
+// is no longer in specs. Fundraisers without an external id are never changed.
+// End times are only compared to the day, as Facebook converts them to 11:59pm in the timezone of the user.
+func (c APIClient) PlanSync(ctx context.Context, accessToken string, specs []FundraiserSpec) (*SyncPlan, error) {
+	ctx = backgroundContext(ctx)
+	desired := make(map[string]FundraiserSpec, len(specs))
+	fields := append([]string{"id", "external_id"}, syncFields...)
+	for _, spec := range specs {
+		id := spec.Params.ExternalID
+		if id == "" {
+			return nil, errors.New("fundraiser spec is missing an external id")
+		}
+		if _, exists := desired[id]; exists {
+			return nil, fmt.Errorf("duplicate fundraiser spec %s", id)
+		}
+		spec.Params.AccessToken = accessToken
+		desired[id] = spec
+		for name := range spec.Fields {
+			fields = append(fields, name)
+		}
+	}
+
+	plan := &SyncPlan{accessToken: accessToken}
+	seen := make(map[string]bool)
+	it := c.ListFundraisers(ctx, accessToken, uniqueStrings(fields)...)
+	defer it.Close()
+	for it.Next() {
+		current := it.Item()
+		externalID, _ := current["external_id"].(string)
+		if externalID == "" || seen[externalID] {
+			continue
+		}
+		seen[externalID] = true
+		id, _ := current["id"].(string)
+		spec, exists := desired[externalID]
+		if !exists {
+			if end, ok := syncEndTime(current); ok && end.After(c.now()) {
+				plan.Actions = append(plan.Actions, SyncAction{Type: SyncEnd, ExternalID: externalID, FundraiserID: id,
+					Changes: map[string]SyncChange{"end_time": {From: end.Format(time.RFC3339), To: "now"}}})
+			}
+			continue
+		}
+		if changes := syncChanges(current, spec); len(changes) > 0 {
+			plan.Actions = append(plan.Actions, SyncAction{Type: SyncUpdate, ExternalID: externalID, FundraiserID: id,
+				Changes: changes, spec: spec})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	for id, spec := range desired {
+		if !seen[id] {
+			plan.Actions = append(plan.Actions, SyncAction{Type: SyncCreate, ExternalID: id,
+				Changes: syncChanges(nil, spec), spec: spec})
+		}
+	}
+	sort.Slice(plan.Actions, func(i, j int) bool {
+		return plan.Actions[i].ExternalID < plan.Actions[j].ExternalID
+	})
+	return plan, nil
+}
+
+// ApplySync applies each action of plan in turn, setting the FundraiserID of created fundraisers and the Err of
+// actions which failed. An error is returned if any action failed. Fundraisers changed are saved to the
+// MirrorStore, if configured with WithMirrorStore.
+func (c APIClient) ApplySync(ctx context.Context, plan *SyncPlan) error {
+	ctx = backgroundContext(ctx)
+	failed := 0
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		action.Err = c.applySyncAction(ctx, plan.accessToken, action)
+		if action.Err != nil {
+			failed++
+			continue
+		}
+		if c.mirror != nil {
+			if err := c.mirrorFundraiser(ctx, plan.accessToken, action.FundraiserID); err != nil && c.logger != nil {
+				c.logf(ctx, "error mirroring fundraiser %s %v\n", action.FundraiserID, err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sync actions failed", failed, len(plan.Actions))
+	}
+	return nil
+}
+
+func (c APIClient) applySyncAction(ctx context.Context, accessToken string, action *SyncAction) error {
+	switch action.Type {
+	case SyncCreate:
+		options, err := FundraiserJob{Params: action.spec.Params, Fields: action.spec.Fields, CoverPhotoURL: action.spec.CoverPhotoURL}.options()
+		if err != nil {
+			return err
+		}
+		_, result, err := c.CreateFundraiserWithContext(ctx, action.spec.Params, options...)
+		if err != nil {
+			return err
+		}
+		action.FundraiserID, _ = result["id"].(string)
+		return nil
+	case SyncUpdate:
+		values := syncValues(action.spec)
+		fields := make(map[string]string, len(action.Changes))
+		for name := range action.Changes {
+			fields[name] = values[name]
+		}
+		_, _, err := c.UpdateFundraiser(ctx, accessToken, action.FundraiserID, fields)
+		return err
+	case SyncEnd:
+		_, _, err := c.UpdateFundraiser(ctx, accessToken, action.FundraiserID, map[string]string{
+			"end_time": strconv.FormatInt(c.now().Unix(), 10),
+		})
+		return err
+	default:
+		return fmt.Errorf("unsupported sync action %s", action.Type)
+	}
+}
+
+// WriteTo writes the actions of the plan in a readable form to w, with the outcome of actions once applied.
+func (p *SyncPlan) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	symbols := map[SyncActionType]string{SyncCreate: "+", SyncUpdate: "~", SyncEnd: "-"}
+	for _, action := range p.Actions {
+		fmt.Fprintf(&b, "%s %s %s", symbols[action.Type], action.Type, action.ExternalID)
+		if action.FundraiserID != "" {
+			fmt.Fprintf(&b, " (%s)", action.FundraiserID)
+		}
+		if action.Err != nil {
+			fmt.Fprintf(&b, " failed: %v", action.Err)
+		}
+		b.WriteString("\n")
+		names := make([]string, 0, len(action.Changes))
+		for name := range action.Changes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			change := action.Changes[name]
+			if action.Type == SyncCreate {
+				fmt.Fprintf(&b, "    %s: %q\n", name, change.To)
+			} else {
+				fmt.Fprintf(&b, "    %s: %q -> %q\n", name, change.From, change.To)
+			}
+		}
+	}
+	if len(p.Actions) == 0 {
+		b.WriteString("no changes\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// syncValues returns the desired values of the fields compared by a sync.
+func syncValues(spec FundraiserSpec) map[string]string {
+	values := map[string]string{
+		"name":        spec.Params.Title,
+		"description": spec.Params.Description,
+		"goal_amount": strconv.Itoa(spec.Params.Goal),
+		"end_time":    strconv.FormatInt(spec.Params.EndTime.Unix(), 10),
+	}
+	for name, v := range spec.Fields {
+		values[name] = v
+	}
+	return values
+}
+
+// syncChanges returns the fields of current which differ from spec, or all fields if current is nil.
+func syncChanges(current map[string]interface{}, spec FundraiserSpec) map[string]SyncChange {
+	changes := make(map[string]SyncChange)
+	for name, to := range syncValues(spec) {
+		if current == nil {
+			if name == "end_time" {
+				to = spec.Params.EndTime.Format(time.RFC3339)
+			}
+			changes[name] = SyncChange{To: to}
+			continue
+		}
+		if name == "end_time" {
+			end, ok := syncEndTime(current)
+			if diff := end.Sub(spec.Params.EndTime); !ok || diff > 24*time.Hour || diff < -24*time.Hour {
+				changes[name] = SyncChange{From: end.Format(time.RFC3339), To: spec.Params.EndTime.Format(time.RFC3339)}
+			}
+			continue
+		}
+		from, err := exportValue(current[name])
+		if err != nil || from != to {
+			changes[name] = SyncChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// syncEndTime returns the end time of a fundraiser, as returned by the Graph API.
+func syncEndTime(current map[string]interface{}) (time.Time, bool) {
+	switch v := current["end_time"].(type) {
+	case string:
+		return parseGraphTime(v)
+	case float64:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+// parseGraphTime parses a time as formatted by the Graph API.
+func parseGraphTime(s string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02T15:04:05-0700", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,80 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	end := time.Now().AddDate(0, 1, 0).UTC().Truncate(24 * time.Hour)
+	// Facebook returns end times at 11:59pm in the timezone of the user
+	returned := end.Add(23*time.Hour + 59*time.Minute).Format("2006-01-02T15:04:05-0700")
+
+	var mu sync.Mutex
+	var posts []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2.8/me/fundraisers":
+			fmt.Fprintf(w, `{"data":[
+				{"id":"1","external_id":"ext-1","name":"Same","description":"d","goal_amount":10000,"end_time":%[1]q},
+				{"id":"2","external_id":"ext-2","name":"Old","description":"d","goal_amount":10000,"end_time":%[1]q},
+				{"id":"3","external_id":"ext-3","name":"Removed","description":"d","goal_amount":10000,"end_time":%[1]q},
+				{"id":"4","name":"Unmanaged","end_time":%[1]q}]}`, returned)
+		case r.Method == "POST":
+			r.ParseMultipartForm(1024)
+			mu.Lock()
+			posts = append(posts, r.URL.Path+" "+r.FormValue("name")+r.FormValue("external_id")+r.FormValue("description"))
+			mu.Unlock()
+			fmt.Fprint(w, `{"id":"5","success":true}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	spec := func(id string, title string) FundraiserSpec {
+		return FundraiserSpec{Params: CreateFundraiserParams{CharityID: "123", Title: title, Description: "d",
+			Goal: 10000, Currency: "USD", EndTime: end, ExternalID: id}}
+	}
+	plan, err := c.PlanSync(context.Background(), "token", []FundraiserSpec{
+		spec("ext-1", "Same"), spec("ext-2", "New"), spec("ext-4", "Created"),
+	})
+	if err != nil {
+		t.Fatalf("failed to plan sync %v", err)
+	}
+	var b bytes.Buffer
+	plan.WriteTo(&b)
+	for _, expected := range []string{"~ update ext-2 (2)\n    name: \"Old\" -> \"New\"\n", "- end ext-3 (3)\n", "+ create ext-4\n"} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected plan to contain %q\n%s", expected, b.String())
+		}
+	}
+	if len(plan.Actions) != 3 {
+		t.Fatalf("expected 3 actions, got\n%s", b.String())
+	}
+
+	if err := c.ApplySync(context.Background(), plan); err != nil {
+		t.Fatalf("failed to apply sync %v", err)
+	}
+	sort.Strings(posts)
+	if fmt.Sprintf("%q", posts) != `["/v2.8/2 New" "/v2.8/3 " "/v2.8/me/fundraisers Createdext-4d"]` {
+		t.Errorf("unexpected updates %q", posts)
+	}
+	if plan.Actions[2].FundraiserID != "5" {
+		t.Errorf("expected created fundraiser id to be set, got %+v", plan.Actions[2])
+	}
+}
+
+func TestPlanSyncDuplicateSpec(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	spec := FundraiserSpec{Params: CreateFundraiserParams{ExternalID: "ext-1"}}
+	if _, err := c.PlanSync(context.Background(), "token", []FundraiserSpec{spec, spec}); err == nil {
+		t.Errorf("expected duplicate specs to fail")
+	}
+}
@@ -0,0 +1,113 @@
+package flannel
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Length limits of fundraiser titles and descriptions, in characters.
+const (
+	FundraiserTitleMaxLength       = 70
+	FundraiserDescriptionMaxLength = 50000
+)
+
+// A FundraiserTemplate renders fundraiser titles and descriptions from campaign data, using text/template.
+// HTML tags are stripped from the output, and rendering fails if the title or description is too long, so templates
+// should use the truncate function for data of unknown length.
+//
+//	t, err := flannel.NewFundraiserTemplate(`{{.Name}} runs for {{.Charity}}`, `{{truncate 500 .Story}}`)
+//	err = t.Apply(&params, campaign)
+type FundraiserTemplate struct {
+	title       *template.Template
+	description *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"truncate": truncate,
+	"default": func(fallback string, s string) string {
+		if strings.TrimSpace(s) == "" {
+			return fallback
+		}
+		return s
+	},
+}
+
+// NewFundraiserTemplate parses the title and description templates.
+func NewFundraiserTemplate(title string, description string) (*FundraiserTemplate, error) {
+	t := &FundraiserTemplate{}
+	var err error
+	if t.title, err = template.New("title").Funcs(templateFuncs).Option("missingkey=error").Parse(title); err != nil {
+		return nil, err
+	}
+	if t.description, err = template.New("description").Funcs(templateFuncs).Option("missingkey=error").Parse(description); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render renders the title and description from data.
+func (t *FundraiserTemplate) Render(data interface{}) (title string, description string, err error) {
+	if title, err = render(t.title, data, FundraiserTitleMaxLength); err != nil {
+		return "", "", err
+	}
+	if description, err = render(t.description, data, FundraiserDescriptionMaxLength); err != nil {
+		return "", "", err
+	}
+	return title, description, nil
+}
+
+// Apply renders the title and description from data into params.
+func (t *FundraiserTemplate) Apply(params *CreateFundraiserParams, data interface{}) error {
+	title, description, err := t.Render(data)
+	if err != nil {
+		return err
+	}
+	params.Title, params.Description = title, description
+	return nil
+}
+
+// LocalizedTemplates are fundraiser templates by locale, such as "en_GB" or "fr".
+type LocalizedTemplates map[string]*FundraiserTemplate
+
+// Render renders the template of locale, falling back to the template of its language and then the template
+// of the empty locale.
+func (l LocalizedTemplates) Render(locale string, data interface{}) (title string, description string, err error) {
+	language := strings.SplitN(strings.Replace(locale, "-", "_", 1), "_", 2)[0]
+	for _, candidate := range []string{locale, language, ""} {
+		if t, exists := l[candidate]; exists {
+			return t.Render(data)
+		}
+	}
+	return "", "", fmt.Errorf("no template for locale %s", locale)
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func render(t *template.Template, data interface{}, maxLength int) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(b.String(), "")))
+	if n := utf8.RuneCountInString(s); n > maxLength {
+		return "", fmt.Errorf("%s is %d characters long, the maximum is %d", t.Name(), n, maxLength)
+	}
+	return s, nil
+}
+
+// truncate shortens s to at most n characters, ending with an ellipsis if shortened.
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,41 @@
+package flannel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFundraiserTemplate(t *testing.T) {
+	en, err := NewFundraiserTemplate(`{{.Name}} runs the {{.Event}}`, `<p>{{truncate 12 .Story}}</p> &amp; {{default "thanks" .Thanks}}`)
+	if err != nil {
+		t.Fatalf("failed to parse template %v", err)
+	}
+	fr, err := NewFundraiserTemplate(`{{.Name}} court le {{.Event}}`, `{{.Story}}`)
+	if err != nil {
+		t.Fatalf("failed to parse template %v", err)
+	}
+	templates := LocalizedTemplates{"": en, "fr": fr}
+	data := map[string]string{"Name": "Sam", "Event": "Marathon", "Story": "A very long story", "Thanks": ""}
+
+	var params CreateFundraiserParams
+	if err := en.Apply(&params, data); err != nil {
+		t.Fatalf("failed to render template %v", err)
+	}
+	if params.Title != "Sam runs the Marathon" || params.Description != "A very long… & thanks" {
+		t.Errorf("unexpected title %q and description %q", params.Title, params.Description)
+	}
+	if title, _, err := templates.Render("fr_CA", data); err != nil || title != "Sam court le Marathon" {
+		t.Errorf("expected french title, got %q %v", title, err)
+	}
+	if title, _, err := templates.Render("de-DE", data); err != nil || title != "Sam runs the Marathon" {
+		t.Errorf("expected default title, got %q %v", title, err)
+	}
+
+	data["Name"] = strings.Repeat("x", 60)
+	if _, _, err := en.Render(data); err == nil {
+		t.Errorf("expected long title to fail")
+	}
+	if _, _, err := en.Render(map[string]string{}); err == nil {
+		t.Errorf("expected missing data to fail")
+	}
+}
@@ -0,0 +1,176 @@
+package flannel
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TokenStore is the interface implemented by stores of user access tokens, keyed by the user they belong to.
+// Implementations must be safe for concurrent use, see MemoryTokenStore for an in-memory implementation and
+// EncryptedTokenStore to encrypt tokens held by another TokenStore.
+type TokenStore interface {
+
+	// Get returns the token stored for key, if it exists.
+	Get(ctx context.Context, key string) (LoginToken, bool, error)
+
+	// Put inserts or updates the token stored for key.
+	Put(ctx context.Context, key string, token LoginToken) error
+
+	// Delete removes the token stored for key.
+	Delete(ctx context.Context, key string) error
+}
+
+// A MemoryTokenStore is an in-memory TokenStore, tokens are lost when the process exits.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]LoginToken
+}
+
+// NewMemoryTokenStore creates a new MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]LoginToken)}
+}
+
+// Get returns the token stored for key, if it exists.
+func (s *MemoryTokenStore) Get(ctx context.Context, key string) (LoginToken, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, exists := s.tokens[key]
+	return token, exists, nil
+}
+
+// Put inserts or updates the token stored for key.
+func (s *MemoryTokenStore) Put(ctx context.Context, key string, token LoginToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[key] = token
+	return nil
+}
+
+// Delete removes the token stored for key.
+func (s *MemoryTokenStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, key)
+	return nil
+}
+
+// TokenKey is an AES key used by an EncryptedTokenStore, identified by ID.
+type TokenKey struct {
+	ID string
+
+	// Key is 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+	Key []byte
+}
+
+// encryptedTokenPrefix starts the access tokens encrypted by an EncryptedTokenStore.
+const encryptedTokenPrefix = "enc:"
+
+// An EncryptedTokenStore encrypts access tokens with AES-GCM before they are stored by another TokenStore, so that
+// tokens are encrypted at rest. Tokens are encrypted with the first key, and decrypted with the key they were
+// encrypted with, so keys are rotated by adding a new first key and removing old keys once no tokens use them.
+// Tokens read which were encrypted with an old key, or stored before encryption was enabled, are re-encrypted
+// with the first key.
+type EncryptedTokenStore struct {
+	store TokenStore
+	keyID string
+	keys  map[string]cipher.AEAD
+}
+
+// NewEncryptedTokenStore creates a new EncryptedTokenStore which stores tokens in store, encrypted with keys.
+func NewEncryptedTokenStore(store TokenStore, keys ...TokenKey) (*EncryptedTokenStore, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no token encryption keys")
+	}
+	s := &EncryptedTokenStore{store: store, keyID: keys[0].ID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid token encryption key id %q", key.ID)
+		}
+		if _, exists := s.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate token encryption key id %q", key.ID)
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token encryption key %q %w", key.ID, err)
+		}
+		s.keys[key.ID], _ = cipher.NewGCM(block)
+	}
+	return s, nil
+}
+
+// Get returns the token stored for key, decrypted.
+func (s *EncryptedTokenStore) Get(ctx context.Context, key string) (LoginToken, bool, error) {
+	token, exists, err := s.store.Get(ctx, key)
+	if err != nil || !exists {
+		return LoginToken{}, false, err
+	}
+	accessToken, keyID, err := s.decrypt(key, token.AccessToken)
+	if err != nil {
+		return LoginToken{}, false, err
+	}
+	token.AccessToken = accessToken
+	if keyID != s.keyID {
+		if err := s.Put(ctx, key, token); err != nil {
+			return LoginToken{}, false, err
+		}
+	}
+	return token, true, nil
+}
+
+// Put encrypts and stores the token for key.
+func (s *EncryptedTokenStore) Put(ctx context.Context, key string, token LoginToken) error {
+	accessToken, err := s.encrypt(key, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	token.AccessToken = accessToken
+	return s.store.Put(ctx, key, token)
+}
+
+// Delete removes the token stored for key.
+func (s *EncryptedTokenStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
+
+// encrypt encrypts accessToken, authenticating the key it is stored for so that tokens cannot be swapped between users.
+func (s *EncryptedTokenStore) encrypt(key string, accessToken string) (string, error) {
+	aead := s.keys[s.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(accessToken), []byte(key))
+	return encryptedTokenPrefix + s.keyID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt decrypts accessToken, returning the id of the key used, which is empty if it was not encrypted.
+func (s *EncryptedTokenStore) decrypt(key string, accessToken string) (string, string, error) {
+	if !strings.HasPrefix(accessToken, encryptedTokenPrefix) {
+		return accessToken, "", nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(accessToken, encryptedTokenPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", "", errors.New("invalid encrypted access token")
+	}
+	aead, exists := s.keys[parts[0]]
+	if !exists {
+		return "", "", fmt.Errorf("access token encrypted with unknown key %q", parts[0])
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", "", errors.New("invalid encrypted access token")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", "", fmt.Errorf("error decrypting access token %w", err)
+	}
+	return string(plain), parts[0], nil
+}
@@ -0,0 +1,58 @@
+package flannel
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptedTokenStore(t *testing.T) {
+	ctx := context.Background()
+	backing := NewMemoryTokenStore()
+	old := TokenKey{ID: "old", Key: bytes.Repeat([]byte{1}, 32)}
+	current := TokenKey{ID: "new", Key: bytes.Repeat([]byte{2}, 32)}
+
+	s, err := NewEncryptedTokenStore(backing, old)
+	if err != nil {
+		t.Fatalf("failed to create store %v", err)
+	}
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := s.Put(ctx, "user", LoginToken{AccessToken: "secret", Expiry: expiry}); err != nil {
+		t.Fatalf("failed to put token %v", err)
+	}
+	stored, _, _ := backing.Get(ctx, "user")
+	if !strings.HasPrefix(stored.AccessToken, "enc:old:") || strings.Contains(stored.AccessToken, "secret") {
+		t.Errorf("expected token to be encrypted, got %q", stored.AccessToken)
+	}
+
+	// swapping encrypted tokens between users fails
+	backing.Put(ctx, "other", stored)
+	if _, _, err := s.Get(ctx, "other"); err == nil {
+		t.Error("expected token stored for another user to fail")
+	}
+
+	// rotating keys re-encrypts tokens as they are read
+	s, _ = NewEncryptedTokenStore(backing, current, old)
+	token, exists, err := s.Get(ctx, "user")
+	if err != nil || !exists || token.AccessToken != "secret" || !token.Expiry.Equal(expiry) {
+		t.Fatalf("unexpected token %+v %t %v", token, exists, err)
+	}
+	if stored, _, _ := backing.Get(ctx, "user"); !strings.HasPrefix(stored.AccessToken, "enc:new:") {
+		t.Errorf("expected token to be re-encrypted, got %q", stored.AccessToken)
+	}
+
+	// plain tokens are encrypted once read
+	backing.Put(ctx, "plain", LoginToken{AccessToken: "plain"})
+	if token, _, _ := s.Get(ctx, "plain"); token.AccessToken != "plain" {
+		t.Errorf("unexpected plain token %q", token.AccessToken)
+	}
+	if stored, _, _ := backing.Get(ctx, "plain"); !strings.HasPrefix(stored.AccessToken, "enc:new:") {
+		t.Errorf("expected plain token to be encrypted, got %q", stored.AccessToken)
+	}
+
+	if _, err := NewEncryptedTokenStore(backing, TokenKey{ID: "short", Key: []byte("short")}); err == nil {
+		t.Error("expected invalid key to fail")
+	}
+}
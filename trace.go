@@ -0,0 +1,145 @@
+package flannel
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ConnectionTrace reports the connection level timings of a HTTP request, zero durations were not observed,
+// for example because an idle connection was re-used.
+type ConnectionTrace struct {
+	Method   string
+	Endpoint string
+
+	// Reused is set if an idle connection was re-used.
+	Reused bool
+
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+
+	// TimeToFirstByte is the time from requesting a connection until the first byte of the response was received.
+	TimeToFirstByte time.Duration
+
+	// Total is the time taken to receive the response headers, or fail.
+	Total time.Duration
+}
+
+// ConnectionMetrics is implemented by Metrics which also export connection level timings.
+type ConnectionMetrics interface {
+	Metrics
+	ObserveConnection(trace ConnectionTrace)
+}
+
+// WithConnectionTracing traces the DNS, connect, TLS handshake and time to first byte durations of each HTTP request,
+// which are logged in debug mode or when the request takes longer than slowThreshold (zero disables), and reported to
+// any Metrics implementing ConnectionMetrics.
+func WithConnectionTracing(slowThreshold time.Duration) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.connectionTracing = &connectionTracing{slowThreshold: slowThreshold}
+		return nil
+	}
+}
+
+type connectionTracing struct {
+	slowThreshold time.Duration
+}
+
+// connectionTracer collects the timings of a single request.
+type connectionTracer struct {
+	mu           sync.Mutex
+	trace        ConnectionTrace
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+// traceConnection returns req with a httptrace.ClientTrace and a func to report the trace once a response is received.
+func (c APIClient) traceConnection(req *http.Request) (*http.Request, func(err error)) {
+	if c.connectionTracing == nil {
+		return req, func(error) {}
+	}
+	t := &connectionTracer{start: time.Now()}
+	t.trace.Method = req.Method
+	t.trace.Endpoint = statsEndpoint(req)
+	since := func(start time.Time) time.Duration {
+		if start.IsZero() {
+			return 0
+		}
+		return time.Since(start)
+	}
+	ct := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.trace.DNS = since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			if err == nil && t.trace.Connect == 0 {
+				t.trace.Connect = since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.trace.TLSHandshake = since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.trace.Reused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.trace.TimeToFirstByte = since(t.start)
+			t.mu.Unlock()
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), ct))
+	return req, func(err error) {
+		t.mu.Lock()
+		trace := t.trace
+		t.mu.Unlock()
+		trace.Total = time.Since(t.start)
+		c.reportConnection(req, trace, err)
+	}
+}
+
+func (c APIClient) reportConnection(req *http.Request, trace ConnectionTrace, err error) {
+	if m, ok := c.metrics.(ConnectionMetrics); ok {
+		m.ObserveConnection(trace)
+	}
+	slow := c.connectionTracing.slowThreshold > 0 && trace.Total > c.connectionTracing.slowThreshold
+	if c.logger == nil || !(c.debug() || slow || err != nil) {
+		return
+	}
+	msg := "facebook api"
+	if requestID, ok := RequestIDFromContext(req.Context()); ok {
+		msg = msg + " " + requestID
+	}
+	c.logf(req.Context(), "%s %s request to %s connection reused %t dns %v connect %v tls %v first byte %v total %v\n",
+		msg, req.Method, redactString(req.URL.String()), trace.Reused, trace.DNS, trace.Connect, trace.TLSHandshake, trace.TimeToFirstByte, trace.Total)
+}
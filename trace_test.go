@@ -0,0 +1,55 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testConnectionMetrics struct {
+	testMetrics
+	traces []ConnectionTrace
+}
+
+func (m *testConnectionMetrics) ObserveConnection(trace ConnectionTrace) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.traces = append(m.traces, trace)
+}
+
+func TestConnectionTracing(t *testing.T) {
+	var logged []string
+	logger := LoggerFunc(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	m := &testConnectionMetrics{}
+	c := newTestClient(t, http.HandlerFunc(fundraiserCreated), WithConnectionTracing(0), WithMetrics(m), WithLogger(logger, true))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.GetFundraiser(context.Background(), "token", "1234"); err != nil {
+			t.Fatalf("failed to get fundraiser %v", err)
+		}
+	}
+
+	if len(m.traces) != 2 {
+		t.Fatalf("expected 2 traces, got %d", len(m.traces))
+	}
+	first, second := m.traces[0], m.traces[1]
+	if first.Reused || first.Connect <= 0 || first.TLSHandshake <= 0 || first.TimeToFirstByte <= 0 || first.Endpoint != "/{id}" {
+		t.Errorf("unexpected trace of new connection %+v", first)
+	}
+	if !second.Reused || second.TLSHandshake != 0 {
+		t.Errorf("unexpected trace of re-used connection %+v", second)
+	}
+	var traced int
+	for _, l := range logged {
+		if strings.Contains(l, "connection reused") {
+			traced++
+		}
+	}
+	if traced != 2 {
+		t.Errorf("expected traces to be logged in debug mode, got %v", logged)
+	}
+}
@@ -0,0 +1,255 @@
+package flannel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime/multipart"
+
+	// register decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// A CoverPhotoTransform transforms a cover photo before it is validated and uploaded, returning the transformed photo.
+type CoverPhotoTransform func(photo io.Reader) (io.Reader, error)
+
+// WithFundraiserCoverPhotoTransform transforms the cover photo before it is uploaded when creating a new Facebook Fundraiser.
+// Transforms are applied in order each time the cover photo is read, and the MIME type of the transformed cover photo
+// is detected from its content.
+func WithFundraiserCoverPhotoTransform(transforms ...CoverPhotoTransform) func(*multipart.Writer) error {
+	return formOption(func(f *fundraiserForm) error {
+		f.coverPhotoTransforms = append(f.coverPhotoTransforms, transforms...)
+		return nil
+	})
+}
+
+// maxTransformSize is the largest photo read by transforms which need to decode the photo.
+const maxTransformSize = 64 * 1024 * 1024
+
+// maxTransformPixels is the largest photo, in pixels, decoded by transforms, so that photos declaring huge
+// dimensions cannot exhaust memory.
+const maxTransformPixels = 100000000
+
+// readTransformInput reads a photo to be decoded by a transform, failing if it exceeds maxTransformSize
+// rather than transforming a truncated photo.
+func readTransformInput(photo io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(photo, maxTransformSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxTransformSize {
+		return nil, fmt.Errorf("cover photo exceeds the maximum size of %d bytes which can be transformed", maxTransformSize)
+	}
+	return b, nil
+}
+
+// checkTransformPixels fails if a photo of width and height exceeds maxTransformPixels.
+func checkTransformPixels(width int, height int) error {
+	if int64(width)*int64(height) > maxTransformPixels {
+		return fmt.Errorf("cover photo of %dx%d exceeds the maximum of %d pixels which can be transformed", width, height, maxTransformPixels)
+	}
+	return nil
+}
+
+// DownscaleCoverPhoto returns a CoverPhotoTransform which re-encodes JPEG, PNG and GIF cover photos exceeding
+// the dimension limits of Facebook, or maxSize bytes, as JPEG. Photos are scaled down to the dimension limits
+// and then JPEG quality is stepped down, and the dimensions reduced further, until the photo is smaller than maxSize.
+// Photos within the limits, or in other formats, are uploaded unchanged.
+func DownscaleCoverPhoto(maxSize int64) CoverPhotoTransform {
+	return func(photo io.Reader) (io.Reader, error) {
+		b, err := readTransformInput(photo)
+		if err != nil {
+			return nil, err
+		}
+		info, ok := decodeImageHeader(b)
+		withinLimits := info.width <= FundraiserCoverPhotoMaxDimension && info.height <= FundraiserCoverPhotoMaxDimension &&
+			info.width*info.height <= FundraiserCoverPhotoMaxPixels
+		if !ok || (withinLimits && int64(len(b)) <= maxSize) {
+			return bytes.NewReader(b), nil
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(b))
+		if err != nil {
+			// not a format which can be re-encoded
+			return bytes.NewReader(b), nil
+		}
+		if err := checkTransformPixels(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+		src, _, err := image.Decode(bytes.NewReader(b))
+		if err != nil {
+			// not a format which can be re-encoded
+			return bytes.NewReader(b), nil
+		}
+		return downscale(src, maxSize)
+	}
+}
+
+// downscale encodes src as a JPEG within the dimension limits and smaller than maxSize, if possible.
+func downscale(src image.Image, maxSize int64) (io.Reader, error) {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	scale := math.Min(1, math.Min(
+		float64(FundraiserCoverPhotoMaxDimension)/float64(width),
+		float64(FundraiserCoverPhotoMaxDimension)/float64(height)))
+	scale = math.Min(scale, math.Sqrt(float64(FundraiserCoverPhotoMaxPixels)/float64(width*height)))
+
+	flat := flatten(src)
+
+	var b bytes.Buffer
+	for {
+		w, h := int(float64(width)*scale), int(float64(height)*scale)
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		img := image.Image(flat)
+		if w != width || h != height {
+			img = resize(flat, w, h)
+		}
+		for _, quality := range []int{90, 80, 70, 60, 50} {
+			b.Reset()
+			if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, err
+			}
+			if int64(b.Len()) <= maxSize {
+				return bytes.NewReader(b.Bytes()), nil
+			}
+		}
+		if w <= 64 || h <= 64 {
+			// give up, Facebook will reject the photo
+			return bytes.NewReader(b.Bytes()), nil
+		}
+		scale *= 0.75
+	}
+}
+
+// flatten draws src onto a white background, as JPEG does not support transparency.
+func flatten(src image.Image) *image.RGBA {
+	flat := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, src.Bounds().Min, draw.Over)
+	return flat
+}
+
+// resize scales src to w x h by averaging the source pixels covered by each destination pixel.
+func resize(src *image.RGBA, w int, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// StripMetadata returns a CoverPhotoTransform which removes metadata from JPEG cover photos, including EXIF
+// (such as GPS location and device details), XMP, IPTC and comments. Other formats are uploaded unchanged.
+// As EXIF orientation is also removed, photos relying on it may be displayed rotated.
+func StripMetadata() CoverPhotoTransform {
+	return func(photo io.Reader) (io.Reader, error) {
+		r := bufio.NewReader(photo)
+		soi, err := r.Peek(2)
+		if err != nil || soi[0] != 0xff || soi[1] != 0xd8 {
+			// not a jpeg
+			return r, nil
+		}
+		var header bytes.Buffer
+		if err := stripJPEGMetadata(&header, r); err != nil {
+			return nil, err
+		}
+		return io.MultiReader(&header, r), nil
+	}
+}
+
+// stripJPEGMetadata copies the segments of a JPEG from r to w up to the start of scan, skipping metadata segments.
+// The remainder of the image is left unread in r.
+func stripJPEGMetadata(w io.Writer, r *bufio.Reader) error {
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil {
+		return err
+	}
+	w.Write(soi)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != 0xff {
+			return errors.New("invalid jpeg marker")
+		}
+		marker, err := r.ReadByte()
+		for err == nil && marker == 0xff {
+			// fill bytes
+			marker, err = r.ReadByte()
+		}
+		if err != nil {
+			return err
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd8) {
+			// standalone markers
+			w.Write([]byte{0xff, marker})
+			continue
+		}
+		length := make([]byte, 2)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return err
+		}
+		n := int64(binary.BigEndian.Uint16(length)) - 2
+		if n < 0 {
+			return errors.New("invalid jpeg segment length")
+		}
+		switch marker {
+		case 0xe1, 0xed, 0xfe:
+			// APP1 (EXIF, XMP), APP13 (IPTC) and comments
+			if _, err := io.CopyN(ioutil.Discard, r, n); err != nil {
+				return err
+			}
+			continue
+		}
+		w.Write([]byte{0xff, marker})
+		w.Write(length)
+		if _, err := io.CopyN(w, r, n); err != nil {
+			return err
+		}
+		if marker == 0xda || marker == 0xd9 {
+			// start of scan, the entropy coded image data follows
+			return nil
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package flannel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownscaleCoverPhoto(t *testing.T) {
+	// random noise compresses poorly, so the photo exceeds the max size
+	src := image.NewRGBA(image.Rect(0, 0, 1000, 800))
+	rnd := rand.New(rand.NewSource(1))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(rnd.Intn(256))
+	}
+	var photo bytes.Buffer
+	png.Encode(&photo, src)
+	maxSize := int64(100 * 1024)
+	if int64(photo.Len()) <= maxSize {
+		t.Fatalf("expected test photo to exceed %d bytes, got %d", maxSize, photo.Len())
+	}
+
+	var uploaded []byte
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Errorf("missing cover photo %v", err)
+			return
+		}
+		uploaded, _ = ioutil.ReadAll(file)
+		fundraiserCreated(w, r)
+	}))
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{},
+		WithFundraiserCoverPhotoImage("photo.png", bytes.NewReader(photo.Bytes())),
+		WithFundraiserCoverPhotoTransform(DownscaleCoverPhoto(maxSize)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if int64(len(uploaded)) > maxSize {
+		t.Errorf("expected uploaded photo to be at most %d bytes, got %d", maxSize, len(uploaded))
+	}
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(uploaded)); err != nil {
+		t.Errorf("expected uploaded photo to be a jpeg %v", err)
+	}
+}
+
+func TestDownscaleCoverPhotoWithinLimits(t *testing.T) {
+	photo := testPhoto(0)
+	transformed, err := DownscaleCoverPhoto(FundraiserCoverPhotoImageMaxSize)(bytes.NewReader(photo))
+	if err != nil {
+		t.Fatalf("failed to transform photo %v", err)
+	}
+	if b, _ := ioutil.ReadAll(transformed); !bytes.Equal(b, photo) {
+		t.Error("expected photo within limits to be unchanged")
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.Set(x, 0, color.RGBA{200, 0, 0, 255})
+		src.Set(x, 1, color.RGBA{0, 0, 100, 255})
+	}
+	dst := resize(src, 2, 1)
+	if c := dst.RGBAAt(1, 0); c != (color.RGBA{100, 0, 50, 255}) {
+		t.Errorf("expected averaged pixel, got %v", c)
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	var photo bytes.Buffer
+	jpeg.Encode(&photo, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	exif := append([]byte("\xff\xe1\x00\x16Exif\x00\x00"), []byte("GPS 51.5N 0.1W")...)
+	exif[3] = byte(len(exif) - 2)
+	// insert the exif segment after the start of image marker
+	withEXIF := append(append([]byte{0xff, 0xd8}, exif...), photo.Bytes()[2:]...)
+
+	stripped, err := StripMetadata()(bytes.NewReader(withEXIF))
+	if err != nil {
+		t.Fatalf("failed to strip metadata %v", err)
+	}
+	b, _ := ioutil.ReadAll(stripped)
+	if bytes.Contains(b, []byte("GPS")) {
+		t.Error("expected exif to be stripped")
+	}
+	if !bytes.Equal(b, photo.Bytes()) {
+		t.Errorf("expected photo without exif to be unchanged, got %d bytes from %d", len(b), photo.Len())
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(b)); err != nil {
+		t.Errorf("expected stripped photo to decode %v", err)
+	}
+
+	other := testPhoto(0)
+	stripped, _ = StripMetadata()(bytes.NewReader(other))
+	if b, _ := ioutil.ReadAll(stripped); !bytes.Equal(b, other) {
+		t.Error("expected png to be unchanged")
+	}
+}
+
+func TestConvertCoverPhotoToJPEG(t *testing.T) {
+	heif := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic")
+	decoders := map[string]ImageDecoder{
+		"heif": ImageDecoderFunc(func(r io.Reader) (image.Image, error) {
+			return image.NewGray(image.Rect(0, 0, 8, 8)), nil
+		}),
+	}
+	converted, err := ConvertCoverPhotoToJPEG(decoders, 90)(bytes.NewReader(heif))
+	if err != nil {
+		t.Fatalf("failed to convert photo %v", err)
+	}
+	b, _ := ioutil.ReadAll(converted)
+	if config, err := jpeg.DecodeConfig(bytes.NewReader(b)); err != nil || config.Width != 8 {
+		t.Errorf("expected photo to be converted to jpeg %v", err)
+	}
+
+	photo := testPhoto(0)
+	converted, _ = ConvertCoverPhotoToJPEG(decoders, 90)(bytes.NewReader(photo))
+	if b, _ := ioutil.ReadAll(converted); !bytes.Equal(b, photo) {
+		t.Error("expected photo without a decoder to be unchanged")
+	}
+}
+
+func TestDownscaleCoverPhotoLimits(t *testing.T) {
+	if _, err := DownscaleCoverPhoto(1024)(io.MultiReader(bytes.NewReader(testPhoto(0)), bytes.NewReader(make([]byte, maxTransformSize)))); err == nil {
+		t.Error("expected photo exceeding the maximum size to fail rather than be truncated")
+	}
+
+	// a PNG header declaring 20000x20000 pixels
+	var header bytes.Buffer
+	header.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := []byte("IHDR\x00\x00\x4e\x20\x00\x00\x4e\x20\x08\x00\x00\x00\x00")
+	binary.Write(&header, binary.BigEndian, uint32(len(ihdr)-4))
+	header.Write(ihdr)
+	binary.Write(&header, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	if _, err := DownscaleCoverPhoto(1024)(&header); err == nil || !strings.Contains(err.Error(), "pixels") {
+		t.Errorf("expected photo exceeding the maximum pixels to fail before decoding, got %v", err)
+	}
+}
+
+func TestCoverPhotoTransformContentType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flannel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "photo.png")
+	if err := ioutil.WriteFile(path, testPhoto(0), 0600); err != nil {
+		t.Fatal(err)
+	}
+	toJPEG := func(photo io.Reader) (io.Reader, error) {
+		var b bytes.Buffer
+		err := jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+		return &b, err
+	}
+
+	var contentType string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("cover_photo")
+		if err != nil {
+			t.Errorf("missing cover photo %v", err)
+			return
+		}
+		contentType = header.Header.Get("Content-Type")
+		fundraiserCreated(w, r)
+	}))
+	for _, options := range [][]func(*multipart.Writer) error{
+		{WithFundraiserCoverPhotoFile(path), WithFundraiserCoverPhotoTransform(toJPEG)},
+		{WithFundraiserCoverPhotoTransform(toJPEG), WithFundraiserCoverPhotoFile(path)},
+	} {
+		contentType = ""
+		if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}, options...); err != nil {
+			t.Fatalf("failed to create fundraiser %v", err)
+		}
+		if contentType != "image/jpeg" {
+			t.Errorf("expected transformed cover photo to be image/jpeg, got %q", contentType)
+		}
+	}
+}
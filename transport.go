@@ -0,0 +1,132 @@
+package flannel
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// transport returns the http.Transport used by the APIClient, creating one from the
+// default transport settings so it can be tuned without changing http.DefaultTransport.
+func (c *APIClient) transport() (*http.Transport, error) {
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		return t, nil
+	}
+	if c.httpClient.Transport != nil {
+		return nil, errors.New("http client transport is not an *http.Transport")
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	c.httpClient.Transport = t
+	return t, nil
+}
+
+// WithHTTPTransport sets the http.RoundTripper used for API calls and cover photo downloads, such as one
+// recording requests or serving them from a fake Graph API. Options tuning the transport, such as WithTimeouts,
+// require an *http.Transport.
+func WithHTTPTransport(transport http.RoundTripper) func(*APIClient) error {
+	return func(c *APIClient) error {
+		c.httpClient.Transport = transport
+		return nil
+	}
+}
+
+// WithTransportTuning configures the connection pool used for API calls.
+// The default transport only keeps 2 idle connections per host, which throttles parallel API calls.
+func WithTransportTuning(maxIdleConns int, maxIdleConnsPerHost int, idleTimeout time.Duration) func(*APIClient) error {
+	return func(c *APIClient) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		t.MaxIdleConns = maxIdleConns
+		t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		t.IdleConnTimeout = idleTimeout
+		return nil
+	}
+}
+
+// Timeouts configures the timeouts of each phase of an API call.
+// Zero values leave the existing timeout unchanged.
+type Timeouts struct {
+
+	// Dial limits the time spent establishing a TCP connection.
+	Dial time.Duration
+
+	// TLSHandshake limits the time spent performing the TLS handshake.
+	TLSHandshake time.Duration
+
+	// ResponseHeader limits the time spent waiting for response headers after the request has been written.
+	ResponseHeader time.Duration
+
+	// Overall limits the total time of an API call, including reading the response body, defaults to 20 seconds.
+	Overall time.Duration
+}
+
+// WithTimeouts replaces the single overall timeout with per-phase timeouts, so that
+// connection failures fail fast whilst slow responses are still bounded.
+func WithTimeouts(timeouts Timeouts) func(*APIClient) error {
+	return func(c *APIClient) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		if timeouts.Dial > 0 {
+			d := c.currentDialer()
+			d.Timeout = timeouts.Dial
+			c.dialer = d
+			t.DialContext = d.DialContext
+		}
+		if timeouts.TLSHandshake > 0 {
+			t.TLSHandshakeTimeout = timeouts.TLSHandshake
+		}
+		if timeouts.ResponseHeader > 0 {
+			t.ResponseHeaderTimeout = timeouts.ResponseHeader
+		}
+		if timeouts.Overall > 0 {
+			c.httpClient.Timeout = timeouts.Overall
+		}
+		return nil
+	}
+}
+
+// WithDialer sets the net.Dialer used to connect to the Graph API and cover photo hosts, for example one with a
+// LocalAddr on IPv6-only clusters, instead of patching http.DefaultTransport. It requires an *http.Transport.
+func WithDialer(dialer *net.Dialer) func(*APIClient) error {
+	return func(c *APIClient) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		d := *dialer
+		c.dialer = &d
+		t.DialContext = d.DialContext
+		return nil
+	}
+}
+
+// WithResolver resolves the Graph API and cover photo hosts with resolver, such as one querying internal
+// split-horizon DNS, instead of the system resolver. It requires an *http.Transport.
+func WithResolver(resolver *net.Resolver) func(*APIClient) error {
+	return func(c *APIClient) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		d := c.currentDialer()
+		d.Resolver = resolver
+		c.dialer = d
+		t.DialContext = d.DialContext
+		return nil
+	}
+}
+
+// currentDialer returns a copy of the net.Dialer set by WithDialer, WithResolver or WithTimeouts, or one with the
+// settings of http.DefaultTransport.
+func (c *APIClient) currentDialer() *net.Dialer {
+	if c.dialer == nil {
+		return &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	}
+	d := *c.dialer
+	return &d
+}
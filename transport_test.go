@@ -0,0 +1,86 @@
+package flannel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithTransportTuning(t *testing.T) {
+	c, err := CreateAPIClient(WithTransportTuning(200, 50, time.Minute))
+	if err != nil {
+		t.Fatalf("failed to create api client %v", err)
+	}
+	tr, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", c.httpClient.Transport)
+	}
+	if tr == http.DefaultTransport {
+		t.Errorf("expected http.DefaultTransport not to be modified")
+	}
+	if tr.MaxIdleConns != 200 || tr.MaxIdleConnsPerHost != 50 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("unexpected transport settings %d %d %v", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr.Proxy == nil {
+		t.Errorf("expected default proxy settings to be kept")
+	}
+}
+
+func TestWithTimeouts(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		fundraiserCreated(w, r)
+	}), WithTimeouts(Timeouts{ResponseHeader: 20 * time.Millisecond, Overall: time.Minute}))
+	if c.httpClient.Timeout != time.Minute {
+		t.Errorf("expected overall timeout to be set, got %v", c.httpClient.Timeout)
+	}
+	_, _, err := c.CreateFundraiser(CreateFundraiserParams{})
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("expected response header timeout, got %v", err)
+	}
+}
+
+func TestWithDialerAndResolver(t *testing.T) {
+	srv := httptest.NewTLSServer(fundraiserCreated)
+	defer srv.Close()
+	var dialed []string
+	dialer := &net.Dialer{Control: func(network, address string, conn syscall.RawConn) error {
+		dialed = append(dialed, address)
+		return nil
+	}}
+	c, err := CreateAPIClient(WithTimeouts(Timeouts{Dial: time.Second}), WithDialer(dialer),
+		WithBaseHost(srv.Listener.Addr().String()))
+	if err != nil {
+		t.Fatalf("failed to create api client %v", err)
+	}
+	c.httpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); err != nil {
+		t.Fatalf("failed to create fundraiser %v", err)
+	}
+	if len(dialed) != 1 || dialed[0] != srv.Listener.Addr().String() {
+		t.Errorf("expected dialer to be used, got %v", dialed)
+	}
+
+	var queried bool
+	resolver := &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		queried = true
+		return nil, errors.New("internal dns unavailable")
+	}}
+	c, err = CreateAPIClient(WithResolver(resolver), WithBaseHost("fundraisers.internal.example"))
+	if err != nil {
+		t.Fatalf("failed to create api client %v", err)
+	}
+	if _, _, err := c.CreateFundraiser(CreateFundraiserParams{}); err == nil {
+		t.Errorf("expected error resolving host")
+	}
+	if !queried {
+		t.Errorf("expected resolver to be used")
+	}
+}
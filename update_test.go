@@ -0,0 +1,27 @@
+package flannel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateFundraiser(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v2.8/1234" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.FormValue("name"); got != "Updated" {
+			t.Errorf("unexpected name %q", got)
+		}
+		fmt.Fprint(w, `{"success":true}`)
+	}))
+	_, result, err := c.UpdateFundraiser(context.Background(), "token", "1234", map[string]string{"name": "Updated"})
+	if err != nil {
+		t.Fatalf("failed to update fundraiser %v", err)
+	}
+	if result["success"] != true {
+		t.Errorf("unexpected result %v", result)
+	}
+}
@@ -0,0 +1,380 @@
+package flannel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookPayload is the body of a webhook request sent by Facebook.
+// See https://developers.facebook.com/docs/graph-api/webhooks/getting-started
+type WebhookPayload struct {
+	Object string         `json:"object"`
+	Entry  []WebhookEntry `json:"entry"`
+}
+
+// WebhookEntry is an object with changes in a WebhookPayload.
+type WebhookEntry struct {
+	ID      string          `json:"id"`
+	Time    int64           `json:"time"`
+	Changes []WebhookChange `json:"changes"`
+}
+
+// WebhookChange is a change to a field of a WebhookEntry.
+type WebhookChange struct {
+	Field string          `json:"field"`
+	Value json.RawMessage `json:"value"`
+}
+
+// WebhookEventType identifies the type of a WebhookEvent.
+type WebhookEventType string
+
+// Webhook event types, events for other fields have the type of the field.
+const (
+	DonationCreated   WebhookEventType = "donation_created"
+	FundraiserUpdated WebhookEventType = "fundraiser_updated"
+)
+
+// webhookFields maps the fields of changes to event types.
+var webhookFields = map[string]WebhookEventType{
+	"donations":  DonationCreated,
+	"fundraiser": FundraiserUpdated,
+}
+
+// WebhookDonation is the value of a donations change.
+type WebhookDonation struct {
+	ID           string `json:"id"`
+	FundraiserID string `json:"fundraiser_id"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency"`
+	CreatedTime  string `json:"created_time"`
+}
+
+// WebhookFundraiser is the value of a fundraiser change.
+type WebhookFundraiser struct {
+	ID            string   `json:"id"`
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// A WebhookEvent is a single change received by a webhook, as passed to WebhookSettings OnEvent.
+type WebhookEvent struct {
+	Type    WebhookEventType
+	Object  string
+	EntryID string
+	Time    time.Time
+	Field   string
+
+	// Donation is set for DonationCreated events.
+	Donation *WebhookDonation
+
+	// Fundraiser is set for FundraiserUpdated events.
+	Fundraiser *WebhookFundraiser
+
+	// Value is the value of the change as received, including any fields not yet decoded into Donation or
+	// Fundraiser, so that new fields can be used before they are added.
+	Value json.RawMessage
+}
+
+// DecodeValue decodes the value of the change into v, for example a struct with fields added by Facebook
+// which are not yet decoded into Donation or Fundraiser.
+func (e WebhookEvent) DecodeValue(v interface{}) error {
+	if len(e.Value) == 0 {
+		return fmt.Errorf("webhook %s change has no value", e.Field)
+	}
+	return json.Unmarshal(e.Value, v)
+}
+
+// WebhookSettings configures a WebhookHandler.
+type WebhookSettings struct {
+
+	// AppSecret is the secret of the Facebook app, used to verify the signature of webhook requests.
+	AppSecret string
+
+	// PreviousAppSecrets are also accepted during secret rotation, until Facebook signs requests with AppSecret.
+	PreviousAppSecrets []string
+
+	// Metrics, if set, is told which app secret matched the signature of each request.
+	Metrics SecretMetrics
+
+	// VerifyToken is the token set when subscribing to webhooks, used to verify the subscription.
+	VerifyToken string
+
+	// OnEvent is called for each event received. If it returns an error the webhook request fails,
+	// and is retried by Facebook.
+	OnEvent func(ctx context.Context, event WebhookEvent) error
+
+	// MaxBodySize limits the size of webhook requests, defaults to 1MB.
+	MaxBodySize int64
+
+	// RequireSHA256 rejects requests signed only with SHA-1 in the X-Hub-Signature header.
+	RequireSHA256 bool
+
+	// MaxEventAge, if set, rejects requests with entries older than it, so that captured requests cannot be
+	// replayed later. Facebook retries failed webhooks for up to 36 hours, so it should be longer than that.
+	MaxEventAge time.Duration
+
+	// MaxClockSkew is how far in the future entries may be when MaxEventAge is set, defaults to 5 minutes.
+	MaxClockSkew time.Duration
+
+	// Clock is used to check the age of entries, defaults to SystemClock.
+	Clock Clock
+
+	// OnReject, if set, is called with the reason each rejected request was rejected.
+	OnReject func(r *http.Request, reason WebhookRejectReason)
+
+	// Tenant, if set, returns the tenant of a request, for example from a path parameter of the route, which is
+	// added to the context passed to OnEvent as if by ContextWithTenant.
+	//
+	// The path is not covered by the signature of the request, so unless TenantAppSecrets is also set, a request
+	// signed for one tenant can be replayed to the route of another. When tenants share a Facebook app, the tenant
+	// of each event should instead be derived from its payload, such as the fundraiser of a donation.
+	Tenant func(r *http.Request) string
+
+	// TenantAppSecrets, if set, returns the app secret of the Facebook app of tenant, as returned by Tenant,
+	// followed by any previous app secrets still accepted during rotation. The signature of each request is then
+	// verified with the secrets of its tenant, rather than AppSecret and PreviousAppSecrets, so that requests are
+	// rejected unless signed for their tenant. Requests for tenants without an app secret are rejected.
+	TenantAppSecrets func(tenant string) []string
+}
+
+// WebhookRejectReason is why a WebhookHandler rejected a request.
+type WebhookRejectReason string
+
+// Reasons webhook requests are rejected.
+const (
+	WebhookRejectVerifyToken        WebhookRejectReason = "verify_token"
+	WebhookRejectBodyTooLarge       WebhookRejectReason = "body_too_large"
+	WebhookRejectSignatureMissing   WebhookRejectReason = "signature_missing"
+	WebhookRejectSignatureAlgorithm WebhookRejectReason = "signature_algorithm"
+	WebhookRejectSignatureInvalid   WebhookRejectReason = "signature_invalid"
+	WebhookRejectMalformed          WebhookRejectReason = "malformed"
+	WebhookRejectStale              WebhookRejectReason = "stale"
+	WebhookRejectFuture             WebhookRejectReason = "future"
+)
+
+// A WebhookHandler is an http.Handler receiving Facebook webhooks. It responds to subscription verification
+// requests, verifies the signature of event notifications and passes each event to OnEvent.
+//
+// Routers which match the method can route to Verify and Receive directly, for example with a tenant path parameter:
+//
+//	mux.HandleFunc("GET /webhooks/{tenant}", h.Verify)
+//	mux.HandleFunc("POST /webhooks/{tenant}", h.Receive)
+//
+// with WebhookSettings Tenant returning r.PathValue("tenant").
+type WebhookHandler struct {
+	settings WebhookSettings
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(settings WebhookSettings) *WebhookHandler {
+	if settings.MaxBodySize <= 0 {
+		settings.MaxBodySize = 1024 * 1024
+	}
+	if settings.MaxClockSkew <= 0 {
+		settings.MaxClockSkew = 5 * time.Minute
+	}
+	return &WebhookHandler{settings: settings}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.Verify(w, r)
+	case http.MethodPost:
+		h.Receive(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Verify responds to the GET request sent by Facebook when subscribing to webhooks.
+func (h *WebhookHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("hub.mode") != "subscribe" || h.settings.VerifyToken == "" ||
+		!hmac.Equal([]byte(query.Get("hub.verify_token")), []byte(h.settings.VerifyToken)) {
+		h.reject(w, r, WebhookRejectVerifyToken, "invalid verify token", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, query.Get("hub.challenge"))
+}
+
+// Receive verifies the signature of the POST request of an event notification and passes each event to OnEvent.
+func (h *WebhookHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, h.settings.MaxBodySize+1))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > h.settings.MaxBodySize {
+		h.reject(w, r, WebhookRejectBodyTooLarge, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var tenant string
+	if h.settings.Tenant != nil {
+		tenant = h.settings.Tenant(r)
+	}
+	secrets := append([]string{h.settings.AppSecret}, h.settings.PreviousAppSecrets...)
+	if h.settings.TenantAppSecrets != nil {
+		secrets = h.settings.TenantAppSecrets(tenant)
+	}
+	matched, err := verifyWebhookSignature(secrets, body, r.Header, h.settings.RequireSHA256)
+	if err != nil {
+		var rejected webhookSignatureError
+		if errors.As(err, &rejected) {
+			h.reject(w, r, rejected.reason, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if h.settings.Metrics != nil {
+		h.settings.Metrics.ObserveSecretMatch(SecretUseWebhook, matched)
+	}
+	events, err := decodeWebhookEvents(body)
+	if err != nil {
+		h.reject(w, r, WebhookRejectMalformed, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if reason, fresh := h.fresh(events); !fresh {
+		h.reject(w, r, reason, "webhook entry outside acceptance window", http.StatusForbidden)
+		return
+	}
+	ctx := r.Context()
+	if tenant != "" {
+		ctx = ContextWithTenant(ctx, tenant)
+	}
+	for _, event := range events {
+		if h.settings.OnEvent == nil {
+			break
+		}
+		if err := h.settings.OnEvent(ctx, event); err != nil {
+			http.Error(w, "error processing event", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// reject responds to a rejected request with msg and status, reporting reason to OnReject.
+func (h *WebhookHandler) reject(w http.ResponseWriter, r *http.Request, reason WebhookRejectReason, msg string, status int) {
+	if h.settings.OnReject != nil {
+		h.settings.OnReject(r, reason)
+	}
+	http.Error(w, msg, status)
+}
+
+// fresh checks the time of each event is within the acceptance window, if MaxEventAge is set.
+func (h *WebhookHandler) fresh(events []WebhookEvent) (WebhookRejectReason, bool) {
+	if h.settings.MaxEventAge <= 0 {
+		return "", true
+	}
+	now := clockOrSystem(h.settings.Clock).Now()
+	for _, event := range events {
+		switch {
+		case now.Sub(event.Time) > h.settings.MaxEventAge:
+			return WebhookRejectStale, false
+		case event.Time.Sub(now) > h.settings.MaxClockSkew:
+			return WebhookRejectFuture, false
+		}
+	}
+	return "", true
+}
+
+type webhookSignatureError struct {
+	reason WebhookRejectReason
+}
+
+func (e webhookSignatureError) Error() string {
+	if e.reason == WebhookRejectSignatureAlgorithm {
+		return "webhook signature algorithm not accepted"
+	}
+	return "invalid webhook signature"
+}
+
+var errWebhookSignature = webhookSignatureError{reason: WebhookRejectSignatureInvalid}
+
+// verifyWebhookSignature verifies the X-Hub-Signature-256 header of a webhook request, or the X-Hub-Signature
+// header if it is not set and requireSHA256 is not, returning the index of the app secret which matched.
+func verifyWebhookSignature(appSecrets []string, body []byte, header http.Header, requireSHA256 bool) (int, error) {
+	if len(appSecrets) == 0 || appSecrets[0] == "" {
+		return 0, errors.New("app secret is not set")
+	}
+	var h func() hash.Hash
+	signature := header.Get("X-Hub-Signature-256")
+	switch {
+	case strings.HasPrefix(signature, "sha256="):
+		h, signature = sha256.New, strings.TrimPrefix(signature, "sha256=")
+	case strings.HasPrefix(header.Get("X-Hub-Signature"), "sha1="):
+		if requireSHA256 {
+			return 0, webhookSignatureError{reason: WebhookRejectSignatureAlgorithm}
+		}
+		h, signature = sha1.New, strings.TrimPrefix(header.Get("X-Hub-Signature"), "sha1=")
+	default:
+		return 0, webhookSignatureError{reason: WebhookRejectSignatureMissing}
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return 0, errWebhookSignature
+	}
+	for i, appSecret := range appSecrets {
+		if appSecret == "" {
+			continue
+		}
+		mac := hmac.New(h, []byte(appSecret))
+		mac.Write(body)
+		if hmac.Equal(mac.Sum(nil), expected) {
+			return i, nil
+		}
+	}
+	return 0, errWebhookSignature
+}
+
+// decodeWebhookEvents decodes the events of a webhook request body.
+func decodeWebhookEvents(body []byte) ([]WebhookEvent, error) {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("error parsing webhook %v", err)
+	}
+	var events []WebhookEvent
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			event := WebhookEvent{
+				Type:    webhookFields[change.Field],
+				Object:  payload.Object,
+				EntryID: entry.ID,
+				Time:    time.Unix(entry.Time, 0),
+				Field:   change.Field,
+				Value:   change.Value,
+			}
+			var err error
+			switch event.Type {
+			case DonationCreated:
+				event.Donation = &WebhookDonation{}
+				err = json.Unmarshal(change.Value, event.Donation)
+			case FundraiserUpdated:
+				event.Fundraiser = &WebhookFundraiser{}
+				err = json.Unmarshal(change.Value, event.Fundraiser)
+			default:
+				event.Type = WebhookEventType(change.Field)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error parsing webhook %s change %v", change.Field, err)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
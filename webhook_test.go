@@ -0,0 +1,191 @@
+package flannel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	body := `{"object":"fundraiser","entry":[{"id":"1234","time":1767366245,"changes":[{"field":"donations","value":{"id":"1","fundraiser_id":"1234","amount":500}},{"field":"other","value":{}}]}]}`
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(body))
+	var types []WebhookEventType
+	fail := false
+	h := NewWebhookHandler(WebhookSettings{AppSecret: "secret", OnEvent: func(ctx context.Context, event WebhookEvent) error {
+		if fail {
+			return errors.New("unavailable")
+		}
+		types = append(types, event.Type)
+		return nil
+	}})
+	send := func(signature string) int {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("X-Hub-Signature", signature)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := send("sha1=" + hex.EncodeToString(mac.Sum(nil))); code != http.StatusOK {
+		t.Errorf("expected SHA-1 signed webhook to be accepted, got %d", code)
+	}
+	if len(types) != 2 || types[0] != DonationCreated || types[1] != "other" {
+		t.Errorf("unexpected events %v", types)
+	}
+	if code := send("sha1=00"); code != http.StatusForbidden {
+		t.Errorf("expected invalid signature to be rejected, got %d", code)
+	}
+	fail = true
+	if code := send("sha1=" + hex.EncodeToString(mac.Sum(nil))); code != http.StatusInternalServerError {
+		t.Errorf("expected failed event to fail the webhook, got %d", code)
+	}
+}
+
+func TestWebhookAcceptanceWindow(t *testing.T) {
+	body := `{"object":"fundraiser","entry":[{"id":"1234","time":1767366245,"changes":[{"field":"other","value":{}}]}]}`
+	sign := func(h func() hash.Hash) string {
+		mac := hmac.New(h, []byte("secret"))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	clock := newTestClock(time.Unix(1767366245, 0).Add(time.Hour))
+	var reasons []WebhookRejectReason
+	h := NewWebhookHandler(WebhookSettings{
+		AppSecret:     "secret",
+		RequireSHA256: true,
+		MaxEventAge:   48 * time.Hour,
+		Clock:         clock,
+		OnReject:      func(r *http.Request, reason WebhookRejectReason) { reasons = append(reasons, reason) },
+	})
+	send := func(header string, signature string) int {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set(header, signature)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := send("X-Hub-Signature-256", "sha256="+sign(sha256.New)); code != http.StatusOK {
+		t.Errorf("expected fresh SHA-256 signed webhook to be accepted, got %d", code)
+	}
+	if code := send("X-Hub-Signature", "sha1="+sign(sha1.New)); code != http.StatusForbidden {
+		t.Errorf("expected SHA-1 signed webhook to be rejected, got %d", code)
+	}
+	send("X-Other", "")
+	clock.advance(48 * time.Hour)
+	if code := send("X-Hub-Signature-256", "sha256="+sign(sha256.New)); code != http.StatusForbidden {
+		t.Errorf("expected replayed webhook to be rejected, got %d", code)
+	}
+	clock = newTestClock(time.Unix(1767366245, 0).Add(-time.Hour))
+	h.settings.Clock = clock
+	send("X-Hub-Signature-256", "sha256="+sign(sha256.New))
+
+	expected := []WebhookRejectReason{WebhookRejectSignatureAlgorithm, WebhookRejectSignatureMissing, WebhookRejectStale, WebhookRejectFuture}
+	if fmt.Sprint(reasons) != fmt.Sprint(expected) {
+		t.Errorf("expected rejection reasons %v, got %v", expected, reasons)
+	}
+}
+
+func TestWebhookEventValue(t *testing.T) {
+	body := []byte(`{"object":"fundraiser","entry":[{"id":"1234","time":1767366245,"changes":[{"field":"donations","value":{"id":"1","fundraiser_id":"1234","amount":500,"gift_aid":true}}]}]}`)
+	events, err := decodeWebhookEvents(body)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("unexpected events %v %v", events, err)
+	}
+	var donation struct {
+		WebhookDonation
+		GiftAid bool `json:"gift_aid"`
+	}
+	if err := events[0].DecodeValue(&donation); err != nil || !donation.GiftAid || donation.Amount != 500 {
+		t.Errorf("expected fields not yet decoded to be available, got %+v %v", donation, err)
+	}
+}
+
+func TestWebhookRoutes(t *testing.T) {
+	body := `{"object":"fundraiser","entry":[{"id":"1234","time":1767366245,"changes":[{"field":"other","value":{}}]}]}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	var tenants []string
+	h := NewWebhookHandler(WebhookSettings{
+		AppSecret:   "secret",
+		VerifyToken: "verify",
+		Tenant:      func(r *http.Request) string { return r.PathValue("tenant") },
+		OnEvent: func(ctx context.Context, event WebhookEvent) error {
+			tenant, _ := TenantFromContext(ctx)
+			tenants = append(tenants, tenant)
+			return nil
+		},
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /webhooks/{tenant}", h.Verify)
+	mux.HandleFunc("POST /webhooks/{tenant}", h.Receive)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/webhooks/acme?hub.mode=subscribe&hub.verify_token=verify&hub.challenge=abc", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "abc" {
+		t.Errorf("expected subscription to be verified, got %d %s", w.Code, w.Body.String())
+	}
+	r := httptest.NewRequest("POST", "/webhooks/acme", strings.NewReader(body))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || len(tenants) != 1 || tenants[0] != "acme" {
+		t.Errorf("expected event with tenant from path, got %d %v", w.Code, tenants)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("PUT", "/webhooks/acme", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected other methods not to be allowed, got %d", w.Code)
+	}
+}
+
+func TestWebhookTenantAppSecrets(t *testing.T) {
+	body := `{"object":"fundraiser","entry":[{"id":"1234","time":1767366245,"changes":[{"field":"other","value":{}}]}]}`
+	secrets := map[string][]string{"acme": {"acme-secret", "acme-previous"}, "globex": {"globex-secret"}}
+	var tenants []string
+	h := NewWebhookHandler(WebhookSettings{
+		AppSecret:        "secret",
+		Tenant:           func(r *http.Request) string { return r.PathValue("tenant") },
+		TenantAppSecrets: func(tenant string) []string { return secrets[tenant] },
+		OnEvent: func(ctx context.Context, event WebhookEvent) error {
+			tenant, _ := TenantFromContext(ctx)
+			tenants = append(tenants, tenant)
+			return nil
+		},
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhooks/{tenant}", h.Receive)
+	receive := func(tenant string, secret string) int {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		r := httptest.NewRequest("POST", "/webhooks/"+tenant, strings.NewReader(body))
+		r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if status := receive("acme", "acme-previous"); status != http.StatusOK {
+		t.Errorf("expected request signed for tenant to be accepted, got %d", status)
+	}
+	if status := receive("globex", "acme-secret"); status != http.StatusForbidden {
+		t.Errorf("expected request replayed to another tenant to be rejected, got %d", status)
+	}
+	if status := receive("initech", "secret"); status != http.StatusForbidden {
+		t.Errorf("expected request for unknown tenant to be rejected, got %d", status)
+	}
+	if fmt.Sprint(tenants) != "[acme]" {
+		t.Errorf("unexpected tenants %v", tenants)
+	}
+}
@@ -0,0 +1,319 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A WebhookJob is a webhook event waiting to be processed by a WebhookQueue.
+type WebhookJob struct {
+
+	// ID identifies the job, it is set by Enqueue.
+	ID string
+
+	Event WebhookEvent
+
+	// ReceivedAt is when the event was enqueued.
+	ReceivedAt time.Time
+
+	// Attempts is the number of attempts made to process the event.
+	Attempts int
+
+	// NextAttempt is when the event should next be attempted.
+	NextAttempt time.Time
+
+	// LastError is the error returned by the last attempt.
+	LastError string
+}
+
+// WebhookStore is the interface implemented by durable stores of the jobs of a WebhookQueue, so that events
+// received are not lost if processing fails or the process exits.
+type WebhookStore interface {
+
+	// Save inserts or updates job.
+	Save(ctx context.Context, job WebhookJob) error
+
+	// Delete removes the job with id.
+	Delete(ctx context.Context, id string) error
+
+	// Pending returns all saved jobs.
+	Pending(ctx context.Context) ([]WebhookJob, error)
+}
+
+// A MemoryWebhookStore is an in-memory WebhookStore, jobs are lost when the process exits.
+type MemoryWebhookStore struct {
+	mu   sync.Mutex
+	jobs map[string]WebhookJob
+}
+
+// NewMemoryWebhookStore creates a new MemoryWebhookStore.
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{jobs: make(map[string]WebhookJob)}
+}
+
+// Save inserts or updates job.
+func (s *MemoryWebhookStore) Save(ctx context.Context, job WebhookJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Delete removes the job with id.
+func (s *MemoryWebhookStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// Pending returns all saved jobs, oldest first.
+func (s *MemoryWebhookStore) Pending(ctx context.Context) ([]WebhookJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]WebhookJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ReceivedAt.Before(jobs[j].ReceivedAt) })
+	return jobs, nil
+}
+
+// WebhookQueueSettings configures a WebhookQueue.
+type WebhookQueueSettings struct {
+
+	// OnEvent is called to process each event, which is retried if it returns an error.
+	OnEvent func(ctx context.Context, event WebhookEvent) error
+
+	// Workers is the number of events processed at the same time, defaults to 1.
+	Workers int
+
+	// MaxAttempts is the maximum number of attempts made for each event, defaults to 10.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry of an event, which doubles for each subsequent retry, defaults to 5 seconds.
+	Backoff time.Duration
+
+	// OnDeadLetter is called with events which ran out of attempts, before they are removed from the store.
+	OnDeadLetter func(job WebhookJob, err error)
+
+	// Clock schedules retries, defaults to SystemClock.
+	Clock Clock
+}
+
+// A WebhookQueue processes webhook events at least once, decoupling the webhook handler from downstream processing.
+// Events are persisted to a WebhookStore before the webhook request is acknowledged, and are processed by a pool
+// of workers which retry failed events with exponential backoff. As events may be processed more than once,
+// OnEvent should be idempotent, for example using the ID of donations.
+//
+//	q := flannel.NewWebhookQueue(store, flannel.WebhookQueueSettings{OnEvent: process})
+//	q.Start(ctx)
+//	h := flannel.NewWebhookHandler(flannel.WebhookSettings{AppSecret: secret, OnEvent: q.Enqueue})
+type WebhookQueue struct {
+	store    WebhookStore
+	settings WebhookQueueSettings
+
+	mu      sync.Mutex
+	ready   []WebhookJob
+	timers  map[string]func() bool
+	started bool
+	closed  bool
+	notify  chan struct{}
+	quit    chan struct{}
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewWebhookQueue creates a new WebhookQueue.
+func NewWebhookQueue(store WebhookStore, settings WebhookQueueSettings) *WebhookQueue {
+	if settings.Workers < 1 {
+		settings.Workers = 1
+	}
+	if settings.MaxAttempts < 1 {
+		settings.MaxAttempts = 10
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = 5 * time.Second
+	}
+	return &WebhookQueue{
+		store:    store,
+		settings: settings,
+		timers:   make(map[string]func() bool),
+		notify:   make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start resumes any pending events from the store and starts the workers.
+// The ctx is passed to OnEvent.
+func (q *WebhookQueue) Start(ctx context.Context) error {
+	q.mu.Lock()
+	if q.started || q.closed {
+		q.mu.Unlock()
+		return errors.New("queue already started")
+	}
+	q.started = true
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.mu.Unlock()
+
+	jobs, err := q.store.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		q.schedule(job)
+	}
+	for i := 0; i < q.settings.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	return nil
+}
+
+// Enqueue persists event for processing, it can be used as the OnEvent of WebhookSettings so that webhook requests
+// are only acknowledged once their events are saved.
+func (q *WebhookQueue) Enqueue(ctx context.Context, event WebhookEvent) error {
+	q.mu.Lock()
+	closed, started := q.closed, q.started
+	q.mu.Unlock()
+	if closed {
+		return errQueueClosed
+	}
+	id, err := newJobID()
+	if err != nil {
+		return err
+	}
+	job := WebhookJob{ID: id, Event: event, ReceivedAt: clockOrSystem(q.settings.Clock).Now()}
+	if err := q.store.Save(ctx, job); err != nil {
+		return err
+	}
+	// jobs saved before the queue starts are resumed from the store by Start
+	if started {
+		q.schedule(job)
+	}
+	return nil
+}
+
+// Close stops the workers once any events being processed have completed.
+// Events not yet processed remain in the store and are resumed by the next Start.
+func (q *WebhookQueue) Close() error {
+	return q.Shutdown(context.Background())
+}
+
+// Shutdown stops the workers once any events being processed have completed, cancelling them if ctx is done first,
+// in which case the error of ctx is returned. Events not yet completed, including those cancelled, remain in the
+// store and are resumed by the next Start.
+func (q *WebhookQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	for id, stop := range q.timers {
+		stop()
+		delete(q.timers, id)
+	}
+	close(q.quit)
+	cancel := q.cancel
+	q.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	defer cancel()
+	return drain(ctx, &q.wg, cancel)
+}
+
+// schedule makes job ready for a worker at its NextAttempt.
+func (q *WebhookQueue) schedule(job WebhookJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	if delay := job.NextAttempt.Sub(clockOrSystem(q.settings.Clock).Now()); delay > 0 {
+		q.timers[job.ID] = clockOrSystem(q.settings.Clock).AfterFunc(delay, func() {
+			q.mu.Lock()
+			delete(q.timers, job.ID)
+			q.mu.Unlock()
+			job.NextAttempt = time.Time{}
+			q.schedule(job)
+		})
+		return
+	}
+	q.ready = append(q.ready, job)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the next ready job, blocking until one is available or the queue is closed.
+func (q *WebhookQueue) next() (WebhookJob, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return WebhookJob{}, false
+		}
+		if len(q.ready) > 0 {
+			job := q.ready[0]
+			q.ready = q.ready[1:]
+			more := len(q.ready) > 0
+			q.mu.Unlock()
+			if more {
+				// wake another worker
+				select {
+				case q.notify <- struct{}{}:
+				default:
+				}
+			}
+			return job, true
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.notify:
+		case <-q.quit:
+		}
+	}
+}
+
+func (q *WebhookQueue) work(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+		q.process(ctx, job)
+	}
+}
+
+func (q *WebhookQueue) process(ctx context.Context, job WebhookJob) {
+	job.Attempts++
+	var err error
+	if q.settings.OnEvent != nil {
+		err = q.settings.OnEvent(ctx, job.Event)
+	}
+	if err != nil {
+		job.LastError = err.Error()
+		if job.Attempts < q.settings.MaxAttempts && ctx.Err() == nil {
+			job.NextAttempt = clockOrSystem(q.settings.Clock).Now().Add(q.settings.Backoff << uint(job.Attempts-1))
+			// the event remains in the store with its previous attempts if saving fails
+			q.store.Save(ctx, job)
+			q.schedule(job)
+			return
+		}
+		if ctx.Err() != nil {
+			// leave the event in the store to be resumed
+			return
+		}
+		if q.settings.OnDeadLetter != nil {
+			q.settings.OnDeadLetter(job, err)
+		}
+	}
+	q.store.Delete(ctx, job.ID)
+}
@@ -0,0 +1,80 @@
+package flannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookQueue(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	processed := make(chan string, 2)
+	dead := make(chan WebhookJob, 1)
+	store := NewMemoryWebhookStore()
+	q := NewWebhookQueue(store, WebhookQueueSettings{
+		Workers:     2,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		OnEvent: func(ctx context.Context, event WebhookEvent) error {
+			mu.Lock()
+			attempts[event.Donation.ID]++
+			n := attempts[event.Donation.ID]
+			mu.Unlock()
+			// downstream is briefly unavailable, or always for donation 3
+			if n == 1 || event.Donation.ID == "3" {
+				return errors.New("unavailable")
+			}
+			processed <- event.Donation.ID
+			return nil
+		},
+		OnDeadLetter: func(job WebhookJob, err error) {
+			dead <- job
+		},
+	})
+	ctx := context.Background()
+	event := func(id string) WebhookEvent {
+		return WebhookEvent{Type: DonationCreated, Donation: &WebhookDonation{ID: id}}
+	}
+
+	// events enqueued before the queue is started are resumed from the store
+	if err := q.Enqueue(ctx, event("1")); err != nil {
+		t.Fatalf("failed to enqueue event %v", err)
+	}
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("failed to start queue %v", err)
+	}
+	defer q.Close()
+	for _, id := range []string{"2", "3"} {
+		if err := q.Enqueue(ctx, event(id)); err != nil {
+			t.Fatalf("failed to enqueue event %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-processed:
+			seen[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events")
+		}
+	}
+	if !seen["1"] || !seen["2"] {
+		t.Errorf("expected events 1 and 2 to be processed, got %v", seen)
+	}
+	select {
+	case job := <-dead:
+		if job.Event.Donation.ID != "3" || job.Attempts != 3 || job.LastError != "unavailable" {
+			t.Errorf("unexpected dead letter %+v", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for dead letter")
+	}
+	q.Close()
+	if pending, _ := store.Pending(ctx); len(pending) != 0 {
+		t.Errorf("expected no pending events, got %d", len(pending))
+	}
+}